@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+
+	"github.com/opencontainers/runc/internal/ociimage"
+	"github.com/opencontainers/runc/libcontainer/specconv"
+)
+
+var bundleCommand = &cli.Command{
+	Name:  "bundle",
+	Usage: "manage bundles",
+	Commands: []*cli.Command{
+		bundleCreateCommand,
+	},
+}
+
+var bundleCreateCommand = &cli.Command{
+	Name:  "create",
+	Usage: "create a bundle from an OCI image",
+	ArgsUsage: `
+
+The bundle is created in the directory specified via the "--bundle" option
+(default: the current directory).`,
+	Description: `The bundle create command creates a bundle from an image in a local OCI image
+layout (see https://github.com/opencontainers/image-spec). The image layers
+are unpacked into the "rootfs" directory of the bundle, and the image
+configuration is converted into the specification file "` + specConfig + `".
+
+The image is specified as "<path>[:<tag>]", where <path> is the image layout
+directory, and <tag> is the image reference name (the
+"org.opencontainers.image.ref.name" annotation in the image layout index). The
+tag may only be omitted if the image layout contains a single image.
+
+EXAMPLE:
+To create a bundle for the busybox image copied by skopeo into an image layout,
+and run it:
+
+    skopeo copy docker://busybox oci:/tmp/images:busybox
+    mkdir busybox
+    runc bundle create --image /tmp/images:busybox --bundle busybox
+    runc run --bundle busybox container1`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:     "image",
+			Usage:    "image to create the bundle from, as <path>[:<tag>]",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:    "bundle",
+			Aliases: []string{"b"},
+			Value:   "",
+			Usage:   "path to the root of the bundle directory",
+		},
+		&cli.BoolFlag{
+			Name:  "rootless",
+			Usage: "generate a configuration for a rootless container",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) (retErr error) {
+		if err := checkArgs(cmd, 0, exactArgs); err != nil {
+			return err
+		}
+		dir, tag := ociimage.SplitReference(cmd.String("image"))
+		img, err := ociimage.Open(dir, tag)
+		if err != nil {
+			return err
+		}
+
+		if bundle := cmd.String("bundle"); bundle != "" {
+			if err := os.Chdir(bundle); err != nil {
+				return err
+			}
+		}
+		if _, err := os.Stat(specConfig); err == nil {
+			return fmt.Errorf("File %s exists. Remove it first", specConfig)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		spec := specconv.Example()
+		rootfs := spec.Root.Path
+		// Do not unpack the image into an existing rootfs.
+		if err := os.Mkdir(rootfs, 0o755); err != nil {
+			return err
+		}
+		defer func() {
+			if retErr != nil {
+				if err := os.RemoveAll(rootfs); err != nil {
+					logrus.Warnf("unable to remove %s: %v", rootfs, err)
+				}
+			}
+		}()
+		rootless := cmd.Bool("rootless")
+		if err := img.Unpack(rootfs, rootless); err != nil {
+			return err
+		}
+		abs, err := filepath.Abs(rootfs)
+		if err != nil {
+			return err
+		}
+		if err := img.ConvertConfig(spec, abs); err != nil {
+			return err
+		}
+		if rootless {
+			specconv.ToRootless(spec)
+		}
+
+		data, err := json.MarshalIndent(spec, "", "\t")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(specConfig, data, 0o666)
+	},
+}
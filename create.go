@@ -2,12 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 
 	"github.com/urfave/cli/v3"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/configs/validate"
 )
 
+// dryRunResult is the output of runc create --dry-run.
+type dryRunResult struct {
+	// Config is the container configuration, as resolved from the spec.
+	Config *configs.Config `json:"config"`
+	// Mounts are the operations to set up the container root filesystem,
+	// in the order they are performed.
+	Mounts []libcontainer.MountOp `json:"mounts"`
+}
+
 var createCommand = &cli.Command{
 	Name:  "create",
 	Usage: "create a container",
@@ -23,10 +37,14 @@ filesystem.
 The specification file includes an args parameter. The args parameter is used
 to specify command(s) that get run when the container is started. To change the
 command(s) that get executed on start, edit the args parameter of the spec. See
-"runc spec --help" for more explanation.`,
+"runc spec --help" for more explanation.
+
+With --dry-run, the container is not created. Instead, the resolved container
+configuration and the list of operations to set up its root filesystem are
+printed in a JSON format.`,
 	// Disable comma as separator for slice flags.
 	DisableSliceFlagSeparator: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "bundle",
 			Aliases: []string{"b"},
@@ -59,11 +77,27 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
 		},
-	},
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "print the resolved configuration and mount operations instead of creating the container",
+		},
+	}, monitorFlags...),
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 1, exactArgs); err != nil {
 			return err
 		}
+		if cmd.Bool("dry-run") {
+			return dryRunContainer(cmd)
+		}
+		if ok, err := useMonitor(cmd, true); err != nil {
+			return err
+		} else if ok && !isMonitor() {
+			status, err := spawnMonitor(cmd)
+			if err == nil {
+				os.Exit(status)
+			}
+			return fmt.Errorf("runc create failed: %w", err)
+		}
 		status, err := startContainer(cmd, CT_ACT_CREATE, nil)
 		if err == nil {
 			// exit with the container's exit status so any external supervisor
@@ -73,3 +107,33 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 		return fmt.Errorf("runc create failed: %w", err)
 	},
 }
+
+// dryRunContainer prints the configuration and the mount operations of the
+// container which would be created by runc create.
+func dryRunContainer(cmd *cli.Command) error {
+	spec, err := setupSpec(cmd)
+	if err != nil {
+		return err
+	}
+	id := cmd.Args().First()
+	if id == "" {
+		return errEmptyID
+	}
+	if notifySocket := newNotifySocket(cmd, os.Getenv("NOTIFY_SOCKET"), id); notifySocket != nil {
+		notifySocket.setupSpec(spec)
+	}
+	config, err := createLibcontainerConfig(cmd, id, spec)
+	if err != nil {
+		return err
+	}
+	if err := validate.Validate(config); err != nil {
+		return err
+	}
+	mounts, err := libcontainer.MountPlan(config)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(dryRunResult{Config: config, Mounts: mounts})
+}
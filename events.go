@@ -6,7 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"sync"
+	"strings"
 	"time"
 
 	"github.com/opencontainers/cgroups"
@@ -16,6 +16,7 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sys/unix"
 )
 
 var eventsCommand = &cli.Command{
@@ -25,14 +26,36 @@ var eventsCommand = &cli.Command{
 
 Where "<container-id>" is the name for the instance of the container.`,
 	Description: `The events command displays information about the container. By default the
-information is displayed once every 5 seconds.`,
+information is displayed once every 5 seconds.
+
+With --lifecycle, the container lifecycle events (created, started, paused,
+resumed, exec-started, exec-exited, exited, and deleted) are displayed as well,
+starting from the container creation, until the container is deleted.
+
+With --format openmetrics, the container's stats are displayed once in the
+OpenMetrics text format. With --listen, the stats are instead served over HTTP
+in the OpenMetrics text format (so they can be scraped by Prometheus) until the
+container is stopped.
+
+With --pressure, pressure events are displayed when the tasks of the container
+are stalled waiting for a resource for too long, as tracked by the kernel
+pressure stall information (PSI). The argument is a trigger in the form of
+RESOURCE:KIND:STALL[:WINDOW], where RESOURCE is cpu, memory, or io, KIND is
+some or full, and a pressure event is sent once the stall time of some (or
+all) tasks exceeds STALL within WINDOW (2s by default). For example,
+--pressure memory:some:150ms:1s. This option can be specified multiple times,
+and requires cgroup v2.`,
 	// Disable comma as separator for slice flags.
 	DisableSliceFlagSeparator: true,
 	Flags: []cli.Flag{
 		&cli.DurationFlag{Name: "interval", Value: 5 * time.Second, Usage: "set the stats collection interval"},
 		&cli.BoolFlag{Name: "stats", Usage: "display the container's stats then exit"},
+		&cli.BoolFlag{Name: "lifecycle", Usage: "also display the container's lifecycle events until it is deleted"},
+		&cli.StringSliceFlag{Name: "pressure", Usage: "display pressure events for a PSI trigger (RESOURCE:KIND:STALL[:WINDOW]), can be specified multiple times"},
+		&cli.StringFlag{Name: "format", Value: "json", Usage: "select the output format: json or openmetrics (display the container's stats then exit)"},
+		&cli.StringFlag{Name: "listen", Usage: "serve the container's stats in the OpenMetrics format at the given address (unix:///path)"},
 	},
-	Action: func(_ context.Context, cmd *cli.Command) error {
+	Action: func(ctx context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 1, exactArgs); err != nil {
 			return err
 		}
@@ -44,74 +67,142 @@ information is displayed once every 5 seconds.`,
 		if duration <= 0 {
 			return errors.New("duration interval must be greater than 0")
 		}
-		status, err := container.Status()
-		if err != nil {
-			return err
+		if addr := cmd.String("listen"); addr != "" {
+			if cmd.IsSet("format") && cmd.String("format") != "openmetrics" {
+				return errors.New("--listen only supports the openmetrics format")
+			}
+			return serveMetrics(ctx, container, addr)
 		}
-		if status == libcontainer.Stopped {
-			return fmt.Errorf("container with id %s is not running", container.ID())
+		var openMetrics bool
+		switch f := cmd.String("format"); f {
+		case "json":
+		case "openmetrics":
+			openMetrics = true
+		default:
+			return fmt.Errorf("invalid format: %s", f)
 		}
-		var (
-			stats  = make(chan *libcontainer.Stats, 1)
-			events = make(chan *types.Event, 1024)
-			group  = &sync.WaitGroup{}
-		)
-		group.Go(func() {
-			enc := json.NewEncoder(os.Stdout)
-			for e := range events {
-				if err := enc.Encode(e); err != nil {
-					logrus.Error(err)
-				}
+		enc := json.NewEncoder(os.Stdout)
+		if cmd.Bool("stats") || openMetrics {
+			status, err := container.Status()
+			if err != nil {
+				return err
+			}
+			if status == libcontainer.Stopped {
+				return fmt.Errorf("container with id %s is not running", container.ID())
+			}
+			if openMetrics {
+				return writeMetrics(os.Stdout, container)
 			}
-		})
-		if cmd.Bool("stats") {
 			s, err := container.Stats()
 			if err != nil {
 				return err
 			}
-			events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
-			close(events)
-			group.Wait()
-			return nil
+			return enc.Encode(&types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)})
 		}
-		go func() {
-			for range time.Tick(cmd.Duration("interval")) {
-				s, err := container.Stats()
-				if err != nil {
-					logrus.Error(err)
-					continue
-				}
-				stats <- s
+		var triggers []libcontainer.PressureTrigger
+		for _, p := range cmd.StringSlice("pressure") {
+			t, err := parsePressureTrigger(p)
+			if err != nil {
+				return err
 			}
-		}()
-		n, err := container.NotifyOOM()
+			triggers = append(triggers, t)
+		}
+		events, err := container.Events(ctx, &libcontainer.EventsOptions{
+			StatsInterval: duration,
+			Lifecycle:     cmd.Bool("lifecycle"),
+			Pressure:      triggers,
+		})
 		if err != nil {
+			if errors.Is(err, libcontainer.ErrNotRunning) {
+				return fmt.Errorf("container with id %s is not running", container.ID())
+			}
 			return err
 		}
-		for {
-			select {
-			case _, ok := <-n:
-				if ok {
-					// this means an oom event was received, if it is !ok then
-					// the channel was closed because the container stopped and
-					// the cgroups no longer exist.
-					events <- &types.Event{Type: "oom", ID: container.ID()}
-				} else {
-					n = nil
-				}
-			case s := <-stats:
-				events <- &types.Event{Type: "stats", ID: container.ID(), Data: convertLibcontainerStats(s)}
+		for e := range events {
+			ev := &types.Event{ID: container.ID()}
+			switch e.Type {
+			case libcontainer.EventOOM:
+				ev.Type = "oom"
+			case libcontainer.EventStats:
+				ev.Type = "stats"
+				ev.Data = convertLibcontainerStats(e.Stats)
+			case libcontainer.EventPressure:
+				ev.Type = "pressure"
+				ev.Data = convertPressureTrigger(e.Pressure)
+			case libcontainer.EventLifecycle:
+				ev.Type = string(e.Lifecycle.Type)
+				ev.Data = convertLifecycleEvent(e.Lifecycle)
+			default:
+				continue
 			}
-			if n == nil {
-				close(events)
-				break
+			if err := enc.Encode(ev); err != nil {
+				logrus.Error(err)
 			}
 		}
-		group.Wait()
 		return nil
 	},
 }
 
+// parsePressureTrigger parses a PSI trigger in the RESOURCE:KIND:STALL[:WINDOW] form.
+func parsePressureTrigger(s string) (libcontainer.PressureTrigger, error) {
+	t := libcontainer.PressureTrigger{Window: 2 * time.Second}
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 && len(parts) != 4 {
+		return t, fmt.Errorf("invalid pressure trigger %q: must be RESOURCE:KIND:STALL[:WINDOW]", s)
+	}
+	switch r := libcontainer.PressureResource(parts[0]); r {
+	case libcontainer.PressureCPU, libcontainer.PressureMemory, libcontainer.PressureIO:
+		t.Resource = r
+	default:
+		return t, fmt.Errorf("invalid pressure trigger %q: unknown resource %q", s, parts[0])
+	}
+	switch parts[1] {
+	case "some":
+	case "full":
+		t.Full = true
+	default:
+		return t, fmt.Errorf("invalid pressure trigger %q: kind must be some or full", s)
+	}
+	var err error
+	if t.Stall, err = time.ParseDuration(parts[2]); err != nil {
+		return t, fmt.Errorf("invalid pressure trigger %q: %w", s, err)
+	}
+	if len(parts) == 4 {
+		if t.Window, err = time.ParseDuration(parts[3]); err != nil {
+			return t, fmt.Errorf("invalid pressure trigger %q: %w", s, err)
+		}
+	}
+	return t, nil
+}
+
+func convertPressureTrigger(t *libcontainer.PressureTrigger) *types.Pressure {
+	kind := "some"
+	if t.Full {
+		kind = "full"
+	}
+	return &types.Pressure{
+		Resource: string(t.Resource),
+		Kind:     kind,
+		Stall:    t.Stall.Microseconds(),
+		Window:   t.Window.Microseconds(),
+	}
+}
+
+func convertLifecycleEvent(e *libcontainer.LifecycleEvent) *types.Lifecycle {
+	l := &types.Lifecycle{
+		Timestamp: e.Timestamp,
+		Pid:       e.Pid,
+	}
+	if s := e.ExitStatus; s != nil {
+		l.ExitCode = &s.Code
+		if s.Signal != 0 {
+			l.ExitSignal = unix.SignalName(s.Signal)
+		}
+		l.CoreDumped = s.CoreDumped
+	}
+	return l
+}
+
 func convertLibcontainerStats(ls *libcontainer.Stats) *types.Stats {
 	cg := ls.CgroupStats
 	if cg == nil {
@@ -6,15 +6,23 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 
+	"github.com/opencontainers/cgroups"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v3"
+	"golang.org/x/sys/unix"
 )
 
+// timeoutExitCode is the exit code of runc exec if the process is killed
+// because of --timeout (the same as timeout(1) uses).
+const timeoutExitCode = 124
+
 var execCommand = &cli.Command{
 	Name:  "exec",
 	Usage: "execute new process inside the container",
@@ -113,6 +121,10 @@ following will output a list of processes running in the container:
 			Name:  "ignore-paused",
 			Usage: "allow exec in a paused container",
 		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "kill the process and its descendants if it is still running after the given time, and exit with status " + strconv.Itoa(timeoutExitCode),
+		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 1, minArgs); err != nil {
@@ -190,6 +202,26 @@ func execProcess(cmd *cli.Command) (int, error) {
 		return -1, err
 	}
 
+	timeout := cmd.Duration("timeout")
+	if timeout < 0 {
+		return -1, errors.New("--timeout must not be negative")
+	}
+	if timeout > 0 && cmd.Bool("detach") {
+		return -1, errors.New("--timeout can not be used with --detach")
+	}
+	// Unless the process is to be run in a sub-cgroup specified by the
+	// user (which can have other processes), run it in a dedicated one,
+	// so the whole process tree can be killed on timeout.
+	var timeoutCgroup string
+	if timeout > 0 && cgPaths == nil {
+		timeoutCgroup, err = createTimeoutCgroup(container)
+		if err != nil {
+			logrus.Debugf("unable to create a sub-cgroup for the exec process (%v), will kill its process group on timeout", err)
+		} else {
+			cgPaths = map[string]string{"": filepath.Base(timeoutCgroup)}
+		}
+	}
+
 	r := &runner{
 		enableSubreaper: false,
 		shouldDestroy:   false,
@@ -202,10 +234,68 @@ func execProcess(cmd *cli.Command) (int, error) {
 		init:            false,
 		preserveFDs:     cmd.Int("preserve-fds"),
 		subCgroupPaths:  cgPaths,
+		timeout:         timeout,
+		timeoutCgroup:   timeoutCgroup,
+	}
+	if timeoutCgroup != "" {
+		defer func() { removeTimeoutCgroup(timeoutCgroup, r.timedOut) }()
 	}
 	return r.run(p)
 }
 
+// removeTimeoutCgroup removes the exec sub-cgroup. Unless its processes have
+// been killed on timeout, the ones left in it (such as the ones daemonized by
+// the exec process) are moved to the container cgroup first, so that they
+// keep running, as they would without --timeout.
+func removeTimeoutCgroup(path string, killed bool) {
+	if !killed {
+		parent := filepath.Dir(path)
+		// Retry, as the processes can fork while being moved.
+		for range 10 {
+			pids, err := cgroups.GetPids(path)
+			if err != nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					logrus.Warnf("unable to get the processes in exec sub-cgroup %s: %v", path, err)
+				}
+				break
+			}
+			if len(pids) == 0 {
+				break
+			}
+			for _, pid := range pids {
+				if err := cgroups.WriteCgroupProc(parent, pid); err != nil && !errors.Is(err, unix.ESRCH) {
+					logrus.Warnf("unable to move process %d out of exec sub-cgroup %s: %v", pid, path, err)
+				}
+			}
+		}
+	}
+	if err := cgroups.RemovePath(path); err != nil {
+		logrus.Warnf("unable to remove exec sub-cgroup %s: %v", path, err)
+	}
+}
+
+// createTimeoutCgroup creates a new sub-cgroup of the container cgroup for
+// runc exec --timeout, and returns its path. This is only supported for
+// cgroup v2, as killing all processes in a cgroup requires cgroup.kill.
+func createTimeoutCgroup(container *libcontainer.Container) (string, error) {
+	if !cgroups.IsCgroup2UnifiedMode() {
+		return "", errors.New("not supported on cgroup v1")
+	}
+	state, err := container.State()
+	if err != nil {
+		return "", err
+	}
+	base := state.CgroupPaths[""]
+	if base == "" {
+		return "", errors.New("no container cgroup")
+	}
+	// cgroup.kill is available since Linux 5.14.
+	if _, err := os.Stat(filepath.Join(base, "cgroup.kill")); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(base, "runc-exec-")
+}
+
 func getProcess(cmd *cli.Command, c *libcontainer.Container) (*specs.Process, error) {
 	if path := cmd.String("process"); path != "" {
 		f, err := os.Open(path)
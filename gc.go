@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/specconv"
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/runc/libcontainer/utils"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli/v3"
+)
+
+// incompleteGracePeriod is how old a container state directory without
+// state.json must be for runc gc to consider it a leftover of an aborted
+// runc create or run, rather than the one being created right now.
+const incompleteGracePeriod = time.Minute
+
+// gcEntry describes a container found by runc gc.
+type gcEntry struct {
+	// ID is the container ID.
+	ID string `json:"id"`
+	// InitProcessPid is the (former) init process ID, if known.
+	InitProcessPid int `json:"pid,omitempty"`
+	// Bundle is the path to the container bundle, if known.
+	Bundle string `json:"bundle,omitempty"`
+	// Reason describes why the container is considered stopped.
+	Reason string `json:"reason"`
+	// Removed tells whether the container has been removed.
+	Removed bool `json:"removed"`
+	// Error is the error removing the container, if any.
+	Error string `json:"error,omitempty"`
+}
+
+var gcCommand = &cli.Command{
+	Name:  "gc",
+	Usage: "remove stopped and orphaned containers",
+	ArgsUsage: `
+
+Where the given root is specified via the global option "--root"
+(default: "/run/runc").`,
+	Description: `The gc command removes the stopped containers in the runc root whose
+exit status has been recorded (for example, by runc wait). With --force, it
+also removes the ones whose init process has been killed, or is gone together
+with its supervisor (for example, after a host crash). For each such container,
+the poststop hooks are run, and its cgroup, Intel RDT group, and state
+directory are removed, as runc delete does.
+
+The containers are reported in a JSON format.`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only report the containers to be removed, without removing them",
+		},
+		&cli.BoolFlag{
+			Name:  "force",
+			Usage: "also remove the stopped containers whose exit status has not been recorded",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		if err := checkArgs(cmd, 0, exactArgs); err != nil {
+			return err
+		}
+		root := cmd.String("root")
+		list, err := os.ReadDir(root)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) && !cmd.IsSet("root") {
+				list = nil
+			} else {
+				return err
+			}
+		}
+		dryRun := cmd.Bool("dry-run")
+		force := cmd.Bool("force")
+		entries := []*gcEntry{}
+		failed := 0
+		for _, item := range list {
+			if !item.IsDir() {
+				continue
+			}
+			e, err := gcContainer(cmd, item.Name(), dryRun, force)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "load container %s: %v\n", item.Name(), err)
+				continue
+			}
+			if e == nil {
+				continue
+			}
+			if e.Error != "" {
+				failed++
+			}
+			entries = append(entries, e)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(entries); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("unable to remove %d container(s)", failed)
+		}
+		return nil
+	},
+}
+
+// gcContainer removes the container with the given ID if it is stopped
+// (unless dryRun is set), and reports it. If the container is not stopped,
+// or its exit status has not been recorded (unless force is set), nil is
+// returned, as its supervisor might be about to collect it.
+func gcContainer(cmd *cli.Command, id string, dryRun, force bool) (*gcEntry, error) {
+	root := cmd.String("root")
+	container, err := libcontainer.Load(root, id)
+	if err != nil {
+		if !errors.Is(err, libcontainer.ErrNotExist) {
+			return nil, err
+		}
+		return gcIncomplete(cmd, id, dryRun)
+	}
+	status, err := container.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status != libcontainer.Stopped {
+		return nil, nil
+	}
+	state, err := container.State()
+	if err != nil {
+		return nil, err
+	}
+	reason := "container init has exited"
+	if state.ExitStatus == nil {
+		if !force {
+			return nil, nil
+		}
+		reason = initGoneReason(state.InitProcessPid, state.InitProcessStartTime)
+	}
+	bundle, _ := utils.Annotations(state.Config.Labels)
+	e := &gcEntry{
+		ID:             id,
+		InitProcessPid: state.InitProcessPid,
+		Bundle:         bundle,
+		Reason:         reason,
+	}
+	if dryRun {
+		return e, nil
+	}
+	if err := container.Destroy(); err != nil {
+		e.Error = err.Error()
+		return e, nil
+	}
+	e.Removed = true
+	return e, nil
+}
+
+// gcIncomplete handles a container state directory without state.json,
+// which is left if runc create or run is aborted early. The directory is
+// skipped if it is still used by runc create or run.
+func gcIncomplete(cmd *cli.Command, id string, dryRun bool) (*gcEntry, error) {
+	path := filepath.Join(cmd.String("root"), id)
+	fi, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// Possible race with runc delete.
+			return nil, nil
+		}
+		return nil, err
+	}
+	if time.Since(fi.ModTime()) < incompleteGracePeriod {
+		return nil, nil
+	}
+	e := &gcEntry{
+		ID:     id,
+		Reason: "container state is incomplete",
+	}
+	if dryRun {
+		// Taking the lock creates the lock file, which would update the
+		// directory modification time checked above.
+		return e, nil
+	}
+	container, err := libcontainer.LockIncomplete(cmd.String("root"), id)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrLocked) || errors.Is(err, libcontainer.ErrExist) || errors.Is(err, libcontainer.ErrNotExist) {
+			// Being created, or already removed.
+			return nil, nil
+		}
+		return nil, err
+	}
+	// The container configuration is not known, so only the default
+	// cgroup of the container is checked for.
+	cg, err := defaultCgroup(cmd, id)
+	if err != nil {
+		container.Close()
+		return nil, err
+	}
+	if err := container.Destroy(cg); err != nil {
+		e.Error = err.Error()
+		return e, nil
+	}
+	e.Removed = true
+	return e, nil
+}
+
+// defaultCgroup returns the cgroup configuration of the container with the
+// given ID if it does not set the cgroups path.
+func defaultCgroup(cmd *cli.Command, id string) (*cgroups.Cgroup, error) {
+	rootlessCg, err := shouldUseRootlessCgroupManager(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return specconv.CreateCgroupConfig(&specconv.CreateOpts{
+		CgroupName:       id,
+		UseSystemdCgroup: cmd.Bool("systemd-cgroup"),
+		Spec:             &specs.Spec{},
+		RootlessCgroups:  rootlessCg,
+	}, nil)
+}
+
+// initGoneReason describes why the container init with the given PID and
+// start time is considered gone. The start time is used to tell whether
+// the PID has been reused by an unrelated process.
+func initGoneReason(pid int, startTime uint64) string {
+	if pid <= 0 {
+		return "container init was not started"
+	}
+	stat, err := system.Stat(pid)
+	switch {
+	case err != nil:
+		return "container init is gone"
+	case stat.StartTime != startTime:
+		return "container init is gone, and its PID is reused"
+	case stat.State == system.Zombie || stat.State == system.Dead:
+		return "container init has exited"
+	}
+	return "container init is gone"
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/moby/sys/user"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/internal/pathrs"
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/specconv"
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// containerInspect is the output of runc inspect.
+type containerInspect struct {
+	ID      string    `json:"id"`
+	Status  string    `json:"status"`
+	Pid     int       `json:"pid"`
+	Bundle  string    `json:"bundle"`
+	Rootfs  string    `json:"rootfs"`
+	Created time.Time `json:"created"`
+	// RootfsMountID is the mount ID of the container root filesystem, as
+	// seen in /proc/<pid>/mountinfo.
+	RootfsMountID int `json:"rootfs_mount_id,omitempty"`
+	// CgroupPaths are the container cgroup paths per controller. For cgroup
+	// v2, the key is "".
+	CgroupPaths     map[string]string  `json:"cgroup_paths,omitempty"`
+	Namespaces      []inspectNamespace `json:"namespaces,omitempty"`
+	IntelRdtPath    string             `json:"intel_rdt_path,omitempty"`
+	IntelRdtMonPath string             `json:"intel_rdt_mon_path,omitempty"`
+	// Capabilities are the effective capabilities of the container init.
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Seccomp is the seccomp mode of the container init: disabled,
+	// strict, or filter.
+	Seccomp     string          `json:"seccomp,omitempty"`
+	UIDMappings []configs.IDMap `json:"uid_mappings,omitempty"`
+	GIDMappings []configs.IDMap `json:"gid_mappings,omitempty"`
+}
+
+type inspectNamespace struct {
+	Type string `json:"type"`
+	Path string `json:"path"`
+	// Inode is the namespace inode, or 0 if the namespace can not be
+	// accessed (for example, if the namespace path is gone).
+	Inode uint64 `json:"inode"`
+}
+
+var inspectCommand = &cli.Command{
+	Name:  "inspect",
+	Usage: "output the low-level information about a container",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is your name for the instance of the container.`,
+	Description: `The inspect command outputs the low-level information about a container, such
+as its cgroup paths, namespaces, Intel RDT group paths, and the effective
+capabilities, seccomp mode, and user namespace mappings of its init process.
+The namespaces and the information obtained from the container init process
+are omitted if the container is stopped.
+
+With the --oci option, the effective specification of the container is output
+instead, converted from the container configuration. As the container process
+arguments, environment, user and working directory are not a part of the
+configuration, they are not included.`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "text",
+			Usage:   `select one of: text or json`,
+		},
+		&cli.BoolFlag{
+			Name:  "oci",
+			Usage: "output the effective OCI runtime specification of the container",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		if err := checkArgs(cmd, 1, exactArgs); err != nil {
+			return err
+		}
+		format := cmd.String("format")
+		switch format {
+		case "text", "json":
+		default:
+			return errors.New("invalid format option")
+		}
+		container, err := getContainer(cmd)
+		if err != nil {
+			return err
+		}
+		if cmd.Bool("oci") {
+			state, err := container.State()
+			if err != nil {
+				return err
+			}
+			spec, err := specconv.ToSpec(&state.Config)
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(spec)
+		}
+
+		info, err := inspectContainer(container)
+		if err != nil {
+			return err
+		}
+		if format == "json" {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(info)
+		}
+		return printInspect(os.Stdout, info)
+	},
+}
+
+func inspectContainer(container *libcontainer.Container) (*containerInspect, error) {
+	status, err := container.Status()
+	if err != nil {
+		return nil, err
+	}
+	state, err := container.State()
+	if err != nil {
+		return nil, err
+	}
+	bundle, _ := utils.Annotations(state.Config.Labels)
+	info := &containerInspect{
+		ID:              state.ID,
+		Status:          status.String(),
+		Bundle:          bundle,
+		Rootfs:          state.Config.Rootfs,
+		Created:         state.Created,
+		CgroupPaths:     state.CgroupPaths,
+		IntelRdtPath:    state.IntelRdtPath,
+		IntelRdtMonPath: state.IntelRdtMonPath,
+		UIDMappings:     state.Config.UIDMappings,
+		GIDMappings:     state.Config.GIDMappings,
+	}
+	if status == libcontainer.Stopped {
+		return info, nil
+	}
+
+	// The rest is obtained from the init process.
+	pid := state.InitProcessPid
+	info.Pid = pid
+	for _, t := range configs.NamespaceTypes() {
+		path, ok := state.NamespacePaths[t]
+		if !ok {
+			continue
+		}
+		var st unix.Stat_t
+		if err := unix.Stat(path, &st); err != nil {
+			// Still show the namespace, with no inode.
+			logrus.Debugf("unable to stat %s: %v", path, err)
+		}
+		info.Namespaces = append(info.Namespaces, inspectNamespace{
+			Type:  configs.NsName(t),
+			Path:  path,
+			Inode: st.Ino,
+		})
+	}
+	procStatus, err := system.Status(pid)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the init process status: %w", err)
+	}
+	info.Capabilities = capabilityNames(procStatus.CapEff)
+	info.Seccomp = seccompModes[procStatus.Seccomp]
+	if info.RootfsMountID, err = rootMountID(pid); err != nil {
+		return nil, fmt.Errorf("unable to get the rootfs mount ID: %w", err)
+	}
+	if state.Config.Namespaces.Contains(configs.NEWUSER) {
+		// Show the actual mappings, as the user namespace may be joined.
+		uidMap, err := readIDMap(pid, "uid_map")
+		if err != nil {
+			return nil, err
+		}
+		gidMap, err := readIDMap(pid, "gid_map")
+		if err != nil {
+			return nil, err
+		}
+		info.UIDMappings = toConfigIDMap(uidMap)
+		info.GIDMappings = toConfigIDMap(gidMap)
+	}
+	return info, nil
+}
+
+// seccompModes are the names of the seccomp modes in /proc/[pid]/status.
+var seccompModes = map[int]string{
+	0: "disabled",
+	1: "strict",
+	2: "filter",
+}
+
+// rootMountID returns the ID of the root mount of the process.
+func rootMountID(pid int) (int, error) {
+	f, err := pathrs.ProcPidOpen(pid, "mountinfo", os.O_RDONLY)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	mounts, err := mountinfo.GetMountsFromReader(f, mountinfo.SingleEntryFilter("/"))
+	if err != nil {
+		return 0, err
+	}
+	if len(mounts) == 0 {
+		return 0, errors.New("no root mount found")
+	}
+	// The mounts are listed in the order they were mounted, so the
+	// last one is on top of the others (if any) and is the one in use.
+	return mounts[len(mounts)-1].ID, nil
+}
+
+func toConfigIDMap(idMap []user.IDMap) []configs.IDMap {
+	res := make([]configs.IDMap, len(idMap))
+	for i, m := range idMap {
+		res[i] = configs.IDMap{ContainerID: m.ID, HostID: m.ParentID, Size: m.Count}
+	}
+	return res
+}
+
+func printInspect(out io.Writer, info *containerInspect) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", info.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", info.Status)
+	fmt.Fprintf(w, "PID:\t%d\n", info.Pid)
+	fmt.Fprintf(w, "Bundle:\t%s\n", info.Bundle)
+	fmt.Fprintf(w, "Rootfs:\t%s\n", info.Rootfs)
+	if info.RootfsMountID != 0 {
+		fmt.Fprintf(w, "Rootfs mount ID:\t%d\n", info.RootfsMountID)
+	}
+	fmt.Fprintf(w, "Created:\t%s\n", info.Created.Format(time.RFC3339Nano))
+	if info.Seccomp != "" {
+		fmt.Fprintf(w, "Seccomp:\t%s\n", info.Seccomp)
+	}
+	if info.Capabilities != nil {
+		fmt.Fprintf(w, "Capabilities:\t%s\n", strings.Join(info.Capabilities, ","))
+	}
+	if info.IntelRdtPath != "" {
+		fmt.Fprintf(w, "Intel RDT:\t%s\n", info.IntelRdtPath)
+	}
+	if info.IntelRdtMonPath != "" {
+		fmt.Fprintf(w, "Intel RDT monitoring:\t%s\n", info.IntelRdtMonPath)
+	}
+	if len(info.CgroupPaths) > 0 {
+		fmt.Fprintln(w, "Cgroups:")
+		for _, name := range slices.Sorted(maps.Keys(info.CgroupPaths)) {
+			controller := name
+			if controller == "" {
+				controller = "(unified)"
+			}
+			fmt.Fprintf(w, "  %s\t%s\n", controller, info.CgroupPaths[name])
+		}
+	}
+	if len(info.Namespaces) > 0 {
+		fmt.Fprintln(w, "Namespaces:")
+		for _, ns := range info.Namespaces {
+			inode := "-"
+			if ns.Inode != 0 {
+				inode = strconv.FormatUint(ns.Inode, 10)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", ns.Type, inode, ns.Path)
+		}
+	}
+	for _, m := range []struct {
+		name   string
+		idMaps []configs.IDMap
+	}{
+		{"UID mappings:", info.UIDMappings},
+		{"GID mappings:", info.GIDMappings},
+	} {
+		if len(m.idMaps) == 0 {
+			continue
+		}
+		fmt.Fprintln(w, m.name)
+		for _, id := range m.idMaps {
+			fmt.Fprintf(w, "  %d\t%d\t%d\n", id.ContainerID, id.HostID, id.Size)
+		}
+	}
+	return w.Flush()
+}
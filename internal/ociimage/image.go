@@ -0,0 +1,289 @@
+// Package ociimage implements reading images from a local OCI image layout
+// (see https://github.com/opencontainers/image-spec/blob/main/image-layout.md),
+// unpacking them, and converting their configuration into a runtime spec.
+//
+// Only the subset of the image spec needed by runc is implemented.
+package ociimage
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Media types, as per the image spec. Their Docker (schema 2) equivalents
+// are also supported, as they are commonly found in OCI image layouts.
+const (
+	MediaTypeIndex     = "application/vnd.oci.image.index.v1+json"
+	MediaTypeManifest  = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeLayer     = "application/vnd.oci.image.layer.v1.tar"
+	MediaTypeLayerGzip = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerLayerGzip    = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+)
+
+// Annotations, as per the image spec.
+const (
+	AnnotationRefName      = "org.opencontainers.image.ref.name"
+	AnnotationExposedPorts = "org.opencontainers.image.exposedPorts"
+	AnnotationStopSignal   = "org.opencontainers.image.stopSignal"
+)
+
+// Descriptor describes a blob.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *Platform         `json:"platform,omitempty"`
+}
+
+// Platform describes the platform an image runs on.
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// Index is an image index, such as index.json of an image layout.
+type Index struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Manifests     []Descriptor `json:"manifests"`
+}
+
+// Manifest is an image manifest.
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+}
+
+// Config is the execution configuration of an image.
+type Config struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// ImageConfig is an image configuration.
+type ImageConfig struct {
+	Platform
+	Config Config `json:"config"`
+}
+
+// Image is an image in an image layout.
+type Image struct {
+	dir      string
+	Manifest Manifest
+	Config   ImageConfig
+}
+
+// SplitReference splits an image reference of the form "dir[:tag]" into
+// the image layout directory and the tag.
+func SplitReference(ref string) (dir, tag string) {
+	i := strings.LastIndexByte(ref, ':')
+	if i < 0 || strings.ContainsRune(ref[i+1:], '/') {
+		return ref, ""
+	}
+	return ref[:i], ref[i+1:]
+}
+
+// Open opens the image with the given tag in the image layout at dir. If
+// tag is empty, the layout must contain a single image.
+func Open(dir, tag string) (*Image, error) {
+	var layout struct {
+		Version string `json:"imageLayoutVersion"`
+	}
+	if err := readJSON(filepath.Join(dir, "oci-layout"), &layout); err != nil {
+		return nil, fmt.Errorf("%s is not an OCI image layout: %w", dir, err)
+	}
+	if layout.Version != "1.0.0" {
+		return nil, fmt.Errorf("unsupported image layout version %q", layout.Version)
+	}
+	var index Index
+	if err := readJSON(filepath.Join(dir, "index.json"), &index); err != nil {
+		return nil, err
+	}
+
+	var desc *Descriptor
+	for i, d := range index.Manifests {
+		if tag == "" || d.Annotations[AnnotationRefName] == tag {
+			if desc != nil {
+				if tag == "" {
+					return nil, fmt.Errorf("image layout %s contains multiple images, a tag is required", dir)
+				}
+				return nil, fmt.Errorf("image layout %s contains multiple images tagged %q", dir, tag)
+			}
+			desc = &index.Manifests[i]
+		}
+	}
+	if desc == nil {
+		if tag == "" {
+			return nil, fmt.Errorf("image layout %s contains no images", dir)
+		}
+		return nil, fmt.Errorf("image %q not found in %s", tag, dir)
+	}
+
+	img := &Image{dir: dir}
+	if err := img.resolve(*desc); err != nil {
+		return nil, err
+	}
+	if img.Config.OS != "" && img.Config.OS != runtime.GOOS {
+		return nil, fmt.Errorf("image is for %s, not %s", img.Config.OS, runtime.GOOS)
+	}
+	return img, nil
+}
+
+// resolve reads the manifest described by desc, selecting the one for
+// the current platform if desc is an image index, and the image config.
+func (i *Image) resolve(desc Descriptor) error {
+	for desc.MediaType == MediaTypeIndex || desc.MediaType == mediaTypeDockerManifestList {
+		var index Index
+		if err := i.readBlobJSON(desc, &index); err != nil {
+			return err
+		}
+		d, err := selectPlatform(index.Manifests)
+		if err != nil {
+			return err
+		}
+		desc = d
+	}
+	if desc.MediaType != MediaTypeManifest && desc.MediaType != mediaTypeDockerManifest {
+		return fmt.Errorf("unsupported manifest media type %q", desc.MediaType)
+	}
+	if err := i.readBlobJSON(desc, &i.Manifest); err != nil {
+		return err
+	}
+	return i.readBlobJSON(i.Manifest.Config, &i.Config)
+}
+
+// selectPlatform returns the manifest for the current platform.
+func selectPlatform(manifests []Descriptor) (Descriptor, error) {
+	for _, d := range manifests {
+		if p := d.Platform; p != nil && p.OS == runtime.GOOS && p.Architecture == runtime.GOARCH {
+			return d, nil
+		}
+	}
+	if len(manifests) == 1 && manifests[0].Platform == nil {
+		return manifests[0], nil
+	}
+	return Descriptor{}, fmt.Errorf("no image found for %s/%s", runtime.GOOS, runtime.GOARCH)
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+func (i *Image) readBlobJSON(desc Descriptor, v any) error {
+	r, err := i.openBlob(desc)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("unable to parse blob %s: %w", desc.Digest, err)
+	}
+	return nil
+}
+
+// openBlob opens the blob described by desc. The returned reader fails
+// if the blob content does not match the size and digest in desc.
+func (i *Image) openBlob(desc Descriptor) (*verifier, error) {
+	alg, hexDigest, ok := strings.Cut(desc.Digest, ":")
+	if !ok || hexDigest == "" || strings.ContainsAny(hexDigest, "/.") {
+		return nil, fmt.Errorf("invalid digest %q", desc.Digest)
+	}
+	var h hash.Hash
+	switch alg {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm %q", alg)
+	}
+	f, err := os.Open(filepath.Join(i.dir, "blobs", alg, hexDigest))
+	if err != nil {
+		return nil, err
+	}
+	return &verifier{f: f, r: io.TeeReader(io.LimitReader(f, desc.Size+1), h), h: h, desc: desc}, nil
+}
+
+// openVerifiedBlob opens the blob described by desc, and verifies that its
+// content matches the size and digest in desc, so that nothing is unpacked
+// from a corrupted blob. The returned file is positioned at the blob start.
+func (i *Image) openVerifiedBlob(desc Descriptor) (*os.File, error) {
+	v, err := i.openBlob(desc)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, v); err != nil {
+		v.Close()
+		return nil, err
+	}
+	if _, err := v.f.Seek(0, io.SeekStart); err != nil {
+		v.Close()
+		return nil, err
+	}
+	return v.f, nil
+}
+
+// verifier verifies the size and digest of a blob when it is read to EOF.
+type verifier struct {
+	f    *os.File
+	r    io.Reader
+	h    hash.Hash
+	n    int64
+	desc Descriptor
+}
+
+func (v *verifier) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.n += int64(n)
+	if v.n > v.desc.Size {
+		return n, fmt.Errorf("blob %s is larger than %d bytes", v.desc.Digest, v.desc.Size)
+	}
+	if errors.Is(err, io.EOF) {
+		if v.n != v.desc.Size {
+			return n, fmt.Errorf("blob %s is %d bytes, expected %d", v.desc.Digest, v.n, v.desc.Size)
+		}
+		_, expected, _ := strings.Cut(v.desc.Digest, ":")
+		if sum := hex.EncodeToString(v.h.Sum(nil)); sum != expected {
+			return n, fmt.Errorf("blob %s has unexpected digest %s", v.desc.Digest, sum)
+		}
+	}
+	return n, err
+}
+
+func (v *verifier) Close() error {
+	return v.f.Close()
+}
@@ -0,0 +1,317 @@
+package ociimage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+type testEntry struct {
+	name, content, link string
+	typ                 byte
+	mode                int64
+}
+
+// testLayout creates an image layout in a temporary directory, containing
+// a single image with the given config and layers, tagged "latest".
+func testLayout(t *testing.T, config ImageConfig, layers ...[]testEntry) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "blobs", "sha256"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	blob := func(mediaType string, data []byte) Descriptor {
+		sum := sha256.Sum256(data)
+		digest := hex.EncodeToString(sum[:])
+		if err := os.WriteFile(filepath.Join(dir, "blobs", "sha256", digest), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return Descriptor{MediaType: mediaType, Digest: "sha256:" + digest, Size: int64(len(data))}
+	}
+	marshal := func(v any) []byte {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	m := Manifest{SchemaVersion: 2, MediaType: MediaTypeManifest}
+	m.Config = blob("application/vnd.oci.image.config.v1+json", marshal(config))
+	for _, entries := range layers {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for _, e := range entries {
+			hdr := &tar.Header{Name: e.name, Typeflag: e.typ, Linkname: e.link, Mode: 0o644, Size: int64(len(e.content))}
+			if e.typ == tar.TypeDir {
+				hdr.Mode = 0o755
+			}
+			if e.mode != 0 {
+				hdr.Mode = e.mode
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(e.content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		m.Layers = append(m.Layers, blob(MediaTypeLayerGzip, buf.Bytes()))
+	}
+	desc := blob(MediaTypeManifest, marshal(m))
+	desc.Annotations = map[string]string{AnnotationRefName: "latest"}
+
+	if err := os.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	index := Index{SchemaVersion: 2, Manifests: []Descriptor{desc}}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), marshal(index), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestSplitReference(t *testing.T) {
+	for _, tc := range []struct{ ref, dir, tag string }{
+		{"/images", "/images", ""},
+		{"/images:latest", "/images", "latest"},
+		{"images:v1.0", "images", "v1.0"},
+		{"/a:b/images", "/a:b/images", ""},
+	} {
+		dir, tag := SplitReference(tc.ref)
+		if dir != tc.dir || tag != tc.tag {
+			t.Errorf("%q: expected (%q, %q), got (%q, %q)", tc.ref, tc.dir, tc.tag, dir, tag)
+		}
+	}
+}
+
+func TestUnpack(t *testing.T) {
+	dir := testLayout(t, ImageConfig{},
+		[]testEntry{
+			{name: "etc/", typ: tar.TypeDir},
+			{name: "etc/hostname", content: "host", typ: tar.TypeReg},
+			{name: "etc/passwd", content: "root:x:0:0::/root:/bin/sh\n", typ: tar.TypeReg},
+			{name: "var/lib/", typ: tar.TypeDir},
+			{name: "var/lib/old", content: "old", typ: tar.TypeReg},
+			{name: "link", link: "etc/hostname", typ: tar.TypeSymlink},
+		},
+		[]testEntry{
+			{name: "etc/.wh.hostname", typ: tar.TypeReg},
+			{name: "var/lib/new", content: "new", typ: tar.TypeReg},
+			{name: "var/lib/.wh..wh..opq", typ: tar.TypeReg},
+			{name: "etc/hard", link: "etc/passwd", typ: tar.TypeLink},
+			// Must not escape the rootfs.
+			{name: "escape", link: "/..", typ: tar.TypeSymlink},
+			{name: "escape/outside", content: "x", typ: tar.TypeReg},
+		},
+	)
+	img, err := Open(dir, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootfs := filepath.Join(t.TempDir(), "rootfs")
+	if err := os.Mkdir(rootfs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := img.Unpack(rootfs, os.Geteuid() != 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"etc/hostname", "var/lib/old", "../outside"} {
+		if _, err := os.Lstat(filepath.Join(rootfs, name)); !os.IsNotExist(err) {
+			t.Errorf("%s: expected to be removed, got %v", name, err)
+		}
+	}
+	for name, content := range map[string]string{
+		"var/lib/new": "new",
+		"etc/hard":    "root:x:0:0::/root:/bin/sh\n",
+		"outside":     "x",
+	} {
+		data, err := os.ReadFile(filepath.Join(rootfs, name))
+		if err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+}
+
+// TestUnpackCorrupted checks that nothing is unpacked from a layer which does
+// not match its digest.
+func TestUnpackCorrupted(t *testing.T) {
+	dir := testLayout(t, ImageConfig{}, []testEntry{
+		{name: "file", content: "content", typ: tar.TypeReg},
+	})
+	img, err := Open(dir, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, digest, _ := strings.Cut(img.Manifest.Layers[0].Digest, ":")
+	blob := filepath.Join(dir, "blobs", "sha256", digest)
+	data, err := os.ReadFile(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Append to the gzip stream, which does not affect the unpacking.
+	if err := os.WriteFile(blob, append(data, 0), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	img.Manifest.Layers[0].Size++
+
+	rootfs := t.TempDir()
+	if err := img.Unpack(rootfs, os.Geteuid() != 0); err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if _, err := os.Lstat(filepath.Join(rootfs, "file")); !os.IsNotExist(err) {
+		t.Errorf("file: expected not to be unpacked, got %v", err)
+	}
+}
+
+// TestUnpackDirModeSymlink checks that the mode of a directory replaced by
+// a symlink, or of a directory under it, is not applied to the symlink
+// target outside of the rootfs.
+func TestUnpackDirModeSymlink(t *testing.T) {
+	outside := t.TempDir()
+	if err := os.Mkdir(filepath.Join(outside, "b"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(outside, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	dir := testLayout(t, ImageConfig{},
+		[]testEntry{
+			{name: "a/", typ: tar.TypeDir, mode: 0o777},
+			{name: "a", link: outside, typ: tar.TypeSymlink},
+			{name: "c/", typ: tar.TypeDir},
+			{name: "c/b/", typ: tar.TypeDir, mode: 0o777},
+			{name: "c", link: outside, typ: tar.TypeSymlink},
+			{name: "d/", typ: tar.TypeDir, mode: 0o777},
+			{name: ".wh.d", typ: tar.TypeReg},
+			{name: "d", link: outside, typ: tar.TypeSymlink},
+			{name: "e/", typ: tar.TypeDir, mode: 0o750},
+		},
+	)
+	img, err := Open(dir, "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootfs := filepath.Join(t.TempDir(), "rootfs")
+	if err := os.Mkdir(rootfs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := img.Unpack(rootfs, os.Geteuid() != 0); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{outside, filepath.Join(outside, "b")} {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := fi.Mode().Perm(); mode != 0o700 {
+			t.Errorf("%s: expected mode 0700, got %#o", name, mode)
+		}
+	}
+	fi, err := os.Stat(filepath.Join(rootfs, "e"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := fi.Mode().Perm(); mode != 0o750 {
+		t.Errorf("e: expected mode 0750, got %#o", mode)
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	dir := testLayout(t, ImageConfig{})
+	if _, err := Open(dir, "nosuchtag"); err == nil {
+		t.Error("expected an error for a missing tag")
+	}
+	if _, err := Open(t.TempDir(), ""); err == nil {
+		t.Error("expected an error for a directory which is not an image layout")
+	}
+
+	// Corrupt all the blobs.
+	blobs, err := filepath.Glob(filepath.Join(dir, "blobs", "sha256", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range blobs {
+		if err := os.WriteFile(b, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := Open(dir, ""); err == nil {
+		t.Error("expected an error for corrupted blobs")
+	}
+}
+
+func TestConvertConfig(t *testing.T) {
+	config := ImageConfig{Config: Config{
+		User:         "daemon",
+		ExposedPorts: map[string]struct{}{"8080/tcp": {}, "53/udp": {}},
+		Env:          []string{"PATH=/bin", "FOO=bar"},
+		Entrypoint:   []string{"/bin/server"},
+		Cmd:          []string{"--port", "8080"},
+		WorkingDir:   "/srv",
+		Labels:       map[string]string{"version": "1.0"},
+		StopSignal:   "SIGINT",
+	}}
+	img := &Image{Config: config}
+	rootfs := t.TempDir()
+	if err := os.Mkdir(filepath.Join(rootfs, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rootfs, "etc", "passwd"), []byte("daemon:x:2:3::/:/bin/false\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rootfs, "etc", "group"), []byte("daemon:x:3:\nadm:x:4:daemon\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := &specs.Spec{Process: &specs.Process{
+		Args: []string{"sh"},
+		Env:  []string{"PATH=/usr/bin", "TERM=xterm"},
+		Cwd:  "/",
+	}}
+	if err := img.ConvertConfig(spec, rootfs); err != nil {
+		t.Fatal(err)
+	}
+	p := spec.Process
+	if !slices.Equal(p.Args, []string{"/bin/server", "--port", "8080"}) {
+		t.Errorf("unexpected args: %q", p.Args)
+	}
+	if !slices.Equal(p.Env, []string{"TERM=xterm", "PATH=/bin", "FOO=bar"}) {
+		t.Errorf("unexpected env: %q", p.Env)
+	}
+	if p.Cwd != "/srv" {
+		t.Errorf("unexpected cwd: %q", p.Cwd)
+	}
+	if p.User.UID != 2 || p.User.GID != 3 || !slices.Equal(p.User.AdditionalGids, []uint32{4}) {
+		t.Errorf("unexpected user: %+v", p.User)
+	}
+	expected := map[string]string{
+		"version":              "1.0",
+		AnnotationExposedPorts: "53/udp,8080/tcp",
+		AnnotationStopSignal:   "SIGINT",
+	}
+	for k, v := range expected {
+		if spec.Annotations[k] != v {
+			t.Errorf("annotation %s: expected %q, got %q", k, v, spec.Annotations[k])
+		}
+	}
+}
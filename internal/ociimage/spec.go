@@ -0,0 +1,86 @@
+package ociimage
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/moby/sys/user"
+	"github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// ConvertConfig applies the image execution configuration to spec, as per
+// https://github.com/opencontainers/image-spec/blob/main/conversion.md.
+// The user and group names are looked up in the unpacked image at rootfs.
+func (i *Image) ConvertConfig(spec *specs.Spec, rootfs string) error {
+	c := i.Config.Config
+	if spec.Process == nil {
+		spec.Process = &specs.Process{}
+	}
+	p := spec.Process
+
+	if args := append(slices.Clone(c.Entrypoint), c.Cmd...); len(args) > 0 {
+		p.Args = args
+	}
+	p.Env = mergeEnv(p.Env, c.Env)
+	if c.WorkingDir != "" {
+		p.Cwd = c.WorkingDir
+	}
+
+	passwd, err := securejoin.SecureJoin(rootfs, "/etc/passwd")
+	if err != nil {
+		return err
+	}
+	group, err := securejoin.SecureJoin(rootfs, "/etc/group")
+	if err != nil {
+		return err
+	}
+	u, err := user.GetExecUserPath(c.User, &user.ExecUser{}, passwd, group)
+	if err != nil {
+		return fmt.Errorf("unable to find user %q: %w", c.User, err)
+	}
+	p.User = specs.User{
+		UID: uint32(u.Uid),
+		GID: uint32(u.Gid),
+	}
+	for _, gid := range u.Sgids {
+		if gid != u.Gid {
+			p.User.AdditionalGids = append(p.User.AdditionalGids, uint32(gid))
+		}
+	}
+
+	if spec.Annotations == nil {
+		spec.Annotations = make(map[string]string)
+	}
+	maps.Copy(spec.Annotations, c.Labels)
+	if len(c.ExposedPorts) > 0 {
+		spec.Annotations[AnnotationExposedPorts] = strings.Join(slices.Sorted(maps.Keys(c.ExposedPorts)), ",")
+	}
+	if c.StopSignal != "" {
+		spec.Annotations[AnnotationStopSignal] = c.StopSignal
+	}
+	if len(spec.Annotations) == 0 {
+		spec.Annotations = nil
+	}
+	return nil
+}
+
+// mergeEnv returns the environment variables from env, overridden and
+// extended by the ones from override.
+func mergeEnv(env, override []string) []string {
+	var merged []string
+	keys := make(map[string]struct{}, len(override))
+	for _, e := range override {
+		k, _, _ := strings.Cut(e, "=")
+		keys[k] = struct{}{}
+	}
+	for _, e := range env {
+		k, _, _ := strings.Cut(e, "=")
+		if _, ok := keys[k]; !ok {
+			merged = append(merged, e)
+		}
+	}
+	return append(merged, override...)
+}
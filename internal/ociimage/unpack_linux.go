@@ -0,0 +1,335 @@
+package ociimage
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/internal/pathrs"
+)
+
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = whiteoutPrefix + whiteoutPrefix + ".opq"
+)
+
+// Unpack unpacks the image layers into rootfs, which must be an existing
+// directory, applying the whiteouts. If rootless is set, the files are
+// owned by the current user, and the device nodes are skipped, as the
+// current user can not create them.
+func (i *Image) Unpack(rootfs string, rootless bool) error {
+	rootfs, err := filepath.Abs(rootfs)
+	if err != nil {
+		return err
+	}
+	for _, layer := range i.Manifest.Layers {
+		if err := i.unpackLayer(rootfs, layer, rootless); err != nil {
+			return fmt.Errorf("unable to unpack layer %s: %w", layer.Digest, err)
+		}
+	}
+	return nil
+}
+
+func (i *Image) unpackLayer(rootfs string, desc Descriptor, rootless bool) error {
+	blob, err := i.openVerifiedBlob(desc)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	var r io.Reader = io.LimitReader(blob, desc.Size)
+	switch desc.MediaType {
+	case MediaTypeLayer:
+	case MediaTypeLayerGzip, mediaTypeDockerLayerGzip:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	default:
+		return fmt.Errorf("unsupported layer media type %q", desc.MediaType)
+	}
+
+	u := &unpacker{
+		rootfs:   rootfs,
+		rootless: rootless,
+		added:    make(map[string]struct{}),
+	}
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := u.apply(hdr, tr); err != nil {
+			return fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+	}
+	return u.finish()
+}
+
+// unpacker applies the entries of a single layer.
+type unpacker struct {
+	rootfs   string
+	rootless bool
+	// added is the set of paths added by the layer, which are not
+	// affected by its opaque whiteouts.
+	added map[string]struct{}
+	// dirs are the directories unpacked, in order, to set their modes and
+	// times once the layer is unpacked.
+	dirs []dirEntry
+}
+
+type dirEntry struct {
+	// path is the host path of the directory, with no symlinks in it at
+	// the time the directory is unpacked.
+	path string
+	hdr  *tar.Header
+}
+
+// forget drops the pending directories at or under the host path, which is
+// being removed or replaced, so that their modes are not applied to whatever
+// replaces them.
+func (u *unpacker) forget(target string) {
+	u.dirs = slices.DeleteFunc(u.dirs, func(d dirEntry) bool {
+		return d.path == target || strings.HasPrefix(d.path, target+"/")
+	})
+}
+
+// remove removes the host path, together with its pending directories.
+func (u *unpacker) remove(target string) error {
+	u.forget(target)
+	return os.RemoveAll(target)
+}
+
+// resolve returns the host path of the entry name, resolving the symlinks
+// in its parent directory (but not the entry itself) within the rootfs.
+func (u *unpacker) resolve(name string) (string, error) {
+	dir, err := securejoin.SecureJoin(u.rootfs, path.Dir(name))
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, path.Base(name)), nil
+}
+
+func (u *unpacker) apply(hdr *tar.Header, r io.Reader) error {
+	name := path.Clean("/" + hdr.Name)
+	if name == "/" {
+		return nil
+	}
+	dir, base := path.Split(name)
+
+	switch {
+	case base == whiteoutOpaque:
+		// Remove all the directory contents from the lower layers.
+		parent, err := securejoin.SecureJoin(u.rootfs, dir)
+		if err != nil {
+			return err
+		}
+		entries, err := os.ReadDir(parent)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		for _, e := range entries {
+			if _, ok := u.added[path.Join(dir, e.Name())]; ok {
+				continue
+			}
+			if err := u.remove(filepath.Join(parent, e.Name())); err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.HasPrefix(base, whiteoutPrefix):
+		target, err := u.resolve(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+		if err != nil {
+			return err
+		}
+		return u.remove(target)
+	}
+
+	target, err := u.resolve(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	// Replace the existing entry, unless both are directories.
+	if fi, err := os.Lstat(target); err == nil {
+		if !fi.IsDir() || hdr.Typeflag != tar.TypeDir {
+			if err := u.remove(target); err != nil {
+				return err
+			}
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if err := os.Mkdir(target, 0o755); err != nil && !errors.Is(err, os.ErrExist) {
+			return err
+		}
+	case tar.TypeReg:
+		f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY|unix.O_NOFOLLOW, 0o600)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, r)
+		if err1 := f.Close(); err == nil {
+			err = err1
+		}
+		if err != nil {
+			return err
+		}
+	case tar.TypeSymlink:
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		oldname, err := u.resolve(path.Clean("/" + hdr.Linkname))
+		if err != nil {
+			return err
+		}
+		if err := os.Link(oldname, target); err != nil {
+			return err
+		}
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if u.rootless && hdr.Typeflag != tar.TypeFifo {
+			logrus.Debugf("skipping device node %s in rootless mode", name)
+			return nil
+		}
+		devType := map[byte]uint32{
+			tar.TypeChar:  unix.S_IFCHR,
+			tar.TypeBlock: unix.S_IFBLK,
+			tar.TypeFifo:  unix.S_IFIFO,
+		}[hdr.Typeflag]
+		dev := unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor))
+		if err := unix.Mknod(target, devType|0o600, int(dev)); err != nil {
+			return &os.PathError{Op: "mknod", Path: target, Err: err}
+		}
+	default:
+		logrus.Warnf("skipping %s of unsupported type %q", name, hdr.Typeflag)
+		return nil
+	}
+	u.added[name] = struct{}{}
+
+	if !u.rootless {
+		if err := os.Lchown(target, hdr.Uid, hdr.Gid); err != nil {
+			return err
+		}
+		for key, value := range hdr.PAXRecords {
+			attr, ok := strings.CutPrefix(key, "SCHILY.xattr.")
+			if !ok {
+				continue
+			}
+			if err := unix.Lsetxattr(target, attr, []byte(value), 0); err != nil {
+				logrus.Warnf("unable to set xattr %s on %s: %v", attr, name, err)
+			}
+		}
+	}
+	switch hdr.Typeflag {
+	case tar.TypeSymlink, tar.TypeLink:
+		return nil
+	case tar.TypeDir:
+		// The directory may be read-only, and its modification time is
+		// changed by its entries, so the mode and times are set once the
+		// layer is unpacked.
+		u.dirs = append(u.dirs, dirEntry{path: target, hdr: hdr})
+		return nil
+	}
+	return setModeAndTimes(target, hdr)
+}
+
+// finish sets the mode and times of the directories unpacked.
+//
+// As the later entries of the layer may have replaced the parents of a
+// directory with symlinks, each directory is opened within the rootfs, and
+// the ones which are no longer directories are skipped, so that the mode is
+// never applied to anything outside of the rootfs.
+func (u *unpacker) finish() error {
+	root, err := os.OpenFile(u.rootfs, unix.O_DIRECTORY|unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	for i := len(u.dirs) - 1; i >= 0; i-- {
+		d := u.dirs[i]
+		f, err := openDirNoFollow(root, pathrs.LexicallyStripRoot(u.rootfs, d.path))
+		if err != nil {
+			if errors.Is(err, unix.ENOTDIR) || errors.Is(err, unix.ELOOP) || errors.Is(err, os.ErrNotExist) {
+				logrus.Debugf("skipping the mode of %s, which is no longer a directory", d.hdr.Name)
+				continue
+			}
+			return err
+		}
+		err = setDirModeAndTimes(f, d.hdr)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openDirNoFollow opens the directory at subpath inside root. The parent is
+// resolved inside root, and the final component must not be a symlink, so
+// that a directory later replaced by a symlink is never followed.
+func openDirNoFollow(root *os.File, subpath string) (*os.File, error) {
+	dir, base := filepath.Split(subpath)
+	parent, err := pathrs.OpenInRoot(root, dir, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	defer parent.Close()
+	fd, err := unix.Openat(int(parent.Fd()), base, unix.O_RDONLY|unix.O_DIRECTORY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "openat", Path: subpath, Err: err}
+	}
+	return os.NewFile(uintptr(fd), subpath), nil
+}
+
+// setDirModeAndTimes is like setModeAndTimes, for an open directory.
+func setDirModeAndTimes(dir *os.File, hdr *tar.Header) error {
+	if err := dir.Chmod(hdr.FileInfo().Mode()); err != nil {
+		return err
+	}
+	tv := []unix.Timeval{unix.NsecToTimeval(hdr.AccessTime.UnixNano()), unix.NsecToTimeval(hdr.ModTime.UnixNano())}
+	if hdr.AccessTime.IsZero() {
+		tv[0] = tv[1]
+	}
+	if err := unix.Futimes(int(dir.Fd()), tv); err != nil {
+		return &os.PathError{Op: "futimes", Path: dir.Name(), Err: err}
+	}
+	return nil
+}
+
+func setModeAndTimes(target string, hdr *tar.Header) error {
+	// Set the mode after chown, which clears the setuid and setgid bits.
+	if err := os.Chmod(target, hdr.FileInfo().Mode()); err != nil {
+		return err
+	}
+	ts := []unix.Timespec{unix.NsecToTimespec(hdr.AccessTime.UnixNano()), unix.NsecToTimespec(hdr.ModTime.UnixNano())}
+	if hdr.AccessTime.IsZero() {
+		ts[0] = ts[1]
+	}
+	return unix.UtimesNanoAt(unix.AT_FDCWD, target, ts, unix.AT_SYMLINK_NOFOLLOW)
+}
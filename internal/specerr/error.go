@@ -0,0 +1,53 @@
+// Package specerr provides the configuration errors annotated with the
+// location of the offending setting in the OCI runtime spec, which are
+// returned by both the spec conversion and the configuration validation.
+package specerr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error is a configuration error, annotated with the location of the
+// offending setting in the OCI runtime spec.
+type Error struct {
+	// Path is a JSON pointer (RFC 6901) to the offending value in the
+	// runtime spec, such as "/linux/sysctl/kernel.msgmax". For a
+	// configuration not created from a runtime spec, it is meaningless.
+	Path string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WithPath annotates err with a JSON pointer made of the given reference
+// tokens (such as "mounts", 2, "options"). If err is nil, nil is returned.
+func WithPath(err error, tokens ...any) error {
+	if err == nil {
+		return nil
+	}
+	var b strings.Builder
+	for _, t := range tokens {
+		b.WriteByte('/')
+		// Escape as per RFC 6901, section 3.
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(fmt.Sprint(t)))
+	}
+	return &Error{Path: b.String(), Err: err}
+}
+
+// Path returns the JSON pointer err is annotated with by [WithPath], or an
+// empty string if there is none.
+func Path(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Path
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package sys
+
+import (
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// pidfdInfo is struct pidfd_info from <linux/pidfd.h> (PIDFD_INFO_SIZE_VER0).
+type pidfdInfo struct {
+	Mask     uint64
+	CgroupID uint64
+	Pid      uint32
+	Tgid     uint32
+	Ppid     uint32
+	Ruid     uint32
+	Rgid     uint32
+	Euid     uint32
+	Egid     uint32
+	Suid     uint32
+	Sgid     uint32
+	Fsuid    uint32
+	Fsgid    uint32
+	ExitCode int32
+}
+
+const (
+	// _PIDFD_GET_INFO is _IOWR(PIDFS_IOCTL_MAGIC, 11, struct pidfd_info).
+	_PIDFD_GET_INFO = 0xc040ff0b
+	// _PIDFD_INFO_EXIT requests the exit status of a reaped process.
+	_PIDFD_INFO_EXIT = 1 << 3
+)
+
+// PidfdExitStatus returns the wait status of an exited process referred to
+// by pidfd, using PIDFD_GET_INFO (Linux >= 6.15). The returned bool is false
+// if the process has not been reaped by its parent yet, so its exit status is
+// not available.
+//
+// If the kernel does not support PIDFD_INFO_EXIT, an error wrapping
+// [unix.ENOTTY] or [unix.EINVAL] is returned.
+func PidfdExitStatus(pidfd int) (unix.WaitStatus, bool, error) {
+	info := pidfdInfo{Mask: _PIDFD_INFO_EXIT}
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(pidfd), _PIDFD_GET_INFO, uintptr(unsafe.Pointer(&info)))
+	if errno != 0 {
+		// ESRCH means the process is gone and nothing was recorded
+		// (such as with kernels before PIDFD_INFO_EXIT was added).
+		return 0, false, os.NewSyscallError("ioctl PIDFD_GET_INFO", errno)
+	}
+	if info.Mask&_PIDFD_INFO_EXIT == 0 {
+		return 0, false, nil
+	}
+	return unix.WaitStatus(info.ExitCode), true, nil
+}
@@ -146,6 +146,9 @@ type Config struct {
 	// Routes can be specified to create entries in the route table as the container is started.
 	Routes []*Route `json:"routes,omitempty"`
 
+	// Rules can be specified to create routing policy rules as the container is started.
+	Rules []*Rule `json:"rules,omitempty"`
+
 	// Cgroups specifies specific cgroup settings for the various subsystems that the container is
 	// placed into to limit the resources the container has available.
 	Cgroups *cgroups.Cgroup `json:"cgroups"`
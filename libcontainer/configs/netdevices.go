@@ -4,4 +4,17 @@ package configs
 type LinuxNetDevice struct {
 	// Name of the device in the container namespace.
 	Name string `json:"name,omitempty"`
+
+	// Addresses are the IPv4 and IPv6 addresses, with masks in the CIDR
+	// form, to add to the device in the container namespace, in addition to
+	// the ones moved with the device from the host.
+	Addresses []string `json:"addresses,omitempty"`
+
+	// MTU sets the MTU of the device in the container namespace, if not zero.
+	MTU int `json:"mtu,omitempty"`
+
+	// Routes are the routes via the device to add in the container namespace.
+	// The route destination defaults to the default route of the gateway IP
+	// family, and the route InterfaceName is ignored.
+	Routes []*Route `json:"routes,omitempty"`
 }
@@ -5,7 +5,7 @@ package configs
 // The network configuration can be omitted from a container causing the
 // container to be setup with the host's networking stack
 type Network struct {
-	// Type sets the networks type, commonly veth and loopback.
+	// Type sets the networks type: loopback, veth, macvlan, or ipvlan.
 	Type string `json:"type"`
 
 	// Name of the network interface.
@@ -48,6 +48,15 @@ type Network struct {
 	// Note: This is unsupported on some systems.
 	// Note: This does not apply to loopback interfaces.
 	HairpinMode bool `json:"hairpin_mode,omitempty"`
+
+	// Parent is the name of the host interface the interface is created on,
+	// in the case of type macvlan or ipvlan.
+	Parent string `json:"parent,omitempty"`
+
+	// Mode sets the mode of the interface, in the case of type macvlan
+	// (bridge, private, or vepa; the default is bridge) or ipvlan (l2 or l3;
+	// the default is l2).
+	Mode string `json:"mode,omitempty"`
 }
 
 // Route defines a routing table entry.
@@ -56,20 +65,69 @@ type Network struct {
 // is started.
 //
 // All of destination, source, and gateway should be either IPv4 or IPv6.
-// One of the three options must be present, and omitted entries will use their
-// IP family default for the route table.  For IPv4 for example, setting the
-// gateway to 1.2.3.4 and the interface to eth0 will set up a standard
-// destination of 0.0.0.0(or *) when viewed in the route table.
+// The destination or the gateway must be present, and an omitted destination
+// will use the IP family default for the route table.  For IPv4 for example,
+// setting the gateway to 1.2.3.4 and the interface to eth0 will set up a
+// standard destination of 0.0.0.0(or *) when viewed in the route table.
+// A route without a gateway is an on-link route via the interface.
 type Route struct {
 	// Destination specifies the destination IP address and mask in the CIDR form.
 	Destination string `json:"destination,omitempty"`
 
-	// Source specifies the source IP address and mask in the CIDR form.
+	// Source specifies the preferred source IP address.
 	Source string `json:"source,omitempty"`
 
 	// Gateway specifies the gateway IP address.
 	Gateway string `json:"gateway,omitempty"`
 
 	// InterfaceName specifies the device to set this route up for, for example eth0.
+	// It is required for a route without a gateway.
 	InterfaceName string `json:"interface_name,omitempty"`
+
+	// Metric specifies the route metric (priority). The routes with lower
+	// metrics are preferred.
+	Metric int `json:"metric,omitempty"`
+
+	// Table specifies the routing table to add the route to. The default is
+	// the main table.
+	Table int `json:"table,omitempty"`
+
+	// Scope specifies the route scope: universe, site, link, or host. The
+	// default is link for a route without a gateway, and universe otherwise.
+	Scope string `json:"scope,omitempty"`
+}
+
+// Rule defines a routing policy rule, selecting the routing table to look up
+// for the matching packets, as ip-rule(8) does.
+//
+// All of destination and source should be either IPv4 or IPv6. A rule with
+// neither of them is an IPv4 rule, unless IPv6 is set.
+type Rule struct {
+	// Priority specifies the rule priority. The rules with lower priorities
+	// are looked up first. If zero, the kernel picks the priority.
+	Priority int `json:"priority,omitempty"`
+
+	// Source specifies the source IP address and mask in the CIDR form to match.
+	Source string `json:"source,omitempty"`
+
+	// Destination specifies the destination IP address and mask in the CIDR form to match.
+	Destination string `json:"destination,omitempty"`
+
+	// IPv6 makes the rule an IPv6 one if neither source nor destination is set.
+	IPv6 bool `json:"ipv6,omitempty"`
+
+	// InputInterface specifies the incoming device to match.
+	InputInterface string `json:"input_interface,omitempty"`
+
+	// OutputInterface specifies the outgoing device to match.
+	OutputInterface string `json:"output_interface,omitempty"`
+
+	// Mark specifies the firewall mark to match.
+	Mark uint32 `json:"mark,omitempty"`
+
+	// Mask specifies the mask of the firewall mark to match, if not zero.
+	Mask uint32 `json:"mask,omitempty"`
+
+	// Table specifies the routing table to look up.
+	Table int `json:"table"`
 }
@@ -0,0 +1,18 @@
+package validate
+
+// Unjoin returns the list of errors joined by [errors.Join] (recursively),
+// or just err if it is not a joined error. If err is nil, nil is returned.
+func Unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	j, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range j.Unwrap() {
+		errs = append(errs, Unjoin(e)...)
+	}
+	return errs
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/opencontainers/runc/internal/specerr"
 	"github.com/opencontainers/runc/libcontainer/configs"
 )
 
@@ -15,10 +16,7 @@ func rootlessEUIDCheck(config *configs.Config) error {
 	if !config.RootlessEUID {
 		return nil
 	}
-	if err := rootlessEUIDMappings(config); err != nil {
-		return err
-	}
-	if err := rootlessEUIDMount(config); err != nil {
+	if err := errors.Join(rootlessEUIDMappings(config), rootlessEUIDMount(config)); err != nil {
 		return err
 	}
 
@@ -31,16 +29,18 @@ func rootlessEUIDCheck(config *configs.Config) error {
 
 func rootlessEUIDMappings(config *configs.Config) error {
 	if !config.Namespaces.Contains(configs.NEWUSER) {
-		return errors.New("rootless container requires user namespaces")
+		return specerr.WithPath(errors.New("rootless container requires user namespaces"), "linux", "namespaces")
 	}
 	// We only require mappings if we are not joining another userns.
 	if config.Namespaces.IsPrivate(configs.NEWUSER) {
+		var errs []error
 		if len(config.UIDMappings) == 0 {
-			return errors.New("rootless containers requires at least one UID mapping")
+			errs = append(errs, specerr.WithPath(errors.New("rootless containers requires at least one UID mapping"), "linux", "uidMappings"))
 		}
 		if len(config.GIDMappings) == 0 {
-			return errors.New("rootless containers requires at least one GID mapping")
+			errs = append(errs, specerr.WithPath(errors.New("rootless containers requires at least one GID mapping"), "linux", "gidMappings"))
 		}
+		return errors.Join(errs...)
 	}
 	return nil
 }
@@ -54,7 +54,8 @@ func rootlessEUIDMount(config *configs.Config) error {
 
 	// Check that the options list doesn't contain any uid= or gid= entries
 	// that don't resolve to root.
-	for _, mount := range config.Mounts {
+	var errs []error
+	for i, mount := range config.Mounts {
 		// Look for a common substring; skip further processing
 		// if there can't be any uid= or gid= options.
 		if !strings.Contains(mount.Data, "id=") {
@@ -68,7 +69,7 @@ func rootlessEUIDMount(config *configs.Config) error {
 					continue
 				}
 				if _, err := config.HostUID(uid); err != nil {
-					return fmt.Errorf("cannot specify %s mount option for rootless container: %w", opt, err)
+					errs = append(errs, specerr.WithPath(fmt.Errorf("cannot specify %s mount option for rootless container: %w", opt, err), "mounts", i, "options"))
 				}
 			} else if str, ok := strings.CutPrefix(opt, "gid="); ok {
 				gid, err := strconv.Atoi(str)
@@ -77,11 +78,11 @@ func rootlessEUIDMount(config *configs.Config) error {
 					continue
 				}
 				if _, err := config.HostGID(gid); err != nil {
-					return fmt.Errorf("cannot specify %s mount option for rootless container: %w", opt, err)
+					errs = append(errs, specerr.WithPath(fmt.Errorf("cannot specify %s mount option for rootless container: %w", opt, err), "mounts", i, "options"))
 				}
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
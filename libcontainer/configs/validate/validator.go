@@ -3,12 +3,16 @@ package validate
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 
 	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/runc/internal/specerr"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	selinux "github.com/opencontainers/selinux/go-selinux"
@@ -18,7 +22,25 @@ import (
 
 type check func(config *configs.Config) error
 
+// Validate checks the container configuration, and returns the first error
+// found, if any. Warnings about the settings which are accepted for backward
+// compatibility are logged.
 func Validate(config *configs.Config) error {
+	errs, warns := ValidateAll(config)
+	for _, w := range warns {
+		logrus.WithError(w).Warn("configuration")
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// ValidateAll is like [Validate], but returns all the errors found rather
+// than the first one, and the warnings rather than logging them. Where
+// possible, the errors are annotated with the location of the setting in
+// the runtime spec (see [specerr.Error]).
+func ValidateAll(config *configs.Config) (errs, warns []error) {
 	checks := []check{
 		cgroupsCheck,
 		rootfs,
@@ -36,37 +58,33 @@ func Validate(config *configs.Config) error {
 		memoryPolicy,
 	}
 	for _, c := range checks {
-		if err := c(config); err != nil {
-			return err
-		}
+		errs = append(errs, Unjoin(c(config))...)
 	}
 	// Relaxed validation rules for backward compatibility
-	warns := []check{
+	warnChecks := []check{
 		mountsWarn,
 	}
-	for _, c := range warns {
-		if err := c(config); err != nil {
-			logrus.WithError(err).Warn("configuration")
-		}
+	for _, c := range warnChecks {
+		warns = append(warns, Unjoin(c(config))...)
 	}
-	return nil
+	return errs, warns
 }
 
 // rootfs validates if the rootfs is an absolute path and is not a symlink
 // to the container's root filesystem.
 func rootfs(config *configs.Config) error {
 	if _, err := os.Stat(config.Rootfs); err != nil {
-		return fmt.Errorf("invalid rootfs: %w", err)
+		return specerr.WithPath(fmt.Errorf("invalid rootfs: %w", err), "root", "path")
 	}
 	cleaned, err := filepath.Abs(config.Rootfs)
 	if err != nil {
-		return fmt.Errorf("invalid rootfs: %w", err)
+		return specerr.WithPath(fmt.Errorf("invalid rootfs: %w", err), "root", "path")
 	}
 	if cleaned, err = filepath.EvalSymlinks(cleaned); err != nil {
-		return fmt.Errorf("invalid rootfs: %w", err)
+		return specerr.WithPath(fmt.Errorf("invalid rootfs: %w", err), "root", "path")
 	}
 	if filepath.Clean(config.Rootfs) != cleaned {
-		return errors.New("invalid rootfs: not an absolute path, or a symlink")
+		return specerr.WithPath(errors.New("invalid rootfs: not an absolute path, or a symlink"), "root", "path")
 	}
 	return nil
 }
@@ -90,97 +108,323 @@ func netdevices(config *configs.Config) error {
 		return nil
 	}
 	if !config.Namespaces.Contains(configs.NEWNET) {
-		return errors.New("unable to move network devices without a NET namespace")
+		return specerr.WithPath(errors.New("unable to move network devices without a NET namespace"), "linux", "netDevices")
 	}
 
 	if config.RootlessEUID || config.RootlessCgroups {
-		return errors.New("network devices are not supported for rootless containers")
+		return specerr.WithPath(errors.New("network devices are not supported for rootless containers"), "linux", "netDevices")
 	}
 
-	for name, netdev := range config.NetDevices {
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(config.NetDevices)) {
 		if !devValidName(name) {
-			return fmt.Errorf("invalid network device name %q", name)
+			errs = append(errs, specerr.WithPath(fmt.Errorf("invalid network device name %q", name), "linux", "netDevices", name))
 		}
+		netdev := config.NetDevices[name]
 		if netdev.Name != "" && !devValidName(netdev.Name) {
-			return fmt.Errorf("invalid network device name %q", netdev.Name)
+			errs = append(errs, specerr.WithPath(fmt.Errorf("invalid network device name %q", netdev.Name), "linux", "netDevices", name, "name"))
+		}
+		if err := netDeviceSettings(netdev); err != nil {
+			errs = append(errs, specerr.WithPath(fmt.Errorf("network device %q: %w", name, err), "linux", "netDevices", name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// netDeviceSettings validates the addresses, MTU, and routes of the network
+// device.
+func netDeviceSettings(netdev *configs.LinuxNetDevice) error {
+	for _, address := range netdev.Addresses {
+		if _, _, err := net.ParseCIDR(address); err != nil {
+			return err
+		}
+	}
+	if netdev.MTU < 0 {
+		return errors.New("mtu must not be negative")
+	}
+	for _, r := range netdev.Routes {
+		if err := route(r); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// routeScopes are the valid route scopes.
+var routeScopes = []string{"", "universe", "site", "link", "host"}
+
+// route validates the route, except for its interface.
+func route(r *configs.Route) error {
+	if r.Destination == "" && r.Gateway == "" {
+		return errors.New("route destination or gateway must be set")
+	}
+	var ips []net.IP
+	if r.Destination != "" {
+		ip, _, err := net.ParseCIDR(r.Destination)
+		if err != nil {
+			return fmt.Errorf("invalid route destination: %w", err)
+		}
+		ips = append(ips, ip)
+	}
+	for _, addr := range []string{r.Gateway, r.Source} {
+		if addr == "" {
+			continue
+		}
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return fmt.Errorf("invalid route address %q", addr)
+		}
+		ips = append(ips, ip)
+	}
+	if !sameFamily(ips) {
+		return errors.New("route addresses must be of the same IP family")
+	}
+	if r.Metric < 0 || r.Table < 0 {
+		return errors.New("route metric and table must not be negative")
+	}
+	if !slices.Contains(routeScopes, r.Scope) {
+		return fmt.Errorf("invalid route scope %q", r.Scope)
+	}
+	return nil
+}
+
+// rule validates the routing policy rule.
+func rule(r *configs.Rule) error {
+	if r.Table <= 0 {
+		return errors.New("rule table must be set")
+	}
+	if r.Priority < 0 {
+		return errors.New("rule priority must not be negative")
+	}
+	var ips []net.IP
+	for _, addr := range []string{r.Source, r.Destination} {
+		if addr == "" {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(addr)
+		if err != nil {
+			return fmt.Errorf("invalid rule address: %w", err)
+		}
+		ips = append(ips, ip)
+	}
+	if !sameFamily(ips) {
+		return errors.New("rule addresses must be of the same IP family")
+	}
+	if len(ips) > 0 && r.IPv6 && ips[0].To4() != nil {
+		return errors.New("rule addresses must be IPv6 addresses for an IPv6 rule")
+	}
+	for _, name := range []string{r.InputInterface, r.OutputInterface} {
+		if name != "" && !devValidName(name) {
+			return fmt.Errorf("invalid interface name %q", name)
+		}
+	}
+	return nil
+}
+
+// sameFamily reports whether all the IP addresses are of the same family.
+func sameFamily(ips []net.IP) bool {
+	for _, ip := range ips {
+		if (ip.To4() == nil) != (ips[0].To4() == nil) {
+			return false
+		}
+	}
+	return true
+}
+
 func network(config *configs.Config) error {
 	if !config.Namespaces.Contains(configs.NEWNET) {
-		if len(config.Networks) > 0 || len(config.Routes) > 0 {
-			return errors.New("unable to apply network settings without a private NET namespace")
+		if len(config.Networks) > 0 || len(config.Routes) > 0 || len(config.Rules) > 0 {
+			return specerr.WithPath(errors.New("unable to apply network settings without a private NET namespace"), "linux", "namespaces")
+		}
+	}
+	var errs []error
+	for _, r := range config.Routes {
+		err := route(r)
+		if err == nil && r.InterfaceName != "" && !devValidName(r.InterfaceName) {
+			err = fmt.Errorf("invalid interface name %q", r.InterfaceName)
+		}
+		if err == nil && r.Gateway == "" && r.InterfaceName == "" {
+			err = errors.New("route interface must be set for a route without a gateway")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("route to %q: %w", r.Destination, err))
+		}
+	}
+	for _, r := range config.Rules {
+		if err := rule(r); err != nil {
+			errs = append(errs, fmt.Errorf("rule for table %d: %w", r.Table, err))
+		}
+	}
+	for _, n := range config.Networks {
+		switch n.Type {
+		case "loopback":
+		case "veth":
+			if err := vethNetwork(n); err != nil {
+				errs = append(errs, fmt.Errorf("veth network %q: %w", n.Name, err))
+			}
+		case "macvlan", "ipvlan":
+			if err := vlanNetwork(n); err != nil {
+				errs = append(errs, fmt.Errorf("%s network %q: %w", n.Type, n.Name, err))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown network type %q", n.Type))
 		}
 	}
+	return errors.Join(errs...)
+}
+
+func vethNetwork(n *configs.Network) error {
+	if n.Name == "" || n.HostInterfaceName == "" || n.Bridge == "" {
+		return errors.New("name, host interface name, and bridge must be set")
+	}
+	for _, name := range []string{n.Name, n.HostInterfaceName} {
+		if !devValidName(name) {
+			return fmt.Errorf("invalid interface name %q", name)
+		}
+	}
+	return networkAddresses(n)
+}
+
+// vlanModes are the supported modes of the macvlan and ipvlan networks.
+var vlanModes = map[string][]string{
+	"macvlan": {"", "bridge", "private", "vepa"},
+	"ipvlan":  {"", "l2", "l3"},
+}
+
+func vlanNetwork(n *configs.Network) error {
+	if n.Name == "" || n.Parent == "" {
+		return errors.New("name and parent must be set")
+	}
+	for _, name := range []string{n.Name, n.Parent} {
+		if !devValidName(name) {
+			return fmt.Errorf("invalid interface name %q", name)
+		}
+	}
+	if !slices.Contains(vlanModes[n.Type], n.Mode) {
+		return fmt.Errorf("invalid mode %q", n.Mode)
+	}
+	if n.Type == "ipvlan" && n.MacAddress != "" {
+		return errors.New("mac address can not be set, as ipvlan uses the one of the parent")
+	}
+	return networkAddresses(n)
+}
+
+// networkAddresses validates the addresses, MTU, and tx queue length of the
+// network interface.
+func networkAddresses(n *configs.Network) error {
+	if n.MacAddress != "" {
+		if _, err := net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	for _, addr := range []struct {
+		value string
+		ipv6  bool
+	}{{n.Address, false}, {n.IPv6Address, true}} {
+		if addr.value == "" {
+			continue
+		}
+		ip, _, err := net.ParseCIDR(addr.value)
+		if err != nil {
+			return err
+		}
+		if (ip.To4() == nil) != addr.ipv6 {
+			return fmt.Errorf("invalid address %q: wrong IP family", addr.value)
+		}
+	}
+	for _, gw := range []struct {
+		value string
+		ipv6  bool
+	}{{n.Gateway, false}, {n.IPv6Gateway, true}} {
+		if gw.value == "" {
+			continue
+		}
+		ip := net.ParseIP(gw.value)
+		if ip == nil || (ip.To4() == nil) != gw.ipv6 {
+			return fmt.Errorf("invalid gateway %q", gw.value)
+		}
+	}
+	if n.Mtu < 0 || n.TxQueueLen < 0 {
+		return errors.New("mtu and txqueuelen must not be negative")
+	}
 	return nil
 }
 
 func uts(config *configs.Config) error {
+	var errs []error
 	if config.Hostname != "" && !config.Namespaces.Contains(configs.NEWUTS) {
-		return errors.New("unable to set hostname without a private UTS namespace")
+		errs = append(errs, specerr.WithPath(errors.New("unable to set hostname without a private UTS namespace"), "hostname"))
 	}
 	if config.Domainname != "" && !config.Namespaces.Contains(configs.NEWUTS) {
-		return errors.New("unable to set domainname without a private UTS namespace")
+		errs = append(errs, specerr.WithPath(errors.New("unable to set domainname without a private UTS namespace"), "domainname"))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func security(config *configs.Config) error {
+	var errs []error
 	// restrict sys without mount namespace
 	if (len(config.MaskPaths) > 0 || len(config.ReadonlyPaths) > 0) &&
 		!config.Namespaces.Contains(configs.NEWNS) {
-		return errors.New("unable to restrict sys entries without a private MNT namespace")
+		path := "maskedPaths"
+		if len(config.MaskPaths) == 0 {
+			path = "readonlyPaths"
+		}
+		errs = append(errs, specerr.WithPath(errors.New("unable to restrict sys entries without a private MNT namespace"), "linux", path))
 	}
 	if config.ProcessLabel != "" && !selinux.GetEnabled() {
-		return errors.New("selinux label is specified in config, but selinux is disabled or not supported")
+		errs = append(errs, specerr.WithPath(errors.New("selinux label is specified in config, but selinux is disabled or not supported"), "process", "selinuxLabel"))
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func namespaces(config *configs.Config) error {
+	var errs []error
 	if config.Namespaces.Contains(configs.NEWUSER) {
 		if _, err := os.Stat("/proc/self/ns/user"); errors.Is(err, os.ErrNotExist) {
-			return errors.New("user namespaces aren't enabled in the kernel")
+			errs = append(errs, specerr.WithPath(errors.New("user namespaces aren't enabled in the kernel"), "linux", "namespaces"))
 		}
 		hasPath := config.Namespaces.PathOf(configs.NEWUSER) != ""
 		hasMappings := config.UIDMappings != nil || config.GIDMappings != nil
 		if !hasPath && !hasMappings {
-			return errors.New("user namespaces enabled, but no namespace path to join nor mappings to apply specified")
+			errs = append(errs, specerr.WithPath(errors.New("user namespaces enabled, but no namespace path to join nor mappings to apply specified"), "linux", "namespaces"))
 		}
 		// The hasPath && hasMappings validation case is handled in specconv --
 		// we cache the mappings in Config during specconv in the hasPath case,
 		// so we cannot do that validation here.
 	} else {
 		if config.UIDMappings != nil || config.GIDMappings != nil {
-			return errors.New("user namespace mappings specified, but user namespace isn't enabled in the config")
+			path := "uidMappings"
+			if config.UIDMappings == nil {
+				path = "gidMappings"
+			}
+			errs = append(errs, specerr.WithPath(errors.New("user namespace mappings specified, but user namespace isn't enabled in the config"), "linux", path))
 		}
 	}
 
 	if config.Namespaces.Contains(configs.NEWCGROUP) {
 		if _, err := os.Stat("/proc/self/ns/cgroup"); errors.Is(err, os.ErrNotExist) {
-			return errors.New("cgroup namespaces aren't enabled in the kernel")
+			errs = append(errs, specerr.WithPath(errors.New("cgroup namespaces aren't enabled in the kernel"), "linux", "namespaces"))
 		}
 	}
 
 	if config.Namespaces.Contains(configs.NEWTIME) {
 		if _, err := os.Stat("/proc/self/timens_offsets"); errors.Is(err, os.ErrNotExist) {
-			return errors.New("time namespaces aren't enabled in the kernel")
+			errs = append(errs, specerr.WithPath(errors.New("time namespaces aren't enabled in the kernel"), "linux", "namespaces"))
 		}
 		hasPath := config.Namespaces.PathOf(configs.NEWTIME) != ""
 		hasOffsets := config.TimeOffsets != nil
 		if hasPath && hasOffsets {
-			return errors.New("time namespace enabled, but both namespace path and time offsets specified -- you may only provide one")
+			errs = append(errs, specerr.WithPath(errors.New("time namespace enabled, but both namespace path and time offsets specified -- you may only provide one"), "linux", "timeOffsets"))
 		}
 	} else {
 		if config.TimeOffsets != nil {
-			return errors.New("time namespace offsets specified, but time namespace isn't enabled in the config")
+			errs = append(errs, specerr.WithPath(errors.New("time namespace offsets specified, but time namespace isn't enabled in the config"), "linux", "timeOffsets"))
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 // convertSysctlVariableToDotsSeparator can return sysctl variables in dots separator format.
@@ -231,16 +475,19 @@ func sysctl(config *configs.Config) error {
 		netOnce    sync.Once
 		hostnet    bool
 		hostnetErr error
+		errs       []error
 	)
 
-	for s := range config.Sysctl {
-		s := convertSysctlVariableToDotsSeparator(s)
+	for _, key := range slices.Sorted(maps.Keys(config.Sysctl)) {
+		fail := func(err error) {
+			errs = append(errs, specerr.WithPath(err, "linux", "sysctl", key))
+		}
+		s := convertSysctlVariableToDotsSeparator(key)
 		if validSysctlMap[s] || strings.HasPrefix(s, "fs.mqueue.") {
-			if config.Namespaces.Contains(configs.NEWIPC) {
-				continue
-			} else {
-				return fmt.Errorf("sysctl %q is not allowed in the hosts ipc namespace", s)
+			if !config.Namespaces.Contains(configs.NEWIPC) {
+				fail(fmt.Errorf("sysctl %q is not allowed in the hosts ipc namespace", s))
 			}
+			continue
 		}
 		if strings.HasPrefix(s, "net.") {
 			// Is container using host netns?
@@ -258,10 +505,9 @@ func sysctl(config *configs.Config) error {
 				hostnet, hostnetErr = isHostNetNS(path)
 			})
 			if hostnetErr != nil {
-				return fmt.Errorf("invalid netns path: %w", hostnetErr)
-			}
-			if hostnet {
-				return fmt.Errorf("sysctl %q not allowed in host network namespace", s)
+				fail(fmt.Errorf("invalid netns path: %w", hostnetErr))
+			} else if hostnet {
+				fail(fmt.Errorf("sysctl %q not allowed in host network namespace", s))
 			}
 			continue
 		}
@@ -272,7 +518,8 @@ func sysctl(config *configs.Config) error {
 				continue
 			case "kernel.hostname":
 				// This is namespaced but there's a conflicting (dedicated) OCI field for it.
-				return fmt.Errorf("sysctl %q is not allowed as it conflicts with the OCI %q field", s, "hostname")
+				fail(fmt.Errorf("sysctl %q is not allowed as it conflicts with the OCI %q field", s, "hostname"))
+				continue
 			}
 		}
 
@@ -294,15 +541,15 @@ func sysctl(config *configs.Config) error {
 			// so let's refuse this unless we know for sure it
 			// won't touch anything else.
 			if !config.Namespaces.Contains(configs.NEWUSER) {
-				return fmt.Errorf("setting ucounts without a user namespace not allowed: %v", s)
+				fail(fmt.Errorf("setting ucounts without a user namespace not allowed: %v", s))
 			}
 			continue
 		}
 
-		return fmt.Errorf("sysctl %q is not in a separate kernel namespace", s)
+		fail(fmt.Errorf("sysctl %q is not in a separate kernel namespace", s))
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func intelrdtCheck(config *configs.Config) error {
@@ -310,21 +557,22 @@ func intelrdtCheck(config *configs.Config) error {
 		return nil
 	}
 	if !intelRdtIsEnabled() {
-		return fmt.Errorf("intelRdt is specified in config, but Intel RDT is not enabled")
+		return specerr.WithPath(errors.New("intelRdt is specified in config, but Intel RDT is not enabled"), "linux", "intelRdt")
 	}
 
+	var errs []error
 	switch clos := config.IntelRdt.ClosID; {
 	case clos == ".", clos == "..", len(clos) > 1 && strings.Contains(clos, "/"):
-		return fmt.Errorf("invalid intelRdt.ClosID %q", clos)
+		errs = append(errs, specerr.WithPath(fmt.Errorf("invalid intelRdt.ClosID %q", clos), "linux", "intelRdt", "closID"))
 	}
 	if !intelRdtIsCATEnabled() && config.IntelRdt.L3CacheSchema != "" {
-		return errors.New("intelRdt.l3CacheSchema is specified in config, but Intel RDT/CAT is not enabled")
+		errs = append(errs, specerr.WithPath(errors.New("intelRdt.l3CacheSchema is specified in config, but Intel RDT/CAT is not enabled"), "linux", "intelRdt", "l3CacheSchema"))
 	}
 	if !intelRdtIsMBAEnabled() && config.IntelRdt.MemBwSchema != "" {
-		return errors.New("intelRdt.memBwSchema is specified in config, but Intel RDT/MBA is not enabled")
+		errs = append(errs, specerr.WithPath(errors.New("intelRdt.memBwSchema is specified in config, but Intel RDT/MBA is not enabled"), "linux", "intelRdt", "memBwSchema"))
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func cgroupsCheck(config *configs.Config) error {
@@ -334,7 +582,7 @@ func cgroupsCheck(config *configs.Config) error {
 	}
 
 	if (c.Name != "" || c.Parent != "") && c.Path != "" {
-		return fmt.Errorf("cgroup: either Path or Name and Parent should be used, got %+v", c)
+		return specerr.WithPath(fmt.Errorf("cgroup: either Path or Name and Parent should be used, got %+v", c), "linux", "cgroupsPath")
 	}
 
 	r := c.Resources
@@ -343,13 +591,13 @@ func cgroupsCheck(config *configs.Config) error {
 	}
 
 	if !cgroups.IsCgroup2UnifiedMode() && r.Unified != nil {
-		return cgroups.ErrV1NoUnified
+		return specerr.WithPath(cgroups.ErrV1NoUnified, "linux", "resources", "unified")
 	}
 
 	if cgroups.IsCgroup2UnifiedMode() {
 		_, err := cgroups.ConvertMemorySwapToCgroupV2Value(r.MemorySwap, r.Memory)
 		if err != nil {
-			return err
+			return specerr.WithPath(err, "linux", "resources", "memory", "swap")
 		}
 	}
 
@@ -407,24 +655,26 @@ func checkIDMapMounts(config *configs.Config, m *configs.Mount) error {
 }
 
 func mountsWarn(config *configs.Config) error {
-	for _, m := range config.Mounts {
+	var errs []error
+	for i, m := range config.Mounts {
 		if !filepath.IsAbs(m.Destination) {
-			return fmt.Errorf("mount %+v: relative destination path is **deprecated**, using it as relative to /", m)
+			errs = append(errs, specerr.WithPath(fmt.Errorf("mount %+v: relative destination path is **deprecated**, using it as relative to /", m), "mounts", i, "destination"))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func mountsStrict(config *configs.Config) error {
-	for _, m := range config.Mounts {
+	var errs []error
+	for i, m := range config.Mounts {
 		if err := checkBindOptions(m); err != nil {
-			return fmt.Errorf("invalid mount %+v: %w", m, err)
+			errs = append(errs, specerr.WithPath(fmt.Errorf("invalid mount %+v: %w", m, err), "mounts", i, "options"))
 		}
 		if err := checkIDMapMounts(config, m); err != nil {
-			return fmt.Errorf("invalid mount %+v: %w", m, err)
+			errs = append(errs, specerr.WithPath(fmt.Errorf("invalid mount %+v: %w", m, err), "mounts", i))
 		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func isHostNetNS(path string) (bool, error) {
@@ -449,39 +699,41 @@ func scheduler(config *configs.Config) error {
 		return nil
 	}
 	if s.Policy == "" {
-		return errors.New("scheduler policy is required")
+		return specerr.WithPath(errors.New("scheduler policy is required"), "process", "scheduler", "policy")
 	}
+	var errs []error
 	if s.Policy == specs.SchedOther || s.Policy == specs.SchedBatch {
 		if s.Nice < -20 || s.Nice > 19 {
-			return fmt.Errorf("invalid scheduler.nice: %d when scheduler.policy is %s", s.Nice, string(s.Policy))
+			errs = append(errs, specerr.WithPath(fmt.Errorf("invalid scheduler.nice: %d when scheduler.policy is %s", s.Nice, string(s.Policy)), "process", "scheduler", "nice"))
 		}
 	}
 	if s.Priority != 0 && (s.Policy != specs.SchedFIFO && s.Policy != specs.SchedRR) {
-		return errors.New("scheduler.priority can only be specified for SchedFIFO or SchedRR policy")
+		errs = append(errs, specerr.WithPath(errors.New("scheduler.priority can only be specified for SchedFIFO or SchedRR policy"), "process", "scheduler", "priority"))
 	}
 	if s.Policy != specs.SchedDeadline && (s.Runtime != 0 || s.Deadline != 0 || s.Period != 0) {
-		return errors.New("scheduler runtime/deadline/period can only be specified for SchedDeadline policy")
+		errs = append(errs, specerr.WithPath(errors.New("scheduler runtime/deadline/period can only be specified for SchedDeadline policy"), "process", "scheduler"))
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func ioPriority(config *configs.Config) error {
 	if config.IOPriority == nil {
 		return nil
 	}
+	var errs []error
 	priority := config.IOPriority.Priority
 	if priority < 0 || priority > 7 {
-		return fmt.Errorf("invalid ioPriority.Priority: %d", priority)
+		errs = append(errs, specerr.WithPath(fmt.Errorf("invalid ioPriority.Priority: %d", priority), "process", "ioPriority", "priority"))
 	}
 
 	switch class := config.IOPriority.Class; class {
 	case specs.IOPRIO_CLASS_RT, specs.IOPRIO_CLASS_BE, specs.IOPRIO_CLASS_IDLE:
 		// Valid class, do nothing.
 	default:
-		return fmt.Errorf("invalid ioPriority.Class: %q", class)
+		errs = append(errs, specerr.WithPath(fmt.Errorf("invalid ioPriority.Class: %q", class), "process", "ioPriority", "class"))
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
 func memoryPolicy(config *configs.Config) error {
@@ -492,17 +744,17 @@ func memoryPolicy(config *configs.Config) error {
 	switch mpol.Mode {
 	case unix.MPOL_DEFAULT, unix.MPOL_LOCAL:
 		if mpol.Nodes != nil && mpol.Nodes.Count() != 0 {
-			return fmt.Errorf("memory policy mode requires 0 nodes but got %d", mpol.Nodes.Count())
+			return specerr.WithPath(fmt.Errorf("memory policy mode requires 0 nodes but got %d", mpol.Nodes.Count()), "linux", "memoryPolicy", "nodes")
 		}
 	case unix.MPOL_BIND, unix.MPOL_INTERLEAVE,
 		unix.MPOL_PREFERRED_MANY, unix.MPOL_WEIGHTED_INTERLEAVE:
 		if mpol.Nodes == nil || mpol.Nodes.Count() == 0 {
-			return fmt.Errorf("memory policy mode requires at least one node but got 0")
+			return specerr.WithPath(fmt.Errorf("memory policy mode requires at least one node but got 0"), "linux", "memoryPolicy", "nodes")
 		}
 	case unix.MPOL_PREFERRED:
 		// Zero or more nodes are allowed by the kernel.
 	default:
-		return fmt.Errorf("invalid memory policy mode: %d", mpol.Mode)
+		return specerr.WithPath(fmt.Errorf("invalid memory policy mode: %d", mpol.Mode), "linux", "memoryPolicy", "mode")
 	}
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/opencontainers/runc/internal/specerr"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"golang.org/x/sys/unix"
@@ -23,6 +24,45 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestValidateAll(t *testing.T) {
+	config := &configs.Config{
+		Rootfs:   "/var",
+		Hostname: "host",
+		Sysctl: map[string]string{
+			"kernel.shmmax":       "1",
+			"net/ipv4/ip_forward": "1",
+		},
+		Mounts: []*configs.Mount{
+			{Source: "/tmp", Destination: "/tmp", Device: "bind", Flags: unix.MS_BIND},
+			{Source: "tmpfs", Destination: "tmp", Device: "tmpfs"},
+		},
+	}
+
+	errs, warns := ValidateAll(config)
+	expected := []string{
+		"/hostname",
+		"/linux/sysctl/kernel.shmmax",
+		"/linux/sysctl/net~1ipv4~1ip_forward",
+	}
+	if len(errs) != len(expected) {
+		t.Fatalf("expected %d errors, got %d: %v", len(expected), len(errs), errs)
+	}
+	for i, err := range errs {
+		if path := specerr.Path(err); path != expected[i] {
+			t.Errorf("error %d (%v): expected path %q, got %q", i, err, expected[i], path)
+		}
+	}
+	if len(warns) != 1 || specerr.Path(warns[0]) != "/mounts/1/destination" {
+		t.Errorf("expected a single warning for /mounts/1/destination, got %v", warns)
+	}
+
+	// Validate returns the first error, without the path in its message.
+	err := Validate(config)
+	if err == nil || err.Error() != errs[0].Error() || strings.HasPrefix(err.Error(), "/") {
+		t.Errorf("expected %q, got %v", errs[0], err)
+	}
+}
+
 func TestValidateWithInvalidRootfs(t *testing.T) {
 	dir := "rootfs"
 	if err := os.Symlink("/var", dir); err != nil {
@@ -68,6 +108,138 @@ func TestValidateNetworkRoutesWithoutNETNamespace(t *testing.T) {
 	}
 }
 
+func TestValidateRoutesAndRules(t *testing.T) {
+	testCases := []struct {
+		name    string
+		routes  []*configs.Route
+		rules   []*configs.Rule
+		isError bool
+	}{
+		{
+			name: "valid",
+			routes: []*configs.Route{
+				{Gateway: "192.0.2.1"},
+				{Destination: "198.51.100.0/24", Source: "192.0.2.2", InterfaceName: "eth0", Metric: 100, Table: 100, Scope: "link"},
+				{Destination: "::/0", Gateway: "2001:db8::1", InterfaceName: "eth0"},
+				{Destination: "2001:db8:1::/64", InterfaceName: "eth0"},
+			},
+			rules: []*configs.Rule{
+				{Priority: 100, Source: "192.0.2.0/24", Table: 100},
+				{Destination: "2001:db8:1::/64", Table: 100},
+				{InputInterface: "eth1", Mark: 1, Mask: 0xff, IPv6: true, Table: 100},
+			},
+		},
+		{name: "route without destination and gateway", routes: []*configs.Route{{InterfaceName: "eth0"}}, isError: true},
+		{name: "on-link route without interface", routes: []*configs.Route{{Destination: "198.51.100.0/24"}}, isError: true},
+		{name: "route mixed families", routes: []*configs.Route{{Destination: "::/0", Gateway: "192.0.2.1"}}, isError: true},
+		{name: "route invalid scope", routes: []*configs.Route{{Gateway: "192.0.2.1", Scope: "global"}}, isError: true},
+		{name: "route negative metric", routes: []*configs.Route{{Gateway: "192.0.2.1", Metric: -1}}, isError: true},
+		{name: "route invalid interface", routes: []*configs.Route{{Gateway: "192.0.2.1", InterfaceName: "eth/0"}}, isError: true},
+		{name: "rule without table", rules: []*configs.Rule{{Source: "192.0.2.0/24"}}, isError: true},
+		{name: "rule invalid address", rules: []*configs.Rule{{Source: "192.0.2.1", Table: 100}}, isError: true},
+		{name: "rule mixed families", rules: []*configs.Rule{{Source: "192.0.2.0/24", Destination: "2001:db8::/64", Table: 100}}, isError: true},
+		{name: "rule wrong family", rules: []*configs.Rule{{Source: "192.0.2.0/24", IPv6: true, Table: 100}}, isError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &configs.Config{
+				Rootfs:     "/var",
+				Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+				Routes:     tc.routes,
+				Rules:      tc.rules,
+			}
+			err := Validate(config)
+			if tc.isError && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tc.isError && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestValidateVethNetwork(t *testing.T) {
+	valid := configs.Network{
+		Type:              "veth",
+		Name:              "eth0",
+		HostInterfaceName: "veth-host0",
+		Bridge:            "br0",
+		MacAddress:        "02:42:ac:11:00:02",
+		Address:           "172.17.0.2/16",
+		Gateway:           "172.17.0.1",
+		IPv6Address:       "fd00::2/64",
+		IPv6Gateway:       "fd00::1",
+		Mtu:               1500,
+	}
+	testCases := []struct {
+		name    string
+		modify  func(n *configs.Network)
+		isError bool
+	}{
+		{name: "valid", modify: func(*configs.Network) {}},
+		{name: "no bridge", modify: func(n *configs.Network) { n.Bridge = "" }, isError: true},
+		{name: "no host interface", modify: func(n *configs.Network) { n.HostInterfaceName = "" }, isError: true},
+		{name: "invalid name", modify: func(n *configs.Network) { n.Name = "eth/0" }, isError: true},
+		{name: "invalid mac", modify: func(n *configs.Network) { n.MacAddress = "02:42" }, isError: true},
+		{name: "address without mask", modify: func(n *configs.Network) { n.Address = "172.17.0.2" }, isError: true},
+		{name: "ipv6 address as ipv4", modify: func(n *configs.Network) { n.Address = "fd00::2/64" }, isError: true},
+		{name: "ipv4 gateway as ipv6", modify: func(n *configs.Network) { n.IPv6Gateway = "172.17.0.1" }, isError: true},
+		{name: "negative mtu", modify: func(n *configs.Network) { n.Mtu = -1 }, isError: true},
+		{name: "unknown type", modify: func(n *configs.Network) { n.Type = "foo" }, isError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			network := valid
+			tc.modify(&network)
+			config := &configs.Config{
+				Rootfs:     "/var",
+				Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+				Networks:   []*configs.Network{&network},
+			}
+			err := Validate(config)
+			if tc.isError && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tc.isError && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestValidateVlanNetwork(t *testing.T) {
+	testCases := []struct {
+		name    string
+		network configs.Network
+		isError bool
+	}{
+		{name: "macvlan", network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eth1", Mode: "vepa", MacAddress: "02:42:ac:11:00:02", Address: "10.0.0.2/24"}},
+		{name: "macvlan default mode", network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eth1"}},
+		{name: "ipvlan", network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eth1", Mode: "l3", Address: "10.0.0.2/24"}},
+		{name: "no parent", network: configs.Network{Type: "macvlan", Name: "eth0"}, isError: true},
+		{name: "macvlan invalid mode", network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "eth1", Mode: "l2"}, isError: true},
+		{name: "ipvlan invalid mode", network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eth1", Mode: "bridge"}, isError: true},
+		{name: "ipvlan mac", network: configs.Network{Type: "ipvlan", Name: "eth0", Parent: "eth1", MacAddress: "02:42:ac:11:00:02"}, isError: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := &configs.Config{
+				Rootfs:     "/var",
+				Namespaces: []configs.Namespace{{Type: configs.NEWNET}},
+				Networks:   []*configs.Network{&tc.network},
+			}
+			err := Validate(config)
+			if tc.isError && err == nil {
+				t.Error("expected error, got nil")
+			}
+			if !tc.isError && err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
 func TestValidateHostname(t *testing.T) {
 	config := &configs.Config{
 		Rootfs:   "/var",
@@ -1001,6 +1173,102 @@ func TestValidateNetDevices(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "network device settings",
+			config: &configs.Config{
+				Namespaces: configs.Namespaces(
+					[]configs.Namespace{
+						{
+							Type: configs.NEWNET,
+						},
+					},
+				),
+				NetDevices: map[string]*configs.LinuxNetDevice{
+					"eth0": {
+						Name:      "c0",
+						Addresses: []string{"192.0.2.2/24", "2001:db8::2/64"},
+						MTU:       1400,
+						Routes: []*configs.Route{
+							{Gateway: "192.0.2.1"},
+							{Destination: "2001:db8:1::/64", Gateway: "2001:db8::1"},
+							{Destination: "198.51.100.0/24"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:  "network device bad address",
+			isErr: true,
+			config: &configs.Config{
+				Namespaces: configs.Namespaces(
+					[]configs.Namespace{
+						{
+							Type: configs.NEWNET,
+						},
+					},
+				),
+				NetDevices: map[string]*configs.LinuxNetDevice{
+					"eth0": {
+						Addresses: []string{"192.0.2.2"},
+					},
+				},
+			},
+		},
+		{
+			name:  "network device negative mtu",
+			isErr: true,
+			config: &configs.Config{
+				Namespaces: configs.Namespaces(
+					[]configs.Namespace{
+						{
+							Type: configs.NEWNET,
+						},
+					},
+				),
+				NetDevices: map[string]*configs.LinuxNetDevice{
+					"eth0": {
+						MTU: -1,
+					},
+				},
+			},
+		},
+		{
+			name:  "network device route without destination and gateway",
+			isErr: true,
+			config: &configs.Config{
+				Namespaces: configs.Namespaces(
+					[]configs.Namespace{
+						{
+							Type: configs.NEWNET,
+						},
+					},
+				),
+				NetDevices: map[string]*configs.LinuxNetDevice{
+					"eth0": {
+						Routes: []*configs.Route{{Source: "192.0.2.2"}},
+					},
+				},
+			},
+		},
+		{
+			name:  "network device route mixed families",
+			isErr: true,
+			config: &configs.Config{
+				Namespaces: configs.Namespaces(
+					[]configs.Namespace{
+						{
+							Type: configs.NEWNET,
+						},
+					},
+				),
+				NetDevices: map[string]*configs.LinuxNetDevice{
+					"eth0": {
+						Routes: []*configs.Route{{Destination: "2001:db8:1::/64", Gateway: "192.0.2.1"}},
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2,6 +2,7 @@ package libcontainer
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -33,6 +34,7 @@ const stdioFdCount = 3
 type Container struct {
 	id                   string
 	stateDir             string
+	store                StateStore
 	config               *configs.Config
 	cgroupManager        cgroups.Manager
 	intelRdtManager      *intelrdt.Manager
@@ -43,12 +45,19 @@ type Container struct {
 	state                containerState
 	created              time.Time
 	fifo                 *os.File
+	exitStatus           *ExitStatus
+	netDevices           map[string]NetDeviceState
+	stateLock            io.Closer
 }
 
 // State represents a running container's state
 type State struct {
 	BaseState
 
+	// SchemaVersion is the version of the schema the state is persisted
+	// with (see [StateSchemaVersion]).
+	SchemaVersion int `json:"schema_version"`
+
 	// Platform specific fields below.
 
 	// Specified if the container was started under the rootless mode.
@@ -77,6 +86,16 @@ type State struct {
 	// Empty if the container does not have aindividual dedicated monitoring
 	// group.
 	IntelRdtMonPath string `json:"intel_rdt_mon_path,omitempty"`
+
+	// ExitStatus is the exit status of the container's init process.
+	// It is only set once init has exited, and only if the status was
+	// collected (see [Container.Wait] and [Container.RecordExit]).
+	ExitStatus *ExitStatus `json:"exit_status,omitempty"`
+
+	// NetDevices are the network devices moved to the container's network
+	// namespace, keyed by their names on the host. They are restored when
+	// the container is destroyed.
+	NetDevices map[string]NetDeviceState `json:"net_devices,omitempty"`
 }
 
 // ID returns the container's unique ID
@@ -150,6 +169,12 @@ func (c *Container) Stats() (*Stats, error) {
 			return stats, fmt.Errorf("unable to get container Intel RDT stats: %w", err)
 		}
 	}
+	if stats.Interfaces, err = c.netnsInterfaceStats(); err != nil {
+		return stats, fmt.Errorf("unable to get network stats: %w", err)
+	}
+	if stats.Interfaces != nil {
+		return stats, nil
+	}
 	for _, iface := range c.config.Networks {
 		switch iface.Type {
 		case "veth":
@@ -168,6 +193,10 @@ func (c *Container) Stats() (*Stats, error) {
 func (c *Container) Set(config configs.Config) error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
@@ -205,6 +234,10 @@ func (c *Container) Set(config configs.Config) error {
 func (c *Container) Start(process *Process) error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	return c.start(process)
 }
 
@@ -214,6 +247,10 @@ func (c *Container) Start(process *Process) error {
 func (c *Container) Run(process *Process) error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	if err := c.start(process); err != nil {
 		return err
 	}
@@ -227,6 +264,10 @@ func (c *Container) Run(process *Process) error {
 func (c *Container) Exec() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	return c.exec()
 }
 
@@ -235,7 +276,11 @@ func (c *Container) exec() error {
 	if err := handleFifo(path, c.initProcess.pid()); err != nil {
 		return err
 	}
+	c.publishEvent(EventStarted, c.initProcess.pid(), nil)
 
+	// The container is started, so release the state lock to let poststart
+	// hooks query the container state.
+	c.unlockState()
 	return c.postStart()
 }
 
@@ -426,6 +471,8 @@ func (c *Container) start(process *Process) (retErr error) {
 
 	if process.Init {
 		c.fifo.Close()
+	} else {
+		c.publishEvent(EventExecStarted, parent.pid(), nil)
 	}
 	return nil
 }
@@ -795,6 +842,16 @@ func (c *Container) newInitConfig(process *Process) *initConfig {
 func (c *Container) Destroy() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		// The state directory is already removed, so there is nothing to
+		// serialize with, but the rest of the container might still need
+		// to be destroyed.
+		if !errors.Is(err, ErrNotExist) {
+			return err
+		}
+	} else {
+		defer c.unlockState()
+	}
 	if err := c.state.destroy(); err != nil {
 		return fmt.Errorf("unable to destroy container: %w", err)
 	}
@@ -806,6 +863,10 @@ func (c *Container) Destroy() error {
 func (c *Container) Pause() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
@@ -815,9 +876,13 @@ func (c *Container) Pause() error {
 		if err := c.cgroupManager.Freeze(cgroups.Frozen); err != nil {
 			return err
 		}
-		return c.state.transition(&pausedState{
+		if err := c.state.transition(&pausedState{
 			c: c,
-		})
+		}); err != nil {
+			return err
+		}
+		c.publishEvent(EventPaused, c.initProcess.pid(), nil)
+		return nil
 	}
 	return ErrNotRunning
 }
@@ -829,6 +894,10 @@ func (c *Container) Pause() error {
 func (c *Container) Resume() error {
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 	status, err := c.currentStatus()
 	if err != nil {
 		return err
@@ -839,9 +908,13 @@ func (c *Container) Resume() error {
 	if err := c.cgroupManager.Freeze(cgroups.Thawed); err != nil {
 		return err
 	}
-	return c.state.transition(&runningState{
+	if err := c.state.transition(&runningState{
 		c: c,
-	})
+	}); err != nil {
+		return err
+	}
+	c.publishEvent(EventResumed, c.initProcess.pid(), nil)
+	return nil
 }
 
 // NotifyOOM returns a read-only channel signaling when the container receives
@@ -868,6 +941,21 @@ func (c *Container) NotifyMemoryPressure(level PressureLevel) (<-chan struct{},
 	return notifyMemoryPressure(c.cgroupManager.Path("memory"), level)
 }
 
+// NotifyPressure returns a read-only channel signaling each time the given
+// PSI trigger fires for the container. The channel is closed once the
+// container's cgroup is empty or removed, or ctx is done. This requires
+// cgroup v2.
+func (c *Container) NotifyPressure(ctx context.Context, trigger PressureTrigger) (<-chan struct{}, error) {
+	if !cgroups.IsCgroup2UnifiedMode() {
+		return nil, errors.New("pressure notifications require cgroup v2")
+	}
+	// XXX(cyphar): This requires cgroups.
+	if c.config.RootlessCgroups {
+		logrus.Warn("getting pressure notifications may fail if you don't have the full access to cgroups")
+	}
+	return notifyPressure(ctx, c.cgroupManager.Path(""), trigger)
+}
+
 func (c *Container) updateState(process parentProcess) (*State, error) {
 	if process != nil {
 		c.initProcess = process
@@ -879,30 +967,8 @@ func (c *Container) updateState(process parentProcess) (*State, error) {
 	return state, nil
 }
 
-func (c *Container) saveState(s *State) (retErr error) {
-	tmpFile, err := os.CreateTemp(c.stateDir, "state-")
-	if err != nil {
-		return err
-	}
-
-	defer func() {
-		if retErr != nil {
-			tmpFile.Close()
-			os.Remove(tmpFile.Name())
-		}
-	}()
-
-	err = utils.WriteJSON(tmpFile, s)
-	if err != nil {
-		return err
-	}
-	err = tmpFile.Close()
-	if err != nil {
-		return err
-	}
-
-	stateFilePath := filepath.Join(c.stateDir, stateFilename)
-	return os.Rename(tmpFile.Name(), stateFilePath)
+func (c *Container) saveState(s *State) error {
+	return c.store.Save(c.id, s)
 }
 
 func (c *Container) currentStatus() (Status, error) {
@@ -978,6 +1044,7 @@ func (c *Container) currentState() *State {
 		intelRdtMonPath = c.intelRdtManager.GetMonPath()
 	}
 	state := &State{
+		SchemaVersion: StateSchemaVersion,
 		BaseState: BaseState{
 			ID:                   c.ID(),
 			Config:               *c.config,
@@ -991,6 +1058,8 @@ func (c *Container) currentState() *State {
 		IntelRdtMonPath:     intelRdtMonPath,
 		NamespacePaths:      make(map[configs.NamespaceType]string),
 		ExternalDescriptors: externalDescriptors,
+		ExitStatus:          c.exitStatus,
+		NetDevices:          c.netDevices,
 	}
 	if pid > 0 {
 		for _, ns := range c.config.Namespaces {
@@ -242,6 +242,7 @@ func TestGetContainerStateAfterUpdate(t *testing.T) {
 
 	container := &Container{
 		stateDir: t.TempDir(),
+		store:    newMemStateStore(),
 		id:       "myid",
 		config: &configs.Config{
 			Namespaces: []configs.Namespace{
@@ -298,6 +298,10 @@ func (c *Container) Checkpoint(criuOpts *CriuOpts) error {
 	const logFile = "dump.log"
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 
 	// Checkpoint is unlikely to work if os.Geteuid() != 0 || system.RunningInUserNS().
 	// (CLI prints a warning)
@@ -639,6 +643,10 @@ func (c *Container) Restore(process *Process, criuOpts *CriuOpts) error {
 	const logFile = "restore.log"
 	c.m.Lock()
 	defer c.m.Unlock()
+	if err := c.lockState(); err != nil {
+		return err
+	}
+	defer c.unlockState()
 
 	var extraFiles []*os.File
 
@@ -1177,6 +1185,7 @@ func (c *Container) criuNotifications(resp *criurpc.CriuResp, process *Process,
 		if _, err := c.updateState(r); err != nil {
 			return err
 		}
+		c.publishEvent(EventStarted, r.pid(), nil)
 		if err := os.Remove(filepath.Join(c.stateDir, "checkpoint")); err != nil {
 			if !errors.Is(err, os.ErrNotExist) {
 				logrus.Error(err)
@@ -11,4 +11,7 @@ var (
 	ErrNotRunning     = errors.New("container not running")
 	ErrNotPaused      = errors.New("container not paused")
 	ErrCgroupNotExist = errors.New("cgroup not exist")
+	ErrExitUnknown    = errors.New("container init exit status unknown")
+	ErrLocked         = errors.New("container is locked")
+	ErrStateVersion   = errors.New("unknown state schema version")
 )
@@ -0,0 +1,282 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventType is the type of an event received from [Container.Events].
+type EventType string
+
+const (
+	// EventOOM means the container has hit an OOM condition.
+	EventOOM EventType = "oom"
+	// EventMemoryPressure means the container has reached the memory
+	// pressure level requested by [EventsOptions.MemoryPressure].
+	EventMemoryPressure EventType = "memory-pressure"
+	// EventPressure means one of the PSI triggers requested by
+	// [EventsOptions.Pressure] has fired.
+	EventPressure EventType = "pressure"
+	// EventStats is a periodic statistics sample (see [EventsOptions.StatsInterval]).
+	EventStats EventType = "stats"
+	// EventLifecycle is a container lifecycle event, such as a state change
+	// or init exit (see [EventsOptions.Lifecycle]).
+	EventLifecycle EventType = "lifecycle"
+)
+
+// EventsOptions specifies which events are sent by [Container.Events].
+// OOM events are always sent.
+type EventsOptions struct {
+	// StatsInterval, if not zero, is the interval to send stats at.
+	StatsInterval time.Duration
+
+	// MemoryPressure, if not nil, enables memory pressure events for the
+	// given level. Only supported for cgroup v1.
+	MemoryPressure *PressureLevel
+
+	// Pressure is a list of PSI triggers to send pressure events for.
+	// Only supported for cgroup v2.
+	Pressure []PressureTrigger
+
+	// Lifecycle enables lifecycle events. With this option, events are sent
+	// until the container is deleted, and a stopped container is accepted.
+	Lifecycle bool
+}
+
+// Event is an event received from [Container.Events].
+type Event struct {
+	Type EventType
+
+	// Timestamp is the time the event was received at (or, for lifecycle
+	// events, the time of the transition).
+	Timestamp time.Time
+
+	// Stats is set for EventStats.
+	Stats *Stats
+
+	// Lifecycle is set for EventLifecycle.
+	Lifecycle *LifecycleEvent
+
+	// Pressure is the trigger which has fired, for EventPressure.
+	Pressure *PressureTrigger
+}
+
+// Events returns a channel of container events, as specified by opts.
+//
+// OOM, memory pressure, PSI pressure, and stats events are only sent while the container
+// cgroup is alive; the channel is closed once it is gone, unless lifecycle
+// events are requested, in which case the channel is closed after the
+// deleted event. In any case, the channel is closed once ctx is done.
+//
+// It is an error to call Events for a stopped container, unless lifecycle
+// events are requested.
+func (c *Container) Events(ctx context.Context, opts *EventsOptions) (<-chan Event, error) {
+	if opts == nil {
+		opts = &EventsOptions{}
+	}
+	if opts.StatsInterval < 0 {
+		return nil, errors.New("stats interval must not be negative")
+	}
+	status, err := c.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status == Stopped && !opts.Lifecycle {
+		return nil, ErrNotRunning
+	}
+
+	var (
+		oom, pressure <-chan struct{}
+		psi           chan int
+		lifecycle     <-chan LifecycleEvent
+		exited        <-chan LifecycleEvent
+		tick          <-chan time.Time
+	)
+	ctx, cancel := context.WithCancel(ctx)
+	if opts.Lifecycle {
+		lifecycle, err = c.LifecycleEvents(ctx)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		// Make sure the exited event is sent once init exits, even if
+		// nobody publishes it (see watchExit).
+		if status != Stopped {
+			exited = c.watchExit(ctx)
+		}
+	}
+	if status != Stopped {
+		oom, err = c.NotifyOOM()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		if opts.MemoryPressure != nil {
+			pressure, err = c.NotifyMemoryPressure(*opts.MemoryPressure)
+			if err != nil {
+				cancel()
+				drain(oom)
+				return nil, err
+			}
+		}
+		if len(opts.Pressure) > 0 {
+			psi = make(chan int)
+		}
+		for i, t := range opts.Pressure {
+			ch, err := c.NotifyPressure(ctx, t)
+			if err != nil {
+				cancel()
+				drain(oom)
+				drain(pressure)
+				return nil, err
+			}
+			// Merge all the triggers into psi, identifying each one
+			// by its index, until ch is closed once ctx is done. The
+			// channel is passed as psi itself is reset below.
+			go func(psi chan<- int) {
+				for range ch {
+					select {
+					case psi <- i:
+					case <-ctx.Done():
+					}
+				}
+			}(psi)
+		}
+	}
+
+	ch := make(chan Event)
+	go func() {
+		defer func() {
+			cancel()
+			// The notifiers can not be stopped, so make sure
+			// they are not blocked on us forever.
+			drain(oom)
+			drain(pressure)
+			close(ch)
+		}()
+		if oom != nil && opts.StatsInterval > 0 {
+			ticker := time.NewTicker(opts.StatsInterval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		send := func(e Event) bool {
+			select {
+			case ch <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		// Whether the exited event is sent, either from the log or from
+		// the watcher, so that it is not sent twice.
+		sentExited := false
+		for oom != nil || lifecycle != nil {
+			var e Event
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-oom:
+				if !ok {
+					// The cgroup is gone, and so are the cgroup-based events.
+					drain(pressure)
+					oom, pressure, psi, tick = nil, nil, nil, nil
+					continue
+				}
+				e = Event{Type: EventOOM, Timestamp: time.Now()}
+			case _, ok := <-pressure:
+				if !ok {
+					pressure = nil
+					continue
+				}
+				e = Event{Type: EventMemoryPressure, Timestamp: time.Now()}
+			case i := <-psi:
+				e = Event{Type: EventPressure, Timestamp: time.Now(), Pressure: &opts.Pressure[i]}
+			case t := <-tick:
+				s, err := c.Stats()
+				if err != nil {
+					logrus.Error(err)
+					continue
+				}
+				e = Event{Type: EventStats, Timestamp: t, Stats: s}
+			case l, ok := <-lifecycle:
+				if !ok {
+					lifecycle = nil
+					continue
+				}
+				if l.Type == EventExited {
+					if sentExited {
+						continue
+					}
+					sentExited = true
+				}
+				e = Event{Type: EventLifecycle, Timestamp: l.Timestamp, Lifecycle: &l}
+			case l, ok := <-exited:
+				exited = nil
+				if !ok || sentExited {
+					continue
+				}
+				sentExited = true
+				e = Event{Type: EventLifecycle, Timestamp: l.Timestamp, Lifecycle: &l}
+			}
+			if !send(e) {
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// watchExit waits for the container's init process to exit, without reaping
+// it, so that its parent can still wait for it, and sends the exited event to
+// the returned channel. Unlike [Container.Wait], it neither records the exit
+// status nor publishes the event, which is left to whoever reaps init (see
+// [Container.RecordExit]). The channel is closed once ctx is done.
+func (c *Container) watchExit(ctx context.Context) <-chan LifecycleEvent {
+	c.m.Lock()
+	init := c.initProcess
+	c.m.Unlock()
+
+	ch := make(chan LifecycleEvent, 1)
+	if init == nil {
+		close(ch)
+		return ch
+	}
+	go func() {
+		defer close(ch)
+		ws, err := waitNonChild(ctx, init.pid(), c.initProcessStartTime)
+		if ctx.Err() != nil {
+			return
+		}
+		e := LifecycleEvent{Type: EventExited, Timestamp: time.Now().UTC(), Pid: init.pid()}
+		switch {
+		case err == nil:
+			e.ExitStatus = newExitStatus(ws, e.Timestamp)
+		case errors.Is(err, ErrExitUnknown):
+			// Init is reaped already, and its exit status might have
+			// been recorded by whoever has reaped it.
+			if state, err := c.store.Load(c.id); err == nil && state.ExitStatus != nil {
+				e.ExitStatus = state.ExitStatus
+				e.Timestamp = state.ExitStatus.ExitedAt
+			}
+		default:
+			logrus.Warnf("unable to wait for the container %s init: %v", c.id, err)
+			return
+		}
+		ch <- e
+	}()
+	return ch
+}
+
+// drain reads from ch until it is closed, in the background.
+func drain(ch <-chan struct{}) {
+	if ch == nil {
+		return
+	}
+	go func() {
+		for range ch {
+		}
+	}()
+}
@@ -20,6 +20,9 @@ import (
 const (
 	stateFilename    = "state.json"
 	execFifoFilename = "exec.fifo"
+	eventsFilename   = "events.log"
+	exitedFilename   = "exited"
+	lockFilename     = "state.lock"
 )
 
 // Create creates a new container with the given id inside a given state
@@ -32,7 +35,10 @@ const (
 // The id must not be empty and consist of only the following characters:
 // ASCII letters, digits, underscore, plus, minus, period. The id must be
 // unique and non-existent for the given root path.
-func Create(root, id string, config *configs.Config) (*Container, error) {
+//
+// The container state is persisted in the root directory, unless another
+// [StateStore] is set with [WithStateStore].
+func Create(root, id string, config *configs.Config, opts ...Option) (*Container, error) {
 	if root == "" {
 		return nil, errors.New("root not set")
 	}
@@ -89,11 +95,16 @@ func Create(root, id string, config *configs.Config) (*Container, error) {
 
 	// Parent directory is already created above, so Mkdir is enough.
 	if err := os.Mkdir(stateDir, 0o711); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			// Lost the race with a concurrent Create.
+			return nil, ErrExist
+		}
 		return nil, err
 	}
 	c := &Container{
 		id:              id,
 		stateDir:        stateDir,
+		store:           newOptions(root, opts).store,
 		config:          config,
 		cgroupManager:   cm,
 		intelRdtManager: intelrdt.NewManager(config, id, ""),
@@ -105,7 +116,16 @@ func Create(root, id string, config *configs.Config) (*Container, error) {
 // Load takes a path to the state directory (root) and an id of an existing
 // container, and returns a Container object reconstructed from the saved
 // state. This presents a read only view of the container.
-func Load(root, id string) (*Container, error) {
+//
+// Load does not take the container lock, so it does not wait for another
+// process performing a state-changing operation on the container (which
+// might be running the container hooks, or criu), and can be used from the
+// hooks. The state is saved atomically, so Load either sees the state from
+// before or after such an operation.
+//
+// The same [StateStore] the container was created with must be set with
+// [WithStateStore], if any.
+func Load(root, id string, opts ...Option) (*Container, error) {
 	if root == "" {
 		return nil, errors.New("root not set")
 	}
@@ -117,10 +137,15 @@ func Load(root, id string) (*Container, error) {
 	if err != nil {
 		return nil, err
 	}
-	state, err := loadState(stateDir)
+	store := newOptions(root, opts).store
+	state, err := store.Load(id)
 	if err != nil {
 		return nil, err
 	}
+	// Cgroup v1 fs manager expect Resources to never be nil.
+	if state.Config.Cgroups.Resources == nil {
+		state.Config.Cgroups.Resources = &cgroups.Resources{}
+	}
 	r := &nonChildProcess{
 		processPid:       state.InitProcessPid,
 		processStartTime: state.InitProcessStartTime,
@@ -138,7 +163,10 @@ func Load(root, id string) (*Container, error) {
 		cgroupManager:        cm,
 		intelRdtManager:      intelrdt.NewManager(&state.Config, id, state.IntelRdtPath),
 		stateDir:             stateDir,
+		store:                store,
 		created:              state.Created,
+		exitStatus:           state.ExitStatus,
+		netDevices:           state.NetDevices,
 	}
 	c.state = &loadedState{c: c}
 	if err := c.refreshState(); err != nil {
@@ -152,22 +180,21 @@ func loadState(root string) (*State, error) {
 	if err != nil {
 		return nil, err
 	}
-	f, err := os.Open(stateFilePath)
+	data, err := os.ReadFile(stateFilePath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return nil, ErrNotExist
 		}
 		return nil, err
 	}
-	defer f.Close()
+	data, err = migrateState(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load state %s: %w", stateFilePath, err)
+	}
 	var state *State
-	if err := json.NewDecoder(f).Decode(&state); err != nil {
+	if err := json.Unmarshal(data, &state); err != nil {
 		return nil, err
 	}
-	// Cgroup v1 fs manager expect Resources to never be nil.
-	if state.Config.Cgroups.Resources == nil {
-		state.Config.Cgroups.Resources = &cgroups.Resources{}
-	}
 	return state, nil
 }
 
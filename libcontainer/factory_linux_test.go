@@ -81,6 +81,90 @@ func TestFactoryLoadContainer(t *testing.T) {
 	}
 }
 
+func TestFactoryLoadStateSchemaVersion(t *testing.T) {
+	if len(stateMigrations) != StateSchemaVersion {
+		t.Fatalf("expected %d state migrations, got %d", StateSchemaVersion, len(stateMigrations))
+	}
+	for _, tc := range []struct {
+		name  string
+		state string
+		err   error
+	}{
+		{
+			name:  "legacy",
+			state: `{"id":"1","init_process_pid":1024,"config":{"rootfs":"/mycontainer/root","cgroups":{}}}`,
+		},
+		{
+			name:  "current",
+			state: `{"id":"1","schema_version":1,"init_process_pid":1024,"config":{"rootfs":"/mycontainer/root","cgroups":{}}}`,
+		},
+		{
+			name:  "newer",
+			state: `{"id":"1","schema_version":1000,"init_process_pid":1024,"config":{"rootfs":"/mycontainer/root","cgroups":{}}}`,
+			err:   ErrStateVersion,
+		},
+		{
+			name:  "negative",
+			state: `{"id":"1","schema_version":-1,"config":{"cgroups":{}}}`,
+			err:   ErrStateVersion,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			if err := os.WriteFile(filepath.Join(root, stateFilename), []byte(tc.state), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			state, err := loadState(root)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("expected %v, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.SchemaVersion != StateSchemaVersion {
+				t.Errorf("expected schema version %d, got %d", StateSchemaVersion, state.SchemaVersion)
+			}
+			if state.InitProcessPid != 1024 || state.Config.Rootfs != "/mycontainer/root" {
+				t.Errorf("unexpected state %+v", state.BaseState)
+			}
+		})
+	}
+}
+
+func TestSaveState(t *testing.T) {
+	root := t.TempDir()
+	stateDir := filepath.Join(root, "c")
+	if err := os.Mkdir(stateDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	s := &State{
+		SchemaVersion: StateSchemaVersion,
+		BaseState: BaseState{
+			Config: configs.Config{Cgroups: &cgroups.Cgroup{}},
+		},
+	}
+	if err := NewDirStateStore(root).Save("c", s); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != stateFilename {
+		t.Fatalf("expected only %s in the state directory, got %v", stateFilename, entries)
+	}
+	state, err := loadState(stateDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.SchemaVersion != StateSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", StateSchemaVersion, state.SchemaVersion)
+	}
+}
+
 func marshal(path string, v any) error {
 	f, err := os.Create(path)
 	if err != nil {
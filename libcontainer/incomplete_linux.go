@@ -0,0 +1,101 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/cgroups/manager"
+	"golang.org/x/sys/unix"
+)
+
+// IncompleteContainer is the state directory of a container which has no
+// saved state, such as the one left by [Create] if the process creating the
+// container is killed before the container is started.
+type IncompleteContainer struct {
+	id       string
+	stateDir string
+	lock     *os.File
+}
+
+// LockIncomplete locks the state directory of the container with the given
+// id, which must have no saved state. Unlike the other operations, it does
+// not wait for the lock held by another process (such as the one still
+// creating the container), failing with [ErrLocked] instead. If the
+// container state is saved, [ErrExist] is returned.
+//
+// The lock is released by [IncompleteContainer.Close] or
+// [IncompleteContainer.Destroy].
+func LockIncomplete(root, id string) (*IncompleteContainer, error) {
+	if root == "" {
+		return nil, errors.New("root not set")
+	}
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+	stateDir, err := securejoin.SecureJoin(root, id)
+	if err != nil {
+		return nil, err
+	}
+	lock, err := lockStateDir(stateDir, unix.LOCK_EX, 0)
+	if err != nil {
+		return nil, err
+	}
+	// The container might have been created before the lock was taken.
+	if _, err := os.Stat(filepath.Join(stateDir, stateFilename)); !errors.Is(err, os.ErrNotExist) {
+		lock.Close()
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrExist
+	}
+	return &IncompleteContainer{id: id, stateDir: stateDir, lock: lock}, nil
+}
+
+// ID returns the container ID.
+func (c *IncompleteContainer) ID() string {
+	return c.id
+}
+
+// Destroy removes the container state directory, and releases the lock.
+//
+// As the container configuration is not saved, its cgroup (which might have
+// been created) is not known, so it is to be given by the caller as cg, if
+// any. The cgroup is removed if it exists and has no processes; otherwise,
+// an error is returned, and the state directory is kept.
+func (c *IncompleteContainer) Destroy(cg *cgroups.Cgroup) error {
+	if cg != nil {
+		if cg.Resources == nil {
+			cg.Resources = &cgroups.Resources{}
+		}
+		cm, err := manager.New(cg)
+		if err != nil {
+			return err
+		}
+		if cm.Exists() {
+			pids, err := cm.GetAllPids()
+			// Reading PIDs can race with cgroups removal, so ignore ENOENT and ENODEV.
+			if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.Is(err, unix.ENODEV) {
+				return fmt.Errorf("unable to get cgroup PIDs: %w", err)
+			}
+			if len(pids) != 0 {
+				return fmt.Errorf("container's cgroup is not empty: %d process(es) found", len(pids))
+			}
+			if err := cm.Destroy(); err != nil {
+				return fmt.Errorf("unable to remove container's cgroup: %w", err)
+			}
+		}
+	}
+	// The lock is released by removing the lock file together with the
+	// directory, so that the waiters for it find the container gone.
+	defer c.lock.Close()
+	return os.RemoveAll(c.stateDir)
+}
+
+// Close releases the lock.
+func (c *IncompleteContainer) Close() error {
+	return c.lock.Close()
+}
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -46,8 +45,8 @@ type pid struct {
 type network struct {
 	configs.Network
 
-	// TempVethPeerName is a unique temporary veth peer name that was placed into
-	// the container's namespace.
+	// TempVethPeerName is a unique temporary name of the interface (such as
+	// the veth peer) that was placed into the container's namespace.
 	TempVethPeerName string `json:"temp_veth_peer_name"`
 }
 
@@ -570,31 +569,29 @@ func setupNetwork(config *initConfig) error {
 
 func setupRoute(config *configs.Config) error {
 	for _, config := range config.Routes {
-		_, dst, err := net.ParseCIDR(config.Destination)
+		linkIndex := 0
+		if config.InterfaceName != "" {
+			l, err := netlink.LinkByName(config.InterfaceName)
+			if err != nil {
+				return err
+			}
+			linkIndex = l.Attrs().Index
+		}
+		route, err := netlinkRoute(config, linkIndex)
 		if err != nil {
 			return err
 		}
-		src := net.ParseIP(config.Source)
-		if src == nil {
-			return fmt.Errorf("Invalid source for route: %s", config.Source)
-		}
-		gw := net.ParseIP(config.Gateway)
-		if gw == nil {
-			return fmt.Errorf("Invalid gateway for route: %s", config.Gateway)
+		if err := netlink.RouteAdd(route); err != nil {
+			return fmt.Errorf("unable to add route %s: %w", route, err)
 		}
-		l, err := netlink.LinkByName(config.InterfaceName)
+	}
+	for _, config := range config.Rules {
+		rule, err := netlinkRule(config)
 		if err != nil {
 			return err
 		}
-		route := &netlink.Route{
-			Scope:     netlink.SCOPE_UNIVERSE,
-			Dst:       dst,
-			Src:       src,
-			Gw:        gw,
-			LinkIndex: l.Attrs().Index,
-		}
-		if err := netlink.RouteAdd(route); err != nil {
-			return err
+		if err := netlink.RuleAdd(rule); err != nil {
+			return fmt.Errorf("unable to add rule %s: %w", rule, err)
 		}
 	}
 	return nil
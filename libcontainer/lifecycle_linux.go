@@ -0,0 +1,335 @@
+package libcontainer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// LifecycleEventType is the type of a container lifecycle event.
+type LifecycleEventType string
+
+const (
+	// EventCreated is published once the container is created.
+	EventCreated LifecycleEventType = "created"
+	// EventStarted is published once the user process of the container
+	// is started (or the container is restored from a checkpoint).
+	EventStarted LifecycleEventType = "started"
+	// EventPaused is published once the container is paused.
+	EventPaused LifecycleEventType = "paused"
+	// EventResumed is published once the container is resumed.
+	EventResumed LifecycleEventType = "resumed"
+	// EventExecStarted is published once an additional process is started
+	// in the container.
+	EventExecStarted LifecycleEventType = "exec-started"
+	// EventExecExited is published once an additional process has exited.
+	EventExecExited LifecycleEventType = "exec-exited"
+	// EventExited is published once the container's init has exited.
+	EventExited LifecycleEventType = "exited"
+	// EventDeleted is published once the container is destroyed. This is
+	// always the last event of the container.
+	EventDeleted LifecycleEventType = "deleted"
+)
+
+// LifecycleEvent describes a transition in the container lifecycle.
+type LifecycleEvent struct {
+	Type LifecycleEventType `json:"type"`
+
+	// Timestamp is the time of the transition.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Pid is the process the event is about: container's init for most
+	// events, or an additional process for exec-started and exec-exited.
+	Pid int `json:"pid,omitempty"`
+
+	// ExitStatus is the process exit status for exited and exec-exited
+	// events, if known.
+	ExitStatus *ExitStatus `json:"exit_status,omitempty"`
+}
+
+// eventsPollInterval is how often the events log is checked for updates.
+const eventsPollInterval = 200 * time.Millisecond
+
+// maxEventsLogSize is the size the events log is rotated at, keeping only the
+// previous log, so that the log of a long-running container with many exec
+// sessions does not grow without bound.
+var maxEventsLogSize int64 = 1 << 20
+
+// publishEvent appends a lifecycle event to the container events log. Since
+// this is merely informational, errors are logged rather than returned.
+func (c *Container) publishEvent(t LifecycleEventType, pid int, status *ExitStatus) {
+	e := LifecycleEvent{
+		Type:       t,
+		Timestamp:  time.Now().UTC(),
+		Pid:        pid,
+		ExitStatus: status,
+	}
+	if status != nil {
+		e.Timestamp = status.ExitedAt
+	}
+	if err := appendEvent(filepath.Join(c.stateDir, eventsFilename), &e); err != nil {
+		logrus.WithError(err).Warnf("unable to publish %s event", t)
+	}
+}
+
+// appendEvent appends e to the events log at path, and rotates the log once
+// it reaches maxEventsLogSize.
+func appendEvent(path string, e *LifecycleEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	f, err := lockEventsLog(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// A single write with O_APPEND keeps concurrent writers from
+	// interleaving their lines, even if they do not lock the log.
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < maxEventsLogSize {
+		return nil
+	}
+	// The rotated log is never written to again, so the readers can
+	// switch to the new one once they read it to the end.
+	return os.Rename(path, path+".1")
+}
+
+// lockEventsLog opens the events log at path for appending, creating it if
+// needed, and locks it, to serialize the writers with the log rotation.
+func lockEventsLog(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			err = unix.Flock(int(f.Fd()), unix.LOCK_EX)
+			if !errors.Is(err, unix.EINTR) {
+				break
+			}
+		}
+		if err != nil {
+			f.Close()
+			return nil, os.NewSyscallError("flock", err)
+		}
+		// The log might have been rotated in between the open and the lock.
+		if sameFile(f, path) {
+			return f, nil
+		}
+		f.Close()
+	}
+}
+
+// sameFile tells whether f is the file at path.
+func sameFile(f *os.File, path string) bool {
+	fi1, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	fi2, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(fi1, fi2)
+}
+
+// RecordExecExit publishes an exec-exited event for an additional process
+// of the container, which was reaped by the caller with the wait status ws.
+// Callers that use [Process.Wait] do not need this.
+func (c *Container) RecordExecExit(p *Process, ws unix.WaitStatus) error {
+	pid, err := p.Pid()
+	if err != nil {
+		return err
+	}
+	c.publishEvent(EventExecExited, pid, newExitStatus(ws, time.Now()))
+	return nil
+}
+
+// LifecycleEvents returns a channel of the container lifecycle events,
+// starting from the container creation (or, for a container with many
+// events, from the oldest event kept in the log). The events are read from
+// the log in the container state directory, so the events published by
+// other processes (such as a runc start or runc delete) are also received.
+//
+// The channel is closed after the deleted event, if the container state
+// directory is removed, or when ctx is done.
+func (c *Container) LifecycleEvents(ctx context.Context) (<-chan LifecycleEvent, error) {
+	if _, err := os.Stat(c.stateDir); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	ch := make(chan LifecycleEvent)
+	go func() {
+		defer close(ch)
+		l := &eventsLogReader{path: filepath.Join(c.stateDir, eventsFilename)}
+		defer l.close()
+		ticker := time.NewTicker(eventsPollInterval)
+		defer ticker.Stop()
+		gone := false
+		for {
+			events, err := l.read()
+			if err != nil {
+				logrus.WithError(err).Warn("unable to read container events log")
+				return
+			}
+			for _, e := range events {
+				select {
+				case ch <- e:
+				case <-ctx.Done():
+					return
+				}
+				if e.Type == EventDeleted {
+					return
+				}
+			}
+			if gone {
+				return
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+			// The container is removed without a deleted event (e.g. by an
+			// older runc version). An opened log can still be read, so do
+			// read whatever is left in it before returning.
+			if _, err := os.Stat(c.stateDir); err != nil {
+				gone = true
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// eventsLogReader reads the events log, following its rotation.
+type eventsLogReader struct {
+	path string
+	f    *os.File
+	r    *bufio.Reader
+	line []byte
+	// next is the log to read once f is read to the end, if it is already
+	// opened.
+	next *os.File
+}
+
+// open opens the rotated log, if any, and the current one.
+func (l *eventsLogReader) open() error {
+	rotated := l.path + ".1"
+	for {
+		old, err := os.Open(rotated)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		cur, err := os.Open(l.path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			if old != nil {
+				old.Close()
+			}
+			return err
+		}
+		// Make sure the log is not rotated in between the two opens,
+		// so that cur is the log following old.
+		if old == nil {
+			_, err = os.Stat(rotated)
+			if errors.Is(err, os.ErrNotExist) {
+				l.setFile(cur)
+				return nil
+			}
+		} else if sameFile(old, rotated) {
+			l.setFile(old)
+			l.next = cur
+			return nil
+		}
+		if old != nil {
+			old.Close()
+		}
+		if cur != nil {
+			cur.Close()
+		}
+	}
+}
+
+func (l *eventsLogReader) setFile(f *os.File) {
+	if l.f != nil {
+		l.f.Close()
+	}
+	l.f, l.r, l.line = f, nil, l.line[:0]
+	if f != nil {
+		l.r = bufio.NewReader(f)
+	}
+}
+
+// read returns the events appended to the log since the last read.
+func (l *eventsLogReader) read() ([]LifecycleEvent, error) {
+	if l.f == nil {
+		// The log is created by the first published event.
+		if err := l.open(); err != nil || l.f == nil {
+			return nil, err
+		}
+	}
+	var events []LifecycleEvent
+	for {
+		chunk, err := l.r.ReadBytes('\n')
+		l.line = append(l.line, chunk...)
+		if err == nil {
+			var e LifecycleEvent
+			err = json.Unmarshal(bytes.TrimSpace(l.line), &e)
+			l.line = l.line[:0]
+			if err != nil {
+				logrus.WithError(err).Warn("invalid event in container events log")
+				continue
+			}
+			events = append(events, e)
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return events, err
+		}
+		// At the end of the log. Unless it is rotated, there is an
+		// incomplete line at most, the rest of which is read later.
+		if l.next != nil {
+			next := l.next
+			l.next = nil
+			l.setFile(next)
+			continue
+		}
+		if sameFile(l.f, l.path) {
+			return events, nil
+		}
+		l.next, err = os.Open(l.path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				// Not created yet after the rotation.
+				return events, nil
+			}
+			return events, err
+		}
+		// Read what was written to the rotated log before it was
+		// rotated, before switching to the next one.
+	}
+}
+
+func (l *eventsLogReader) close() {
+	l.setFile(nil)
+	if l.next != nil {
+		l.next.Close()
+	}
+}
@@ -0,0 +1,87 @@
+package libcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLifecycleEventsRotation(t *testing.T) {
+	old := maxEventsLogSize
+	maxEventsLogSize = 4096
+	t.Cleanup(func() { maxEventsLogSize = old })
+
+	c := &Container{stateDir: t.TempDir()}
+	c.publishEvent(EventCreated, 1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	events, err := c.LifecycleEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const execs = 200
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for pid := 2; pid < execs+2; pid++ {
+			c.publishEvent(EventExecStarted, pid, nil)
+			// Rotate the log less often than it is polled, as only
+			// the previous log is kept.
+			if pid%10 == 0 {
+				time.Sleep(eventsPollInterval / 4)
+			}
+		}
+		c.publishEvent(EventDeleted, 1, nil)
+	}()
+
+	var got []LifecycleEvent
+	for e := range events {
+		got = append(got, e)
+	}
+	<-done
+	if len(got) != execs+2 {
+		t.Fatalf("expected %d events, got %d", execs+2, len(got))
+	}
+	for i, e := range got[1 : execs+1] {
+		if e.Type != EventExecStarted || e.Pid != i+2 {
+			t.Fatalf("expected event %d to be %s for pid %d, got %+v", i+1, EventExecStarted, i+2, e)
+		}
+	}
+
+	// The log is bounded.
+	for _, name := range []string{eventsFilename, eventsFilename + ".1"} {
+		fi, err := os.Stat(filepath.Join(c.stateDir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() > maxEventsLogSize+256 {
+			t.Errorf("%s: expected to be rotated, size is %d", name, fi.Size())
+		}
+	}
+}
+
+func TestPublishExitedOnce(t *testing.T) {
+	c := &Container{stateDir: t.TempDir()}
+	c.publishExited(nil)
+	c.publishExited(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := c.LifecycleEvents(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.publishEvent(EventDeleted, 1, nil)
+	var exited int
+	for e := range events {
+		if e.Type == EventExited {
+			exited++
+		}
+	}
+	if exited != 1 {
+		t.Fatalf("expected one exited event, got %d", exited)
+	}
+}
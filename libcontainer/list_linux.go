@@ -0,0 +1,163 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// listConcurrency is the maximum number of containers loaded by List in
+// parallel.
+const listConcurrency = 32
+
+// ListFilter selects the containers returned by [List].
+type ListFilter struct {
+	// Statuses, if not empty, selects the containers with any of the given
+	// statuses.
+	Statuses []Status
+	// Labels selects the containers having all the given configuration
+	// labels with the given values.
+	Labels map[string]string
+	// Annotations selects the containers having all the given annotations
+	// with the given values.
+	Annotations map[string]string
+	// SkipStatus makes List only read the saved container states, without
+	// probing their statuses (which involves checking the init process and
+	// the cgroup of each container), which is much faster. It is ignored if
+	// Statuses is set.
+	SkipStatus bool
+}
+
+// ListEntry describes a container returned by [List].
+type ListEntry struct {
+	// ID is the container ID.
+	ID string
+	// Status is the container status. It is not set if
+	// [ListFilter.SkipStatus] is used.
+	Status Status
+	// State is the container state. If [ListFilter.SkipStatus] is used,
+	// this is the saved state, which may be outdated.
+	State *State
+	// Err is the error loading the container. If it is set, the other
+	// fields except ID are not, and the entry is not filtered.
+	Err error
+}
+
+// List returns the containers under the given root directory (see [Load])
+// selected by the filter, which may be nil to select all the containers,
+// sorted by their IDs. The containers are loaded in parallel.
+//
+// The containers removed while being listed are skipped. The containers
+// which can not be loaded are returned with [ListEntry.Err] set, so an
+// error is only returned if the containers can not be listed at all.
+func List(root string, filter *ListFilter, opts ...Option) ([]ListEntry, error) {
+	if root == "" {
+		return nil, errors.New("root not set")
+	}
+	if filter == nil {
+		filter = &ListFilter{}
+	}
+	store := newOptions(root, opts).store
+	ids, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+
+	entries := make([]*ListEntry, len(ids))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(len(ids), listConcurrency) {
+		wg.Go(func() {
+			for i := range next {
+				e, err := listEntry(root, ids[i], store, filter, opts)
+				if err != nil {
+					if errors.Is(err, ErrNotExist) {
+						// Removed in the meantime.
+						continue
+					}
+					e = &ListEntry{ID: ids[i], Err: err}
+				}
+				entries[i] = e
+			}
+		})
+	}
+	for i := range ids {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	var res []ListEntry
+	for _, e := range entries {
+		if e != nil {
+			res = append(res, *e)
+		}
+	}
+	return res, nil
+}
+
+// listEntry loads the container with the given id, and returns its entry,
+// or nil if it is not selected by the filter.
+func listEntry(root, id string, store StateStore, filter *ListFilter, opts []Option) (*ListEntry, error) {
+	e := &ListEntry{ID: id}
+	if filter.SkipStatus && len(filter.Statuses) == 0 {
+		state, err := store.Load(id)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load container %s: %w", id, err)
+		}
+		e.State = state
+	} else {
+		container, err := Load(root, id, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load container %s: %w", id, err)
+		}
+		if e.Status, err = container.Status(); err != nil {
+			return nil, fmt.Errorf("unable to get container %s status: %w", id, err)
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, e.Status) {
+			return nil, nil
+		}
+		if e.State, err = container.State(); err != nil {
+			return nil, fmt.Errorf("unable to get container %s state: %w", id, err)
+		}
+	}
+	if !filter.matchLabels(e.State.Config.Labels) {
+		return nil, nil
+	}
+	return e, nil
+}
+
+func (f *ListFilter) matchLabels(labels []string) bool {
+	if len(f.Labels) > 0 {
+		m := make(map[string]string, len(labels))
+		for _, l := range labels {
+			key, value, _ := strings.Cut(l, "=")
+			m[key] = value
+		}
+		if !matchAll(m, f.Labels) {
+			return false
+		}
+	}
+	if len(f.Annotations) > 0 {
+		_, annotations := utils.Annotations(labels)
+		if !matchAll(annotations, f.Annotations) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAll reports whether m has all the keys of want with the same values.
+func matchAll(m, want map[string]string) bool {
+	for k, v := range want {
+		if val, ok := m[k]; !ok || val != v {
+			return false
+		}
+	}
+	return true
+}
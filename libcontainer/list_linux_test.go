@@ -0,0 +1,80 @@
+package libcontainer
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestList(t *testing.T) {
+	root := t.TempDir()
+	store := newMemStateStore()
+	for id, labels := range map[string][]string{
+		"c1": {"bundle=/b1", "app=web"},
+		"c2": {"bundle=/b2", "app=db"},
+		"c3": {"bundle=/b1", "app=db", "tier=backend"},
+	} {
+		s := &State{
+			SchemaVersion: StateSchemaVersion,
+			BaseState: BaseState{
+				ID: id,
+				// A PID above the maximum pid_max, so the container is stopped.
+				InitProcessPid: 1<<22 + 1,
+				Config: configs.Config{
+					Labels:  labels,
+					Cgroups: &cgroups.Cgroup{},
+				},
+			},
+		}
+		if err := store.Save(id, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A container with a corrupted state is listed with an error.
+	store.states["c4"] = []byte("{")
+
+	for _, tc := range []struct {
+		name   string
+		filter *ListFilter
+		exp    []string
+	}{
+		{"all", nil, []string{"c1", "c2", "c3", "c4"}},
+		{"skip status", &ListFilter{SkipStatus: true}, []string{"c1", "c2", "c3", "c4"}},
+		{"stopped", &ListFilter{Statuses: []Status{Stopped}}, []string{"c1", "c2", "c3", "c4"}},
+		{"running", &ListFilter{Statuses: []Status{Created, Running}}, []string{"c4"}},
+		{"label", &ListFilter{Labels: map[string]string{"bundle": "/b1"}}, []string{"c1", "c3", "c4"}},
+		{"annotations", &ListFilter{Annotations: map[string]string{"app": "db", "tier": "backend"}, SkipStatus: true}, []string{"c3", "c4"}},
+		{"bundle is not an annotation", &ListFilter{Annotations: map[string]string{"bundle": "/b1"}}, []string{"c4"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			list, err := List(root, tc.filter, WithStateStore(store))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, e := range list {
+				ids = append(ids, e.ID)
+				if e.ID == "c4" {
+					if e.Err == nil {
+						t.Errorf("expected an error for %s", e.ID)
+					}
+					continue
+				}
+				if e.Err != nil {
+					t.Errorf("unexpected error for %s: %v", e.ID, e.Err)
+				}
+				if e.State == nil || e.State.ID != e.ID {
+					t.Errorf("unexpected state of %s: %+v", e.ID, e.State)
+				}
+				if (tc.filter == nil || !tc.filter.SkipStatus) && e.Status != Stopped {
+					t.Errorf("expected %s to be stopped, got %s", e.ID, e.Status)
+				}
+			}
+			if !slices.Equal(ids, tc.exp) {
+				t.Errorf("expected %v, got %v", tc.exp, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// LockTimeout is how long to wait for the container state lock, which is
+// held by another process performing a state-changing operation on the same
+// container (such as runc update, pause, or delete), before failing with
+// [ErrLocked]. If zero, the lock is not waited for; if negative, it is waited
+// for indefinitely.
+var LockTimeout = 30 * time.Second
+
+// lockStateDir acquires an advisory lock (of type how, which is either
+// unix.LOCK_EX or unix.LOCK_SH) for the container with the given state
+// directory, waiting for up to timeout (see [LockTimeout] for the meaning of
+// zero and negative values). The lock is released by closing the returned
+// file.
+//
+// If the container is removed (or is being removed) ErrNotExist is returned.
+func lockStateDir(stateDir string, how int, timeout time.Duration) (*os.File, error) {
+	path := filepath.Join(stateDir, lockFilename)
+	// The lock file is created on the first use.
+	f, err := os.OpenFile(path, os.O_RDONLY|os.O_CREATE|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrNotExist
+		}
+		return nil, fmt.Errorf("unable to open container lock: %w", err)
+	}
+
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	delay := 10 * time.Millisecond
+	for {
+		err := unix.Flock(int(f.Fd()), how|unix.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if !errors.Is(err, unix.EWOULDBLOCK) {
+			f.Close()
+			return nil, fmt.Errorf("unable to lock container: %w", os.NewSyscallError("flock", err))
+		}
+		if timeout == 0 {
+			f.Close()
+			return nil, fmt.Errorf("%w: another operation on the container is in progress", ErrLocked)
+		}
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w: timed out after %s waiting for another operation on the container to finish", ErrLocked, timeout)
+		}
+		time.Sleep(delay)
+		delay = min(delay*2, 200*time.Millisecond)
+	}
+
+	// The lock holder might have destroyed the container.
+	var st unix.Stat_t
+	if err := unix.Fstat(int(f.Fd()), &st); err != nil {
+		f.Close()
+		return nil, &os.PathError{Op: "fstat", Path: path, Err: err}
+	}
+	if st.Nlink == 0 {
+		f.Close()
+		return nil, ErrNotExist
+	}
+	return f, nil
+}
+
+// lockState acquires the exclusive container state lock, which serializes the
+// state-changing operations with the ones performed by other processes. It
+// must be called with c.m held, and released by unlockState.
+func (c *Container) lockState() error {
+	if c.stateLock != nil {
+		return errors.New("container state lock is already held")
+	}
+	lock, err := c.store.Lock(c.id, true)
+	if err != nil {
+		return err
+	}
+	c.stateLock = lock
+	return nil
+}
+
+// unlockState releases the lock acquired by lockState. It does nothing if
+// the lock is not held.
+func (c *Container) unlockState() {
+	if c.stateLock != nil {
+		c.stateLock.Close()
+		c.stateLock = nil
+	}
+}
@@ -0,0 +1,155 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func setLockTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	old := LockTimeout
+	LockTimeout = timeout
+	t.Cleanup(func() { LockTimeout = old })
+}
+
+func TestLockStateDir(t *testing.T) {
+	setLockTimeout(t, 100*time.Millisecond)
+	dir := t.TempDir()
+
+	ex, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if _, err := lockStateDir(dir, unix.LOCK_SH, LockTimeout); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	if d := time.Since(start); d < LockTimeout {
+		t.Fatalf("expected to wait for at least %s, waited for %s", LockTimeout, d)
+	}
+
+	// The lock is released once the holder is done.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ex.Close()
+	}()
+	sh1, err := lockStateDir(dir, unix.LOCK_SH, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sh1.Close()
+
+	// Shared locks do not block each other.
+	setLockTimeout(t, 0)
+	sh2, err := lockStateDir(dir, unix.LOCK_SH, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sh2.Close()
+	if _, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+}
+
+func TestLockStateDirRemoved(t *testing.T) {
+	setLockTimeout(t, time.Second)
+	dir := filepath.Join(t.TempDir(), "container")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Emulate a destroy running concurrently.
+	ex, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = os.RemoveAll(dir)
+		ex.Close()
+	}()
+	if _, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	if _, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+}
+
+func TestLoadLocked(t *testing.T) {
+	setLockTimeout(t, 0)
+	root := t.TempDir()
+	dir := filepath.Join(root, "c1")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	state := &State{BaseState: BaseState{Config: configs.Config{Cgroups: &cgroups.Cgroup{}}}}
+	if err := marshal(filepath.Join(dir, stateFilename), state); err != nil {
+		t.Fatal(err)
+	}
+
+	// A state-changing operation (which might be running the hooks) must
+	// not block Load.
+	ex, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ex.Close()
+	if _, err := Load(root, "c1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLockIncomplete(t *testing.T) {
+	// The lock is not waited for.
+	setLockTimeout(t, time.Minute)
+	root := t.TempDir()
+	dir := filepath.Join(root, "c1")
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	// Emulate a create in progress.
+	ex, err := lockStateDir(dir, unix.LOCK_EX, LockTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockIncomplete(root, "c1"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	ex.Close()
+
+	c, err := LockIncomplete(root, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Destroy(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected %s to be removed, got %v", dir, err)
+	}
+	if _, err := LockIncomplete(root, "c1"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+
+	// The container is created before the lock is taken.
+	if err := os.Mkdir(dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	state := &State{BaseState: BaseState{Config: configs.Config{Cgroups: &cgroups.Cgroup{}}}}
+	if err := marshal(filepath.Join(dir, stateFilename), state); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LockIncomplete(root, "c1"); !errors.Is(err, ErrExist) {
+		t.Fatalf("expected ErrExist, got %v", err)
+	}
+}
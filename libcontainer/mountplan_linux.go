@@ -0,0 +1,131 @@
+package libcontainer
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// MountOp describes a single operation performed when setting up the
+// container root filesystem. See [MountPlan].
+type MountOp struct {
+	// Op is the kind of operation: "mount", "remount", "propagation",
+	// "setattr", "tmpcopyup", "mknod", "symlink", "pivot_root",
+	// "move_root", "chroot", "readonly", or "mask".
+	Op string `json:"op"`
+	// Source is the mount source, device node, or symlink target.
+	Source string `json:"source,omitempty"`
+	// Destination is the path inside the container, unless the operation
+	// is done before jailing the process inside the rootfs, in which case
+	// it is the path on the host.
+	Destination string `json:"destination"`
+	// Type is the filesystem type.
+	Type string `json:"type,omitempty"`
+	// Flags are the mount(2) flags.
+	Flags string `json:"flags,omitempty"`
+	// Data is the filesystem-specific mount data.
+	Data string `json:"data,omitempty"`
+	// Note explains the operation, or the conditions it depends on.
+	Note string `json:"note,omitempty"`
+}
+
+// MountPlan returns the ordered list of operations which are performed by
+// runc init to set up the root filesystem of a container with the given
+// configuration, without performing any of them. The operations are recorded
+// by running the same code as runc init does, so that the plan can not get
+// out of sync with it.
+//
+// Some operations depend on the state of the host or the rootfs at the
+// time the container is created (for example, masked paths that do not
+// exist are skipped); such operations are annotated with a note.
+func MountPlan(config *configs.Config) ([]MountOp, error) {
+	rec := &mountRecorder{}
+	if err := prepareRoot(config, rec); err != nil {
+		return nil, err
+	}
+	c := &mountConfig{
+		label:           config.MountLabel,
+		rootlessCgroups: config.RootlessCgroups,
+		cgroupns:        config.Namespaces.Contains(configs.NEWCGROUP),
+		rec:             rec,
+	}
+	for _, m := range config.Mounts {
+		if err := mountToRootfs(c, mountEntry{Mount: m}); err != nil {
+			return nil, fmt.Errorf("error mounting %q to rootfs at %q: %w", m.Source, m.Destination, err)
+		}
+	}
+	if needsSetupDev(config) {
+		if err := doSetupDev(nil, config, rec); err != nil {
+			return nil, err
+		}
+	}
+	if err := jailRootfs(config, nil, rec); err != nil {
+		return nil, err
+	}
+	// See standardInit.Init.
+	if config.Namespaces.Contains(configs.NEWNS) {
+		if err := finalizeRootfs(config, rec); err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range config.ReadonlyPaths {
+		rec.add(MountOp{Op: "readonly", Source: path, Destination: path, Note: "skipped if the path does not exist"})
+	}
+	for _, path := range config.MaskPaths {
+		rec.add(MountOp{
+			Op:          "mask",
+			Destination: path,
+			Note:        "/dev/null is bind-mounted over a file, and a read-only tmpfs over a directory; skipped if the path does not exist",
+		})
+	}
+	return rec.ops, nil
+}
+
+// mountRecorder records the operations done to set up the rootfs instead of
+// performing them (see MountPlan). A nil *mountRecorder performs them.
+type mountRecorder struct {
+	ops []MountOp
+}
+
+// add records the operation.
+func (r *mountRecorder) add(op MountOp) {
+	r.ops = append(r.ops, op)
+}
+
+// record records a mount(2) call with the given arguments.
+func (r *mountRecorder) record(source, target, fstype string, flags uintptr, data string) {
+	op := MountOp{
+		Op:          "mount",
+		Source:      source,
+		Destination: target,
+		Type:        fstype,
+		Flags:       stringifyMountFlags(flags),
+		Data:        data,
+	}
+	switch {
+	case flags&unix.MS_REMOUNT != 0:
+		op.Op = "remount"
+	case flags&(unix.MS_SHARED|unix.MS_PRIVATE|unix.MS_SLAVE|unix.MS_UNBINDABLE) != 0:
+		op.Op = "propagation"
+	case flags&unix.MS_BIND != 0:
+		op.Type = "bind"
+	}
+	r.add(op)
+}
+
+// note sets the note of the last recorded operation.
+func (r *mountRecorder) note(note string) {
+	r.ops[len(r.ops)-1].Note = note
+}
+
+// mount records the mount(2) call with the given arguments, or performs it
+// if r is nil.
+func (r *mountRecorder) mount(source, target, fstype string, flags uintptr, data string) error {
+	if r == nil {
+		return mount(source, target, fstype, flags, data)
+	}
+	r.record(source, target, fstype, flags, data)
+	return nil
+}
@@ -0,0 +1,231 @@
+package libcontainer
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/moby/sys/mountinfo"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestMountPlan(t *testing.T) {
+	config := &configs.Config{
+		Rootfs:     "/rootfs",
+		Readonlyfs: true,
+		Namespaces: configs.Namespaces{{Type: configs.NEWNS}},
+		Mounts: []*configs.Mount{
+			{Source: "tmpfs", Destination: "/dev", Device: "tmpfs", Flags: unix.MS_NOSUID | unix.MS_RDONLY, Data: "mode=755"},
+			{Source: "tmpfs", Destination: "/etc", Device: "tmpfs", Extensions: configs.EXT_COPYUP},
+			{Source: "/data", Destination: "/data", Device: "bind", Flags: unix.MS_BIND | unix.MS_REC | unix.MS_RDONLY},
+		},
+		MaskPaths: []string{"/proc/kcore"},
+	}
+	ops, err := MountPlan(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type op struct{ op, dest, flags string }
+	expected := []op{
+		{"propagation", "/", "MS_REC|MS_SLAVE"},
+		{"propagation", "/rootfs", "MS_PRIVATE"},
+		{"mount", "/rootfs", "MS_BIND|MS_REC"},
+		// Mounted read-write, remounted read-only in finalizeRootfs.
+		{"mount", "/dev", "MS_NOSUID"},
+		{"mount", "/etc", ""},
+		{"tmpcopyup", "/etc", ""},
+		{"mount", "/data", "MS_RDONLY|MS_BIND|MS_REC"},
+		{"remount", "/data", "MS_RDONLY|MS_REMOUNT|MS_BIND|MS_REC"},
+	}
+	if len(ops) < len(expected) {
+		t.Fatalf("expected at least %d operations, got %+v", len(expected), ops)
+	}
+	for i, e := range expected {
+		if got := (op{ops[i].Op, ops[i].Destination, ops[i].Flags}); got != e {
+			t.Errorf("operation %d: expected %+v, got %+v", i, e, got)
+		}
+	}
+
+	// The last operations are done after pivot_root.
+	tail := ops[len(ops)-4:]
+	expected = []op{
+		{"pivot_root", "/", ""},
+		{"remount", "/dev", "MS_RDONLY|MS_NOSUID|MS_REMOUNT|MS_BIND"},
+		{"remount", "/", "MS_RDONLY|MS_REMOUNT|MS_BIND"},
+		{"mask", "/proc/kcore", ""},
+	}
+	for i, e := range expected {
+		if got := (op{tail[i].Op, tail[i].Destination, tail[i].Flags}); got != e {
+			t.Errorf("operation %d from the end: expected %+v, got %+v", len(tail)-i, e, got)
+		}
+	}
+}
+
+// TestMountPlanMatchesRootfs checks that the operations recorded for each
+// mount match what mountToRootfs actually does.
+func TestMountPlanMatchesRootfs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("requires root")
+	}
+	rootfs := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(rootfs, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(rootfs, "etc", "hostname"), []byte("test\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data := t.TempDir()
+	config := &configs.Config{
+		Rootfs:     rootfs,
+		Namespaces: configs.Namespaces{{Type: configs.NEWNS}},
+		Mounts: []*configs.Mount{
+			{Source: "tmpfs", Destination: "/run", Device: "tmpfs", Flags: unix.MS_NOSUID | unix.MS_RDONLY, Data: "mode=755"},
+			{Source: "tmpfs", Destination: "/etc", Device: "tmpfs", Extensions: configs.EXT_COPYUP},
+			{Source: data, Destination: "/data", Device: "bind", Flags: unix.MS_BIND | unix.MS_REC | unix.MS_RDONLY},
+			{Source: data, Destination: "/shared", Device: "bind", Flags: unix.MS_BIND, PropagationFlags: []int{unix.MS_SHARED}},
+		},
+	}
+
+	errCh := make(chan error, 1)
+	// Use a goroutine to dedicate an OS thread to a new mount namespace.
+	go func() {
+		runtime.LockOSThread()
+		// Deliberately omit runtime.UnlockOSThread here, so that the
+		// thread, which is left in the mount namespace, is terminated.
+		errCh <- checkMountPlan(t, config)
+	}()
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func checkMountPlan(t *testing.T, config *configs.Config) error {
+	if err := unix.Unshare(unix.CLONE_NEWNS); err != nil {
+		return os.NewSyscallError("unshare", err)
+	}
+	if err := unix.Mount("", "/", "", unix.MS_REC|unix.MS_PRIVATE, ""); err != nil {
+		return err
+	}
+	root, err := os.OpenFile(config.Rootfs, unix.O_DIRECTORY|unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer root.Close()
+	mc := &mountConfig{root: root}
+	getMounts := func() ([]*mountinfo.Info, error) {
+		f, err := os.Open("/proc/thread-self/mountinfo")
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return mountinfo.GetMountsFromReader(f, mountinfo.PrefixFilter(config.Rootfs))
+	}
+
+	for _, m := range config.Mounts {
+		rec := &mountRecorder{}
+		if err := mountToRootfs(&mountConfig{rec: rec}, mountEntry{Mount: m}); err != nil {
+			return err
+		}
+		ops := rec.ops
+		before, err := getMounts()
+		if err != nil {
+			return err
+		}
+		if err := mountToRootfs(mc, mountEntry{Mount: m}); err != nil {
+			return err
+		}
+		after, err := getMounts()
+		if err != nil {
+			return err
+		}
+		// The mountinfo entries are in the order the mounts are attached.
+		var mounts []*mountinfo.Info
+		for _, mi := range after {
+			if !slices.ContainsFunc(before, func(b *mountinfo.Info) bool { return b.ID == mi.ID }) {
+				mounts = append(mounts, mi)
+			}
+		}
+		var (
+			mounted []*mountinfo.Info
+			// Whether the mounts are read-only, as planned.
+			readonly []bool
+		)
+		for _, op := range ops {
+			rdonly := strings.Contains(op.Flags, "MS_RDONLY")
+			switch op.Op {
+			case "tmpcopyup":
+				// The tmpfs mounted on the host is moved over the
+				// destination.
+			case "mount":
+				if len(mounts) == 0 {
+					t.Errorf("%s: planned %s is not done", m.Destination, op.Op)
+					continue
+				}
+				mi := mounts[0]
+				mounts = mounts[1:]
+				if dest := filepath.Join(config.Rootfs, op.Destination); mi.Mountpoint != dest {
+					t.Errorf("%s: planned %s at %s, done at %s", m.Destination, op.Op, dest, mi.Mountpoint)
+				}
+				if op.Type != "bind" && mi.FSType != op.Type {
+					t.Errorf("%s: planned %s of %s, done of %s", m.Destination, op.Op, op.Type, mi.FSType)
+				}
+				mounted = append(mounted, mi)
+				// The flags of a bind mount are only applied by a remount.
+				readonly = append(readonly, rdonly && op.Type != "bind")
+			case "remount":
+				if len(mounted) == 0 {
+					t.Errorf("%s: planned %s with nothing mounted", m.Destination, op.Op)
+					continue
+				}
+				readonly[len(readonly)-1] = rdonly
+			case "propagation":
+				if len(mounted) == 0 {
+					t.Errorf("%s: planned %s with nothing mounted", m.Destination, op.Op)
+					continue
+				}
+				mi, err := getMount(getMounts, mounted[len(mounted)-1].ID)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(op.Flags, "MS_SHARED") && !strings.Contains(mi.Optional, "shared:") {
+					t.Errorf("%s: planned shared propagation, done with %q", m.Destination, mi.Optional)
+				}
+			default:
+				t.Errorf("%s: unexpected planned operation %s", m.Destination, op.Op)
+			}
+		}
+		for i, mi := range mounted {
+			mi, err := getMount(getMounts, mi.ID)
+			if err != nil {
+				return err
+			}
+			if ro := slices.Contains(strings.Split(mi.Options, ","), "ro"); ro != readonly[i] {
+				t.Errorf("%s: planned read-only: %v, done with options %s", m.Destination, readonly[i], mi.Options)
+			}
+		}
+		for _, mi := range mounts {
+			t.Errorf("%s: unplanned mount at %s", m.Destination, mi.Mountpoint)
+		}
+	}
+	return nil
+}
+
+func getMount(getMounts func() ([]*mountinfo.Info, error), id int) (*mountinfo.Info, error) {
+	mounts, err := getMounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, mi := range mounts {
+		if mi.ID == id {
+			return mi, nil
+		}
+	}
+	return nil, errors.New("mount is gone")
+}
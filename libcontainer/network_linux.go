@@ -2,11 +2,18 @@ package libcontainer
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/types"
@@ -19,6 +26,9 @@ import (
 )
 
 var strategies = map[string]networkStrategy{
+	"veth":     &veth{},
+	"macvlan":  &macvlan{},
+	"ipvlan":   &ipvlan{},
 	"loopback": &loopback{},
 }
 
@@ -29,6 +39,7 @@ type networkStrategy interface {
 	initialize(*network) error
 	detach(*configs.Network) error
 	attach(*configs.Network) error
+	destroy(*configs.Network) error
 }
 
 // getStrategy returns the specific network strategy for the
@@ -77,6 +88,91 @@ func getNetworkInterfaceStats(interfaceName string) (*types.NetworkInterface, er
 	return out, nil
 }
 
+// netnsInterfaceStats returns the network statistics for all the network
+// interfaces in the container's network namespace, or nil if the container
+// does not have a network namespace of its own, is not running, or the
+// namespace can not be entered (for example, by rootless runc).
+func (c *Container) netnsInterfaceStats() ([]*types.NetworkInterface, error) {
+	if !c.config.Namespaces.Contains(configs.NEWNET) || c.initProcess == nil {
+		return nil, nil
+	}
+	ns, err := netns.GetFromPath(fmt.Sprintf("/proc/%d/ns/net", c.initProcess.pid()))
+	if err == nil {
+		defer ns.Close()
+	}
+	// Make sure the namespace is the one of the container init, rather
+	// than of some other process reusing its pid.
+	if !c.hasInit() {
+		return nil, nil
+	}
+	var ifaces []*types.NetworkInterface
+	if err == nil {
+		ifaces, err = getNetnsInterfaceStats(ns)
+	}
+	if errors.Is(err, unix.EPERM) || errors.Is(err, unix.EACCES) {
+		logrus.Debugf("unable to get network stats from the container network namespace: %v", err)
+		return nil, nil
+	}
+	return ifaces, err
+}
+
+// newHandleAt is like netlink.NewHandleAt, except that the setns(2) error is
+// returned as is, so that the caller can check for EPERM.
+func newHandleAt(ns netns.NsHandle) (*netlink.Handle, error) {
+	type result struct {
+		h   *netlink.Handle
+		err error
+	}
+	resCh := make(chan result, 1)
+	// Use a goroutine to dedicate an OS thread to switch to ns, and create
+	// the netlink socket in it.
+	go func() {
+		runtime.LockOSThread()
+		if err := netns.Set(ns); err != nil {
+			resCh <- result{err: fmt.Errorf("unable to join network namespace: %w", err)}
+			return
+		}
+		h, err := netlink.NewHandle()
+		resCh <- result{h: h, err: err}
+		// Deliberately omit runtime.UnlockOSThread here, so that the
+		// thread, which is left in ns, is terminated.
+	}()
+	res := <-resCh
+	return res.h, res.err
+}
+
+// getNetnsInterfaceStats returns the network statistics for all the network
+// interfaces in the network namespace ns, as seen from inside it.
+func getNetnsInterfaceStats(ns netns.NsHandle) ([]*types.NetworkInterface, error) {
+	nhNs, err := newHandleAt(ns)
+	if err != nil {
+		return nil, err
+	}
+	defer nhNs.Close()
+	links, err := nhNs.LinkList()
+	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
+	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
+		return nil, err
+	}
+	out := make([]*types.NetworkInterface, 0, len(links))
+	for _, link := range links {
+		attrs := link.Attrs()
+		iface := &types.NetworkInterface{Name: attrs.Name}
+		if s := attrs.Statistics; s != nil {
+			iface.RxBytes = s.RxBytes
+			iface.RxPackets = s.RxPackets
+			iface.RxErrors = s.RxErrors
+			iface.RxDropped = s.RxDropped
+			iface.TxBytes = s.TxBytes
+			iface.TxPackets = s.TxPackets
+			iface.TxErrors = s.TxErrors
+			iface.TxDropped = s.TxDropped
+		}
+		out = append(out, iface)
+	}
+	return out, nil
+}
+
 // Reads the specified statistics available under /sys/class/net/<EthInterface>/statistics
 func readSysfsNetworkStats(ethInterface, statsFile string) (uint64, error) {
 	data, err := os.ReadFile(filepath.Join("/sys/class/net", ethInterface, "statistics", statsFile))
@@ -105,17 +201,554 @@ func (l *loopback) detach(n *configs.Network) (err error) {
 	return nil
 }
 
+func (l *loopback) destroy(n *configs.Network) error {
+	return nil
+}
+
+// veth is a network strategy that creates a veth pair, one end of which is
+// attached to a bridge on the host, and the other is placed inside the
+// container's network namespace.
+type veth struct{}
+
+func (v *veth) create(n *network, nspid int) (err error) {
+	if err := checkBridge(n.Bridge); err != nil {
+		return err
+	}
+	n.TempVethPeerName, err = tempLinkName("veth")
+	if err != nil {
+		return err
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = n.HostInterfaceName
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen > 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	link := &netlink.Veth{
+		LinkAttrs:  attrs,
+		PeerName:   n.TempVethPeerName,
+		PeerTxQLen: -1, // Same as the host end.
+		// Create the peer in the container's network namespace right away.
+		PeerNamespace: netlink.NsPid(nspid),
+	}
+	if n.MacAddress != "" {
+		if link.PeerHardwareAddr, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	logrus.Debugf("creating veth pair %s (host) and %s (container)", n.HostInterfaceName, n.TempVethPeerName)
+	if err := netlink.LinkAdd(link); err != nil {
+		return fmt.Errorf("unable to create veth pair %s: %w", n.HostInterfaceName, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = netlink.LinkDel(link)
+		}
+	}()
+	return v.attach(&n.Network)
+}
+
+func (v *veth) initialize(config *network) error {
+	return setupLink(config)
+}
+
+// attach attaches the host end of the veth pair to the bridge.
+func (v *veth) attach(n *configs.Network) error {
+	br, err := netlink.LinkByName(n.Bridge)
+	if err != nil {
+		return fmt.Errorf("unable to find bridge %s: %w", n.Bridge, err)
+	}
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	if err := netlink.LinkSetMaster(host, br); err != nil {
+		return fmt.Errorf("unable to attach %s to bridge %s: %w", n.HostInterfaceName, n.Bridge, err)
+	}
+	if n.HairpinMode {
+		if err := netlink.LinkSetHairpin(host, true); err != nil {
+			return fmt.Errorf("unable to set hairpin mode on %s: %w", n.HostInterfaceName, err)
+		}
+	}
+	return netlink.LinkSetUp(host)
+}
+
+// detach detaches the host end of the veth pair from the bridge.
+func (v *veth) detach(n *configs.Network) error {
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		return err
+	}
+	return netlink.LinkSetNoMaster(host)
+}
+
+// destroy removes the veth pair, unless it is already gone together with the
+// container's network namespace.
+func (v *veth) destroy(n *configs.Network) error {
+	host, err := netlink.LinkByName(n.HostInterfaceName)
+	if err != nil {
+		if _, ok := err.(netlink.LinkNotFoundError); ok { //nolint:errorlint // not wrapped.
+			return nil
+		}
+		return err
+	}
+	if _, ok := host.(*netlink.Veth); !ok {
+		return nil
+	}
+	if err := netlink.LinkDel(host); err != nil {
+		return fmt.Errorf("unable to remove veth pair %s: %w", n.HostInterfaceName, err)
+	}
+	return nil
+}
+
+// checkBridge checks that a bridge with the given name exists.
+func checkBridge(name string) error {
+	link, err := netlink.LinkByName(name)
+	if err != nil {
+		return fmt.Errorf("unable to find bridge %s: %w", name, err)
+	}
+	if _, ok := link.(*netlink.Bridge); !ok {
+		return fmt.Errorf("%s is not a bridge (but %s)", name, link.Type())
+	}
+	return nil
+}
+
+// macvlan is a network strategy that creates a macvlan interface on a parent
+// interface of the host, inside the container's network namespace.
+type macvlan struct{}
+
+var macvlanModes = map[string]netlink.MacvlanMode{
+	"":        netlink.MACVLAN_MODE_BRIDGE,
+	"bridge":  netlink.MACVLAN_MODE_BRIDGE,
+	"private": netlink.MACVLAN_MODE_PRIVATE,
+	"vepa":    netlink.MACVLAN_MODE_VEPA,
+}
+
+func (m *macvlan) create(n *network, nspid int) error {
+	mode, ok := macvlanModes[n.Mode]
+	if !ok {
+		return fmt.Errorf("invalid macvlan mode %q", n.Mode)
+	}
+	attrs, err := childLinkAttrs(n, "mvl", nspid)
+	if err != nil {
+		return err
+	}
+	if n.MacAddress != "" {
+		if attrs.HardwareAddr, err = net.ParseMAC(n.MacAddress); err != nil {
+			return err
+		}
+	}
+	logrus.Debugf("creating macvlan %s on %s", n.TempVethPeerName, n.Parent)
+	if err := netlink.LinkAdd(&netlink.Macvlan{LinkAttrs: attrs, Mode: mode}); err != nil {
+		return fmt.Errorf("unable to create macvlan on %s: %w", n.Parent, err)
+	}
+	return nil
+}
+
+func (m *macvlan) initialize(config *network) error {
+	return setupLink(config)
+}
+
+func (m *macvlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (m *macvlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// destroy is a no-op, as the interface is removed together with the
+// container's network namespace.
+func (m *macvlan) destroy(n *configs.Network) error {
+	return nil
+}
+
+// ipvlan is a network strategy that creates an ipvlan interface on a parent
+// interface of the host, inside the container's network namespace.
+type ipvlan struct{}
+
+var ipvlanModes = map[string]netlink.IPVlanMode{
+	"":   netlink.IPVLAN_MODE_L2,
+	"l2": netlink.IPVLAN_MODE_L2,
+	"l3": netlink.IPVLAN_MODE_L3,
+}
+
+func (i *ipvlan) create(n *network, nspid int) error {
+	mode, ok := ipvlanModes[n.Mode]
+	if !ok {
+		return fmt.Errorf("invalid ipvlan mode %q", n.Mode)
+	}
+	attrs, err := childLinkAttrs(n, "ipvl", nspid)
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("creating ipvlan %s on %s", n.TempVethPeerName, n.Parent)
+	if err := netlink.LinkAdd(&netlink.IPVlan{LinkAttrs: attrs, Mode: mode}); err != nil {
+		return fmt.Errorf("unable to create ipvlan on %s: %w", n.Parent, err)
+	}
+	return nil
+}
+
+func (i *ipvlan) initialize(config *network) error {
+	return setupLink(config)
+}
+
+func (i *ipvlan) attach(n *configs.Network) error {
+	return nil
+}
+
+func (i *ipvlan) detach(n *configs.Network) error {
+	return nil
+}
+
+// destroy is a no-op, as the interface is removed together with the
+// container's network namespace.
+func (i *ipvlan) destroy(n *configs.Network) error {
+	return nil
+}
+
+// childLinkAttrs returns the attributes of a new interface on the parent
+// interface n.Parent, created in the network namespace of the process nspid
+// under a temporary name, which is saved in n.TempVethPeerName.
+func childLinkAttrs(n *network, prefix string, nspid int) (netlink.LinkAttrs, error) {
+	attrs := netlink.NewLinkAttrs()
+	parent, err := netlink.LinkByName(n.Parent)
+	if err != nil {
+		return attrs, fmt.Errorf("unable to find parent interface %s: %w", n.Parent, err)
+	}
+	n.TempVethPeerName, err = tempLinkName(prefix)
+	if err != nil {
+		return attrs, err
+	}
+	attrs.Name = n.TempVethPeerName
+	attrs.ParentIndex = parent.Attrs().Index
+	attrs.MTU = n.Mtu
+	if n.TxQueueLen > 0 {
+		attrs.TxQLen = n.TxQueueLen
+	}
+	attrs.Namespace = netlink.NsPid(nspid)
+	return attrs, nil
+}
+
+// setupLink configures the interface created under a temporary name in the
+// container's network namespace: renames it to config.Name, adds the
+// addresses, sets it up, and adds the default routes via the gateways. It
+// runs inside the container's network namespace.
+func setupLink(config *network) error {
+	peer := config.TempVethPeerName
+	if peer == "" {
+		return errors.New("temporary interface name is not specified")
+	}
+	child, err := netlink.LinkByName(peer)
+	if err != nil {
+		return fmt.Errorf("interface %s not found: %w", peer, err)
+	}
+	// The link is down, as just created, so it can be renamed.
+	if err := netlink.LinkSetName(child, config.Name); err != nil {
+		return fmt.Errorf("unable to rename interface %s to %s: %w", peer, config.Name, err)
+	}
+	for _, address := range []string{config.Address, config.IPv6Address} {
+		if address == "" {
+			continue
+		}
+		addr, err := netlink.ParseAddr(address)
+		if err != nil {
+			return err
+		}
+		if err := netlink.AddrAdd(child, addr); err != nil {
+			return fmt.Errorf("unable to add address %s to %s: %w", address, config.Name, err)
+		}
+	}
+	if err := netlink.LinkSetUp(child); err != nil {
+		return fmt.Errorf("unable to set %s up: %w", config.Name, err)
+	}
+	for _, gateway := range []string{config.Gateway, config.IPv6Gateway} {
+		if gateway == "" {
+			continue
+		}
+		gw := net.ParseIP(gateway)
+		if gw == nil {
+			return fmt.Errorf("invalid gateway %q", gateway)
+		}
+		if err := netlink.RouteAdd(&netlink.Route{
+			Scope:     netlink.SCOPE_UNIVERSE,
+			LinkIndex: child.Attrs().Index,
+			Gw:        gw,
+		}); err != nil {
+			return fmt.Errorf("unable to add default route via %s: %w", gateway, err)
+		}
+	}
+	return nil
+}
+
+// tempLinkName returns a random interface name with the given prefix, which
+// is unique enough not to clash with the names of the other interfaces in the
+// container's network namespace until it is renamed.
+func tempLinkName(prefix string) (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(b), nil
+}
+
+// destroyNetworks removes the network interfaces of the container on the
+// host.
+func destroyNetworks(config *configs.Config) error {
+	for _, n := range config.Networks {
+		strategy, err := getStrategy(n.Type)
+		if err != nil {
+			return err
+		}
+		if err := strategy.destroy(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NetDeviceState identifies a network device moved to the container's
+// network namespace (see [configs.Config.NetDevices]).
+type NetDeviceState struct {
+	// Index is the interface index of the device in the container's network
+	// namespace, which the kernel keeps, if possible, when the device is
+	// moved back to the initial network namespace.
+	Index int `json:"index"`
+	// HardwareAddr is the hardware address of the device.
+	HardwareAddr string `json:"hardware_addr,omitempty"`
+	// Virtual is set for a virtual device (such as a veth), which the
+	// kernel removes, rather than moves back, once the container's network
+	// namespace is destroyed.
+	Virtual bool `json:"virtual,omitempty"`
+}
+
+// netDeviceRestoreTimeout is how long to wait for a network device to be
+// moved back to the initial network namespace by the kernel, once the
+// container's network namespace is destroyed.
+const netDeviceRestoreTimeout = 5 * time.Second
+
+// routeScopes are the route scopes by their names in [configs.Route].
+var routeScopes = map[string]netlink.Scope{
+	"universe": netlink.SCOPE_UNIVERSE,
+	"site":     netlink.SCOPE_SITE,
+	"link":     netlink.SCOPE_LINK,
+	"host":     netlink.SCOPE_HOST,
+}
+
+// netlinkRoute converts the route via the interface with the given index,
+// which is 0 to let the kernel pick the interface from the gateway.
+// The destination defaults to the default route of the gateway IP family.
+func netlinkRoute(r *configs.Route, linkIndex int) (*netlink.Route, error) {
+	route := &netlink.Route{
+		Scope:     netlink.SCOPE_UNIVERSE,
+		LinkIndex: linkIndex,
+		Priority:  r.Metric,
+		Table:     r.Table,
+	}
+	if r.Gateway != "" {
+		if route.Gw = net.ParseIP(r.Gateway); route.Gw == nil {
+			return nil, fmt.Errorf("invalid route gateway %q", r.Gateway)
+		}
+	}
+	if r.Source != "" {
+		if route.Src = net.ParseIP(r.Source); route.Src == nil {
+			return nil, fmt.Errorf("invalid route source %q", r.Source)
+		}
+	}
+	if r.Destination != "" {
+		_, dst, err := net.ParseCIDR(r.Destination)
+		if err != nil {
+			return nil, fmt.Errorf("invalid route destination: %w", err)
+		}
+		route.Dst = dst
+	} else if route.Gw == nil {
+		return nil, errors.New("route destination or gateway must be set")
+	}
+	if route.Gw == nil {
+		// A direct route to the destination network.
+		if linkIndex == 0 {
+			return nil, errors.New("route interface must be set for a route without a gateway")
+		}
+		route.Scope = netlink.SCOPE_LINK
+	}
+	if r.Scope != "" {
+		scope, ok := routeScopes[r.Scope]
+		if !ok {
+			return nil, fmt.Errorf("invalid route scope %q", r.Scope)
+		}
+		route.Scope = scope
+	}
+	return route, nil
+}
+
+// netlinkRule converts the routing policy rule.
+func netlinkRule(r *configs.Rule) (*netlink.Rule, error) {
+	rule := netlink.NewRule()
+	rule.Table = r.Table
+	if r.Priority != 0 {
+		rule.Priority = r.Priority
+	}
+	if r.IPv6 {
+		rule.Family = unix.AF_INET6
+	}
+	for _, n := range []struct {
+		value string
+		out   **net.IPNet
+	}{{r.Source, &rule.Src}, {r.Destination, &rule.Dst}} {
+		if n.value == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(n.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule address: %w", err)
+		}
+		*n.out = ipNet
+	}
+	if rule.Src != nil || rule.Dst != nil {
+		// The family is taken from the addresses.
+		rule.Family = 0
+	}
+	rule.IifName = r.InputInterface
+	rule.OifName = r.OutputInterface
+	rule.Mark = r.Mark
+	if r.Mask != 0 {
+		rule.Mask = &r.Mask
+	}
+	return rule, nil
+}
+
+// restoreNetDevices moves the network devices moved to the container's
+// network namespace back to the current one, under their original names.
+// The devices which can not be restored, such as the ones unplugged in the
+// meantime, are skipped with a warning, as this is done when the container
+// is destroyed.
+func restoreNetDevices(config *configs.Config, devices map[string]NetDeviceState) {
+	nsPath := config.Namespaces.PathOf(configs.NEWNET)
+	for _, name := range slices.Sorted(maps.Keys(devices)) {
+		newName := name
+		if device := config.NetDevices[name]; device != nil && device.Name != "" {
+			newName = device.Name
+		}
+		if err := restoreNetDevice(name, newName, nsPath, devices[name]); err != nil {
+			logrus.Warnf("unable to restore network device %s: %v", name, err)
+		}
+	}
+}
+
+// restoreNetDevice restores the network device named name on the host and
+// newName in the container's network namespace, which is nsPath if it is
+// not created for the container.
+func restoreNetDevice(name, newName, nsPath string, device NetDeviceState) error {
+	// If the network namespace still exists, the device is still in it.
+	if nsPath != "" {
+		ns, err := netns.GetFromPath(nsPath)
+		if err == nil {
+			defer ns.Close()
+			moved, err := moveNetDeviceBack(ns, name, newName, device)
+			if err != nil || moved {
+				return err
+			}
+		}
+	}
+	// Otherwise, the kernel moves it to the initial network namespace once
+	// the container's one is destroyed (unless it is a virtual device), which
+	// is done asynchronously, keeping its name from the container if
+	// possible, and its index if it is not used in the meantime.
+	if device.Virtual {
+		return nil
+	}
+	wait := nsPath == ""
+	deadline := time.Now().Add(netDeviceRestoreTimeout)
+	for {
+		link, err := findNetDevice(newName, device)
+		if err != nil {
+			return err
+		}
+		if link != nil {
+			if link.Attrs().Name == name {
+				// Nothing to restore.
+				return nil
+			}
+			logrus.Debugf("renaming network device %s back to %s", link.Attrs().Name, name)
+			if err := netlink.LinkSetName(link, name); err != nil {
+				return fmt.Errorf("unable to rename %s to %s: %w", link.Attrs().Name, name, err)
+			}
+			return nil
+		}
+		if !wait || time.Now().After(deadline) {
+			// Most probably, unplugged in the meantime.
+			logrus.Debugf("network device %s not found on the host", name)
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// findNetDevice returns the network device moved back from the container's
+// network namespace, named newName in it, or nil if it is not found.
+func findNetDevice(newName string, device NetDeviceState) (netlink.Link, error) {
+	links, err := netlink.LinkList()
+	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
+	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
+		return nil, err
+	}
+	for _, link := range links {
+		attrs := link.Attrs()
+		if attrs.HardwareAddr.String() == device.HardwareAddr && (attrs.Index == device.Index || attrs.Name == newName) {
+			return link, nil
+		}
+	}
+	return nil, nil
+}
+
+// moveNetDeviceBack moves the network device from the network namespace ns
+// to the current one, renaming it from newName back to name. It reports
+// whether the device is found in ns.
+func moveNetDeviceBack(ns netns.NsHandle, name, newName string, device NetDeviceState) (bool, error) {
+	nhNs, err := netlink.NewHandleAt(ns)
+	if err != nil {
+		return false, err
+	}
+	defer nhNs.Close()
+	link, err := nhNs.LinkByName(newName)
+	if err != nil || link.Attrs().HardwareAddr.String() != device.HardwareAddr {
+		return false, nil
+	}
+	host, err := netns.Get()
+	if err != nil {
+		return true, err
+	}
+	defer host.Close()
+
+	logrus.Debugf("moving network device %s back to the host as %s", newName, name)
+	if err := nhNs.LinkSetDown(link); err != nil {
+		return true, fmt.Errorf("unable to set %s down: %w", newName, err)
+	}
+	if newName != name {
+		if err := nhNs.LinkSetName(link, name); err != nil {
+			return true, fmt.Errorf("unable to rename %s to %s: %w", newName, name, err)
+		}
+	}
+	if err := nhNs.LinkSetNsFd(link, int(host)); err != nil {
+		return true, fmt.Errorf("unable to move %s back to the host: %w", name, err)
+	}
+	return true, nil
+}
+
 // devChangeNetNamespace allows to move a device given by name to a network namespace given by nsPath
 // and optionally change the device name.
 // The device name will be kept the same if device.Name is the zero value.
 // This function ensures that the move and rename operations occur atomically.
-// It preserves existing interface attributes, including global IP addresses.
-func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) error {
+// It preserves existing interface attributes, including global IP addresses,
+// and then applies the MTU, addresses, and routes from the device configuration.
+// It returns the moved device identity, to restore it with restoreNetDevice,
+// which is also returned along with an error once the device is moved.
+func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) (*NetDeviceState, error) {
 	logrus.Debugf("attaching network device %s with attrs %+v to network namespace %s", name, device, nsPath)
 	link, err := netlink.LinkByName(name)
 	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
 	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
-		return fmt.Errorf("link not found for interface %s on runtime namespace: %w", name, err)
+		return nil, fmt.Errorf("link not found for interface %s on runtime namespace: %w", name, err)
 	}
 
 	// Set the interface link state to DOWN before modifying attributes like namespace or name.
@@ -123,14 +756,14 @@ func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) e
 	// particularly if other host components depend on this specific interface or its properties.
 	err = netlink.LinkSetDown(link)
 	if err != nil {
-		return fmt.Errorf("fail to set link down: %w", err)
+		return nil, fmt.Errorf("fail to set link down: %w", err)
 	}
 
 	// Get the existing IP addresses on the interface.
 	addresses, err := netlink.AddrList(link, netlink.FAMILY_ALL)
 	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
 	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
-		return fmt.Errorf("fail to get ip addresses: %w", err)
+		return nil, fmt.Errorf("fail to get ip addresses: %w", err)
 	}
 
 	// Do interface rename and namespace change in the same operation to avoid
@@ -144,7 +777,7 @@ func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) e
 	// Get a netlink socket in current namespace
 	nlSock, err := nl.GetNetlinkSocketAt(netns.None(), netns.None(), unix.NETLINK_ROUTE)
 	if err != nil {
-		return fmt.Errorf("could not get network namespace handle: %w", err)
+		return nil, fmt.Errorf("could not get network namespace handle: %w", err)
 	}
 	defer nlSock.Close()
 
@@ -168,7 +801,7 @@ func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) e
 	// Get the new network namespace.
 	ns, err := netns.GetFromPath(nsPath)
 	if err != nil {
-		return fmt.Errorf("could not get network namespace from path %s for network device %s : %w", nsPath, name, err)
+		return nil, fmt.Errorf("could not get network namespace from path %s for network device %s : %w", nsPath, name, err)
 	}
 	defer ns.Close()
 
@@ -179,22 +812,31 @@ func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) e
 	_, err = req.Execute(unix.NETLINK_ROUTE, 0)
 	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
 	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
-		return fmt.Errorf("fail to move network device %s to network namespace %s: %w", name, nsPath, err)
+		return nil, fmt.Errorf("fail to move network device %s to network namespace %s: %w", name, nsPath, err)
+	}
+	// From now on, the device state is returned even on errors, so that the
+	// device can be restored.
+	state := &NetDeviceState{
+		Index:        link.Attrs().Index,
+		HardwareAddr: link.Attrs().HardwareAddr.String(),
+		Virtual:      link.Type() != "device",
 	}
 
 	// To avoid us the husle with goroutines when joining a netns,
 	// we let the library create the socket in the namespace for us.
 	nhNs, err := netlink.NewHandleAt(ns)
 	if err != nil {
-		return err
+		return state, err
 	}
 	defer nhNs.Close()
 
 	nsLink, err := nhNs.LinkByName(newName)
 	// recover same behavior on vishvananda/netlink@1.2.1 and do not fail when the kernel returns NLM_F_DUMP_INTR.
 	if err != nil && !errors.Is(err, netlink.ErrDumpInterrupted) {
-		return fmt.Errorf("link not found for interface %s on namespace %s : %w", newName, nsPath, err)
+		return state, fmt.Errorf("link not found for interface %s on namespace %s : %w", newName, nsPath, err)
 	}
+	// The kernel changes the index if it is already used in the namespace.
+	state.Index = nsLink.Attrs().Index
 
 	// Re-add the original IP addresses to the interface in the new namespace.
 	// The kernel removes IP addresses when an interface is moved between network namespaces.
@@ -219,14 +861,40 @@ func devChangeNetNamespace(name, nsPath string, device configs.LinuxNetDevice) e
 		// to avoid issues when the interface is renamed.
 		err = nhNs.AddrAdd(nsLink, &netlink.Addr{IPNet: address.IPNet})
 		if err != nil {
-			return fmt.Errorf("fail to set up address %s on namespace %s: %w", address.String(), nsPath, err)
+			return state, fmt.Errorf("fail to set up address %s on namespace %s: %w", address.String(), nsPath, err)
+		}
+	}
+
+	if device.MTU > 0 {
+		if err := nhNs.LinkSetMTU(nsLink, device.MTU); err != nil {
+			return state, fmt.Errorf("fail to set mtu %d of interface %s on namespace %s: %w", device.MTU, newName, nsPath, err)
+		}
+	}
+	for _, address := range device.Addresses {
+		addr, err := netlink.ParseAddr(address)
+		if err != nil {
+			return state, err
+		}
+		if err := nhNs.AddrAdd(nsLink, addr); err != nil {
+			return state, fmt.Errorf("fail to add address %s to interface %s on namespace %s: %w", address, newName, nsPath, err)
 		}
 	}
 
 	err = nhNs.LinkSetUp(nsLink)
 	if err != nil {
-		return fmt.Errorf("fail to set up interface %s on namespace %s: %w", nsLink.Attrs().Name, nsPath, err)
+		return state, fmt.Errorf("fail to set up interface %s on namespace %s: %w", nsLink.Attrs().Name, nsPath, err)
 	}
 
-	return nil
+	// The routes via a gateway can only be added once the interface is up.
+	for _, r := range device.Routes {
+		route, err := netlinkRoute(r, nsLink.Attrs().Index)
+		if err != nil {
+			return state, err
+		}
+		if err := nhNs.RouteAdd(route); err != nil {
+			return state, fmt.Errorf("fail to add route %s on namespace %s: %w", route, nsPath, err)
+		}
+	}
+
+	return state, nil
 }
@@ -0,0 +1,578 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/types"
+)
+
+// newTestNetns moves the calling goroutine into a new network namespace,
+// which is thrown away when the test ends. The goroutine stays locked to its
+// OS thread, so that the thread is terminated rather than reused when the
+// goroutine exits.
+func newTestNetns(t *testing.T) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("creating network namespaces requires root")
+	}
+	runtime.LockOSThread()
+	origin, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ns, err := netns.New()
+	if err != nil {
+		origin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = netns.Set(origin)
+		origin.Close()
+		ns.Close()
+	})
+}
+
+// addTestLink creates the link in the current network namespace, and sets it
+// up. The test is skipped if the link type is not supported.
+func addTestLink(t *testing.T, link netlink.Link) {
+	t.Helper()
+	if err := netlink.LinkAdd(link); err != nil {
+		t.Skipf("unable to create a %s interface: %v", link.Type(), err)
+	}
+	if err := netlink.LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// addDummyParent creates a dummy interface to serve as the parent interface.
+func addDummyParent(t *testing.T, name string) {
+	t.Helper()
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = name
+	addTestLink(t, &netlink.Dummy{LinkAttrs: attrs})
+}
+
+// startNetnsProcess starts a process in a new network namespace, standing
+// for the container init.
+func startNetnsProcess(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("sleep", "1h")
+	cmd.SysProcAttr = &unix.SysProcAttr{Cloneflags: unix.CLONE_NEWNET}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd.Process.Pid
+}
+
+// inNetns runs fn in the network namespace of the process pid.
+func inNetns(t *testing.T, pid int, fn func() error) error {
+	t.Helper()
+	origin, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer origin.Close()
+	ns, err := netns.GetFromPid(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ns.Close()
+	if err := netns.Set(ns); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := netns.Set(origin); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	return fn()
+}
+
+func TestVlanNetworks(t *testing.T) {
+	testCases := []struct {
+		name    string
+		network configs.Network
+		check   func(t *testing.T, link netlink.Link)
+	}{
+		{
+			name: "macvlan",
+			network: configs.Network{
+				Type:       "macvlan",
+				MacAddress: "02:42:ac:11:00:02",
+			},
+			check: func(t *testing.T, link netlink.Link) {
+				m, ok := link.(*netlink.Macvlan)
+				if !ok {
+					t.Fatalf("expected macvlan, got %s", link.Type())
+				}
+				if m.Mode != netlink.MACVLAN_MODE_BRIDGE {
+					t.Errorf("expected bridge mode, got %d", m.Mode)
+				}
+				if mac := m.HardwareAddr.String(); mac != "02:42:ac:11:00:02" {
+					t.Errorf("expected mac address 02:42:ac:11:00:02, got %s", mac)
+				}
+			},
+		},
+		{
+			name:    "macvlan private",
+			network: configs.Network{Type: "macvlan", Mode: "private"},
+			check: func(t *testing.T, link netlink.Link) {
+				if m, ok := link.(*netlink.Macvlan); !ok || m.Mode != netlink.MACVLAN_MODE_PRIVATE {
+					t.Errorf("expected private macvlan, got %+v", link)
+				}
+			},
+		},
+		{
+			name:    "macvlan vepa",
+			network: configs.Network{Type: "macvlan", Mode: "vepa"},
+			check: func(t *testing.T, link netlink.Link) {
+				if m, ok := link.(*netlink.Macvlan); !ok || m.Mode != netlink.MACVLAN_MODE_VEPA {
+					t.Errorf("expected vepa macvlan, got %+v", link)
+				}
+			},
+		},
+		{
+			name:    "ipvlan",
+			network: configs.Network{Type: "ipvlan"},
+			check: func(t *testing.T, link netlink.Link) {
+				if i, ok := link.(*netlink.IPVlan); !ok || i.Mode != netlink.IPVLAN_MODE_L2 {
+					t.Errorf("expected l2 ipvlan, got %+v", link)
+				}
+			},
+		},
+		{
+			name:    "ipvlan l3",
+			network: configs.Network{Type: "ipvlan", Mode: "l3"},
+			check: func(t *testing.T, link netlink.Link) {
+				if i, ok := link.(*netlink.IPVlan); !ok || i.Mode != netlink.IPVLAN_MODE_L3 {
+					t.Errorf("expected l3 ipvlan, got %+v", link)
+				}
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			newTestNetns(t)
+			addDummyParent(t, "parent0")
+			pid := startNetnsProcess(t)
+
+			n := &network{Network: tc.network}
+			n.Name = "eth0"
+			n.Parent = "parent0"
+			n.Address = "10.0.0.2/24"
+			n.Gateway = "10.0.0.1"
+			n.Mtu = 1400
+			strategy, err := getStrategy(n.Type)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := strategy.create(n, pid); err != nil {
+				if errors.Is(err, unix.EOPNOTSUPP) {
+					t.Skipf("%s is not supported: %v", n.Type, err)
+				}
+				t.Fatal(err)
+			}
+			// The interface is created in the container's namespace.
+			if _, err := netlink.LinkByName(n.TempVethPeerName); err == nil {
+				t.Fatalf("%s found in the host namespace", n.TempVethPeerName)
+			}
+
+			err = inNetns(t, pid, func() error {
+				if err := strategy.initialize(n); err != nil {
+					return err
+				}
+				link, err := netlink.LinkByName("eth0")
+				if err != nil {
+					return err
+				}
+				tc.check(t, link)
+				if link.Attrs().MTU != 1400 {
+					t.Errorf("expected mtu 1400, got %d", link.Attrs().MTU)
+				}
+				if link.Attrs().Flags&unix.IFF_UP == 0 {
+					t.Error("expected the interface to be up")
+				}
+				addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+				if err != nil {
+					return err
+				}
+				if len(addrs) != 1 || addrs[0].IPNet.String() != "10.0.0.2/24" {
+					t.Errorf("expected address 10.0.0.2/24, got %v", addrs)
+				}
+				routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+				if err != nil {
+					return err
+				}
+				for _, r := range routes {
+					if r.Gw.String() == "10.0.0.1" {
+						return nil
+					}
+				}
+				t.Errorf("default route via 10.0.0.1 not found in %v", routes)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := strategy.destroy(&n.Network); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestVlanNetworkNoParent(t *testing.T) {
+	newTestNetns(t)
+	addDummyParent(t, "parent0")
+	pid := startNetnsProcess(t)
+
+	n := &network{Network: configs.Network{Type: "macvlan", Name: "eth0", Parent: "nonexistent0"}}
+	strategy, err := getStrategy(n.Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.create(n, pid); err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestVethNetwork(t *testing.T) {
+	newTestNetns(t)
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "br0"
+	addTestLink(t, &netlink.Bridge{LinkAttrs: attrs})
+	pid := startNetnsProcess(t)
+
+	n := &network{Network: configs.Network{
+		Type:              "veth",
+		Name:              "eth0",
+		Bridge:            "br0",
+		HostInterfaceName: "veth-host0",
+		MacAddress:        "02:42:ac:11:00:03",
+		Address:           "10.0.0.3/24",
+		Mtu:               1400,
+	}}
+	strategy, err := getStrategy(n.Type)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := strategy.create(n, pid); err != nil {
+		t.Fatal(err)
+	}
+	host, err := netlink.LinkByName("veth-host0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	br, err := netlink.LinkByName("br0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if host.Attrs().MasterIndex != br.Attrs().Index {
+		t.Errorf("expected veth-host0 to be attached to br0, got master index %d", host.Attrs().MasterIndex)
+	}
+	// The peer is created in the container's namespace.
+	if _, err := netlink.LinkByName(n.TempVethPeerName); err == nil {
+		t.Fatalf("%s found in the host namespace", n.TempVethPeerName)
+	}
+
+	err = inNetns(t, pid, func() error {
+		if _, err := netlink.LinkByName(n.TempVethPeerName); err != nil {
+			return err
+		}
+		if err := strategy.initialize(n); err != nil {
+			return err
+		}
+		if _, err := netlink.LinkByName(n.TempVethPeerName); err == nil {
+			t.Errorf("%s is not renamed", n.TempVethPeerName)
+		}
+		link, err := netlink.LinkByName("eth0")
+		if err != nil {
+			return err
+		}
+		if _, ok := link.(*netlink.Veth); !ok {
+			t.Errorf("expected veth, got %s", link.Type())
+		}
+		if mac := link.Attrs().HardwareAddr.String(); mac != "02:42:ac:11:00:03" {
+			t.Errorf("expected mac address 02:42:ac:11:00:03, got %s", mac)
+		}
+		if link.Attrs().MTU != 1400 {
+			t.Errorf("expected mtu 1400, got %d", link.Attrs().MTU)
+		}
+		if link.Attrs().Flags&unix.IFF_UP == 0 {
+			t.Error("expected the interface to be up")
+		}
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		if len(addrs) != 1 || addrs[0].IPNet.String() != "10.0.0.3/24" {
+			t.Errorf("expected address 10.0.0.3/24, got %v", addrs)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := strategy.destroy(&n.Network); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := netlink.LinkByName("veth-host0"); err == nil {
+		t.Error("veth-host0 is not removed")
+	}
+	// Removing the host end removes the peer too.
+	err = inNetns(t, pid, func() error {
+		if _, err := netlink.LinkByName("eth0"); err == nil {
+			t.Error("eth0 is not removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing to do once the pair is gone.
+	if err := strategy.destroy(&n.Network); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNetDeviceSettingsAndRestore(t *testing.T) {
+	newTestNetns(t)
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "host0"
+	addTestLink(t, &netlink.Veth{LinkAttrs: attrs, PeerName: "peer0"})
+	pid := startNetnsProcess(t)
+	nsPath := fmt.Sprintf("/proc/%d/ns/net", pid)
+
+	device, err := devChangeNetNamespace("host0", nsPath, configs.LinuxNetDevice{
+		Name:      "ctr0",
+		Addresses: []string{"192.0.2.2/24"},
+		MTU:       1400,
+		Routes: []*configs.Route{
+			{Gateway: "192.0.2.1"},
+			{Destination: "198.51.100.0/24"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = inNetns(t, pid, func() error {
+		link, err := netlink.LinkByName("ctr0")
+		if err != nil {
+			return err
+		}
+		if link.Attrs().MTU != 1400 {
+			t.Errorf("expected mtu 1400, got %d", link.Attrs().MTU)
+		}
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		if len(addrs) != 1 || addrs[0].IPNet.String() != "192.0.2.2/24" {
+			t.Errorf("expected address 192.0.2.2/24, got %v", addrs)
+		}
+		routes, err := netlink.RouteList(link, netlink.FAMILY_V4)
+		if err != nil {
+			return err
+		}
+		var gw, direct bool
+		for _, r := range routes {
+			gw = gw || r.Gw.String() == "192.0.2.1"
+			direct = direct || r.Dst != nil && r.Dst.String() == "198.51.100.0/24"
+		}
+		if !gw || !direct {
+			t.Errorf("expected routes not found in %v", routes)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The network namespace still exists, so the device is moved back.
+	if err := restoreNetDevice("host0", "ctr0", nsPath, *device); err != nil {
+		t.Fatal(err)
+	}
+	link, err := netlink.LinkByName("host0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().HardwareAddr.String() != device.HardwareAddr {
+		t.Errorf("expected hardware address %s, got %s", device.HardwareAddr, link.Attrs().HardwareAddr)
+	}
+	// Restoring again is a no-op.
+	if err := restoreNetDevice("host0", "ctr0", nsPath, *device); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNetDeviceSettingsError(t *testing.T) {
+	newTestNetns(t)
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "host0"
+	addTestLink(t, &netlink.Veth{LinkAttrs: attrs, PeerName: "peer0"})
+	pid := startNetnsProcess(t)
+	nsPath := fmt.Sprintf("/proc/%d/ns/net", pid)
+
+	// The gateway is unreachable, so the device is moved, but adding the
+	// route fails.
+	device, err := devChangeNetNamespace("host0", nsPath, configs.LinuxNetDevice{
+		Name:   "ctr0",
+		Routes: []*configs.Route{{Gateway: "203.0.113.1"}},
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if device == nil {
+		t.Fatal("expected the moved device state, got nil")
+	}
+	if !device.Virtual {
+		t.Error("expected a veth device to be virtual")
+	}
+	if err := restoreNetDevice("host0", "ctr0", nsPath, *device); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := netlink.LinkByName("host0"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupRoute(t *testing.T) {
+	newTestNetns(t)
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "eth0"
+	link := &netlink.Veth{LinkAttrs: attrs, PeerName: "peer0"}
+	addTestLink(t, link)
+	for _, address := range []string{"192.0.2.2/24", "2001:db8::2/64"} {
+		addr, err := netlink.ParseAddr(address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// No duplicate address detection, to use the address right away.
+		addr.Flags = unix.IFA_F_NODAD
+		if err := netlink.AddrAdd(link, addr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &configs.Config{
+		Routes: []*configs.Route{
+			{Destination: "198.51.100.0/24", InterfaceName: "eth0"},
+			{Destination: "203.0.113.0/24", Gateway: "192.0.2.1", Metric: 50},
+			{Gateway: "192.0.2.1", InterfaceName: "eth0", Table: 100},
+			{Destination: "::/0", Gateway: "2001:db8::1", InterfaceName: "eth0", Metric: 10},
+		},
+		Rules: []*configs.Rule{
+			{Priority: 1000, Source: "192.0.2.0/24", Table: 100},
+			{Priority: 1001, InputInterface: "eth0", Mark: 1, Mask: 0xff, IPv6: true, Table: 100},
+		},
+	}
+	if err := setupRoute(config); err != nil {
+		t.Fatal(err)
+	}
+
+	routes, err := netlink.RouteListFiltered(netlink.FAMILY_ALL, &netlink.Route{Table: unix.RT_TABLE_UNSPEC}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]bool)
+	for _, r := range routes {
+		switch {
+		case r.Dst != nil && r.Dst.String() == "198.51.100.0/24":
+			found["on-link"] = r.Gw == nil && r.Scope == netlink.SCOPE_LINK
+		case r.Dst != nil && r.Dst.String() == "203.0.113.0/24":
+			found["metric"] = r.Priority == 50
+		case r.Table == 100:
+			found["table"] = r.Gw.String() == "192.0.2.1"
+		case r.Gw.String() == "2001:db8::1":
+			found["ipv6"] = r.Priority == 10
+		}
+	}
+	for _, name := range []string{"on-link", "metric", "table", "ipv6"} {
+		if !found[name] {
+			t.Errorf("%s route not found in %v", name, routes)
+		}
+	}
+
+	rules, err := netlink.RuleList(netlink.FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(rules, func(r netlink.Rule) bool {
+		return r.Priority == 1000 && r.Table == 100 && r.Src != nil && r.Src.String() == "192.0.2.0/24"
+	}) {
+		t.Errorf("ipv4 rule not found in %v", rules)
+	}
+	rules, err = netlink.RuleList(netlink.FAMILY_V6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(rules, func(r netlink.Rule) bool {
+		return r.Priority == 1001 && r.Table == 100 && r.IifName == "eth0" && r.Mark == 1
+	}) {
+		t.Errorf("ipv6 rule not found in %v", rules)
+	}
+}
+
+func TestGetNetnsInterfaceStats(t *testing.T) {
+	newTestNetns(t)
+	lo, err := netlink.LinkByName("lo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := netlink.LinkSetUp(lo); err != nil {
+		t.Fatal(err)
+	}
+	attrs := netlink.NewLinkAttrs()
+	attrs.Name = "eth0"
+	addTestLink(t, &netlink.Veth{LinkAttrs: attrs, PeerName: "peer0"})
+
+	// Send a packet over the loopback interface.
+	conn, err := net.Dial("udp", "127.0.0.1:9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Write([]byte("ping"))
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ns, err := netns.Get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ns.Close()
+	ifaces, err := getNetnsInterfaceStats(ns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make(map[string]*types.NetworkInterface)
+	for _, iface := range ifaces {
+		names[iface.Name] = iface
+	}
+	for _, name := range []string{"lo", "eth0", "peer0"} {
+		if names[name] == nil {
+			t.Fatalf("interface %s not found in %+v", name, ifaces)
+		}
+	}
+	if s := names["lo"]; s.TxPackets == 0 || s.RxPackets == 0 || s.TxBytes == 0 {
+		t.Errorf("expected loopback traffic, got %+v", s)
+	}
+}
@@ -0,0 +1,150 @@
+package libcontainer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// PressureResource is a resource which pressure stall information (PSI)
+// is tracked for.
+type PressureResource string
+
+const (
+	PressureCPU    PressureResource = "cpu"
+	PressureMemory PressureResource = "memory"
+	PressureIO     PressureResource = "io"
+)
+
+// PressureTrigger describes a PSI trigger, which fires once the tasks of the
+// container have been stalled on Resource for at least Stall within Window.
+// See the kernel's Documentation/accounting/psi.rst for details.
+type PressureTrigger struct {
+	Resource PressureResource
+
+	// Full, if set, means the stall time is counted when all the tasks are
+	// stalled at the same time, rather than some of them ("some").
+	Full bool
+
+	// Stall is the stall time threshold.
+	Stall time.Duration
+
+	// Window is the time window to track the stall time in. The kernel
+	// requires it to be between 500ms and 10s, and a multiple of 2s unless
+	// the caller has CAP_SYS_RESOURCE.
+	Window time.Duration
+}
+
+func (t *PressureTrigger) String() string {
+	kind := "some"
+	if t.Full {
+		kind = "full"
+	}
+	// The kernel expects microseconds.
+	return fmt.Sprintf("%s %d %d", kind, t.Stall.Microseconds(), t.Window.Microseconds())
+}
+
+// notifyPressure registers a PSI trigger t in the cgroup v2 directory cgDir,
+// and returns a channel which receives an event each time it fires. The
+// channel is closed once the cgroup is empty or removed, or ctx is done.
+func notifyPressure(ctx context.Context, cgDir string, t PressureTrigger) (<-chan struct{}, error) {
+	switch t.Resource {
+	case PressureCPU, PressureMemory, PressureIO:
+	default:
+		return nil, fmt.Errorf("invalid pressure resource %q", t.Resource)
+	}
+	if t.Stall <= 0 || t.Window <= 0 || t.Stall > t.Window {
+		return nil, fmt.Errorf("invalid pressure trigger %q: stall must be positive and not exceed window", t.String())
+	}
+
+	psiPath := filepath.Join(cgDir, string(t.Resource)+".pressure")
+	psiFile, err := os.OpenFile(psiPath, os.O_RDWR|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	// A trigger is registered by a write, and lives as long as the file
+	// is open. The kernel expects a NUL-terminated string.
+	if _, err := psiFile.WriteString(t.String() + "\x00"); err != nil {
+		psiFile.Close()
+		return nil, fmt.Errorf("unable to register pressure trigger %q in %s: %w", t.String(), psiPath, err)
+	}
+	// As there are no deletion events for cgroupfs files, watch for the
+	// cgroup becoming empty, as notifyOnOOMV2 does.
+	evFile, err := os.OpenFile(filepath.Join(cgDir, "cgroup.events"), os.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		psiFile.Close()
+		return nil, err
+	}
+	cancelFd, release, err := newCancelFd(ctx)
+	if err != nil {
+		psiFile.Close()
+		evFile.Close()
+		return nil, err
+	}
+
+	ch := make(chan struct{})
+	go func() {
+		defer func() {
+			release()
+			psiFile.Close()
+			evFile.Close()
+			close(ch)
+		}()
+		fds := []unix.PollFd{
+			{Fd: int32(psiFile.Fd()), Events: unix.POLLPRI},
+			{Fd: int32(evFile.Fd()), Events: unix.POLLPRI},
+			{Fd: int32(cancelFd), Events: unix.POLLIN},
+		}
+		for {
+			if populated, err := cgroupPopulated(evFile); err != nil || !populated {
+				return
+			}
+			if _, err := unix.Poll(fds, -1); err != nil {
+				if errors.Is(err, unix.EINTR) {
+					continue
+				}
+				logrus.Warnf("unable to poll for pressure events: %v", os.NewSyscallError("poll", err))
+				return
+			}
+			if fds[2].Revents != 0 {
+				// The ctx is done.
+				return
+			}
+			switch ev := fds[0].Revents; {
+			case ev&(unix.POLLERR|unix.POLLHUP|unix.POLLNVAL) != 0:
+				// The cgroup is removed.
+				return
+			case ev&unix.POLLPRI != 0:
+				select {
+				case ch <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// cgroupPopulated reads the populated key from the opened cgroup.events file.
+// Reading it also acknowledges a pending POLLPRI event on it.
+func cgroupPopulated(evFile *os.File) (bool, error) {
+	buf := make([]byte, 256)
+	n, err := evFile.ReadAt(buf, 0)
+	if err != nil && n == 0 {
+		return false, err
+	}
+	for line := range bytes.Lines(buf[:n]) {
+		if v, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("populated ")); ok {
+			return string(v) != "0", nil
+		}
+	}
+	return false, errors.New("no populated key in cgroup.events")
+}
@@ -0,0 +1,65 @@
+package libcontainer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPressureTriggerString(t *testing.T) {
+	for _, tc := range []struct {
+		trigger PressureTrigger
+		want    string
+	}{
+		{PressureTrigger{Resource: PressureMemory, Stall: 150 * time.Millisecond, Window: time.Second}, "some 150000 1000000"},
+		{PressureTrigger{Resource: PressureIO, Full: true, Stall: time.Second, Window: 2 * time.Second}, "full 1000000 2000000"},
+	} {
+		if got := tc.trigger.String(); got != tc.want {
+			t.Errorf("expected %q, got %q", tc.want, got)
+		}
+	}
+}
+
+func TestNotifyPressureInvalid(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{"cpu.pressure", "cgroup.events"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, trigger := range []PressureTrigger{
+		{Resource: "../cpu", Stall: time.Millisecond, Window: time.Second},
+		{Resource: PressureCPU, Stall: 0, Window: time.Second},
+		{Resource: PressureCPU, Stall: 2 * time.Second, Window: time.Second},
+	} {
+		if _, err := notifyPressure(context.Background(), dir, trigger); err == nil {
+			t.Errorf("expected error for trigger %+v, got nil", trigger)
+		}
+	}
+}
+
+func TestNotifyPressureCancel(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "cpu.pressure"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.events"), []byte("populated 1\nfrozen 0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch, err := notifyPressure(ctx, dir, PressureTrigger{Resource: PressureCPU, Stall: time.Millisecond, Window: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	select {
+	case _, ok := <-ch:
+		if ok {
+			t.Fatal("expected the channel to be closed, got an event")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the channel is not closed once ctx is done")
+	}
+}
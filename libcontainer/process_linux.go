@@ -178,6 +178,15 @@ type setnsProcess struct {
 	initProcessPid  int
 }
 
+func (p *setnsProcess) wait() (*os.ProcessState, error) {
+	ps, err := p.containerProcess.wait()
+	if ps != nil {
+		ws := ps.Sys().(syscall.WaitStatus)
+		p.container.publishEvent(EventExecExited, p.pid(), newExitStatus(unix.WaitStatus(ws), time.Now()))
+	}
+	return ps, err
+}
+
 // tryResetCPUAffinity tries to reset the CPU affinity of the process
 // identified by pid to include all possible CPUs (notwithstanding cgroup
 // cpuset restrictions, isolated CPUs and CPU online status).
@@ -1006,6 +1015,7 @@ func (p *initProcess) start() (retErr error) {
 				return fmt.Errorf("unable to store init state: %w", uerr)
 			}
 			p.container.initProcessStartTime = state.InitProcessStartTime
+			p.container.publishEvent(EventCreated, p.pid(), nil)
 
 			// Sync with child.
 			if err := writeSync(p.comm.syncSockParent, procRun); err != nil {
@@ -1092,7 +1102,15 @@ func (p *initProcess) setupNetworkDevices() error {
 	// that were successfully moved before the failure occurred.
 	// See: https://github.com/opencontainers/runtime-spec/blob/27cb0027fd92ef81eda1ea3a8153b8337f56d94a/config-linux.md#namespace-lifecycle-and-container-termination
 	for name, netDevice := range p.config.Config.NetDevices {
-		err := devChangeNetNamespace(name, nsPath, *netDevice)
+		device, err := devChangeNetNamespace(name, nsPath, *netDevice)
+		// Record the device once it is moved, even if setting it up has
+		// failed, so that it is restored when the container is destroyed.
+		if device != nil {
+			if p.container.netDevices == nil {
+				p.container.netDevices = make(map[string]NetDeviceState)
+			}
+			p.container.netDevices[name] = *device
+		}
 		if err != nil {
 			return fmt.Errorf("move netDevice %s to namespace %s: %w", name, nsPath, err)
 		}
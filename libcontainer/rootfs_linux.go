@@ -40,6 +40,9 @@ type mountConfig struct {
 	cgroup2Path     string
 	rootlessCgroups bool
 	cgroupns        bool
+	// rec, if set, makes mountToRootfs record the operations instead of
+	// performing them (see MountPlan).
+	rec *mountRecorder
 }
 
 // mountEntry contains mount data specific to a mount point.
@@ -47,6 +50,7 @@ type mountEntry struct {
 	*configs.Mount
 	srcFile *mountSource
 	dstFile *os.File
+	rec     *mountRecorder
 }
 
 // srcName is only meant for error messages, it returns a "friendly" name.
@@ -97,14 +101,14 @@ func needsSetupDev(config *configs.Config) bool {
 	return true
 }
 
-func doSetupDev(rootFd *os.File, config *configs.Config) error {
-	if err := createDevices(rootFd, config); err != nil {
+func doSetupDev(rootFd *os.File, config *configs.Config, rec *mountRecorder) error {
+	if err := createDevices(rootFd, config, rec); err != nil {
 		return fmt.Errorf("error creating device nodes: %w", err)
 	}
-	if err := setupPtmx(rootFd); err != nil {
+	if err := setupPtmx(rootFd, rec); err != nil {
 		return fmt.Errorf("error setting up ptmx: %w", err)
 	}
-	if err := setupDevSymlinks(rootFd); err != nil {
+	if err := setupDevSymlinks(rootFd, rec); err != nil {
 		return fmt.Errorf("error setting up /dev symlinks: %w", err)
 	}
 	return nil
@@ -169,7 +173,7 @@ func setupAndMountToRootfs(pipe *syncSocket, config *configs.Config, mountConfig
 // finalizeRootfs after this function to finish setting up the rootfs.
 func prepareRootfs(pipe *syncSocket, iConfig *initConfig) (err error) {
 	config := iConfig.Config
-	if err := prepareRoot(config); err != nil {
+	if err := prepareRoot(config, nil); err != nil {
 		return fmt.Errorf("error preparing rootfs: %w", err)
 	}
 
@@ -197,7 +201,7 @@ func prepareRootfs(pipe *syncSocket, iConfig *initConfig) (err error) {
 
 	setupDev := needsSetupDev(config)
 	if setupDev {
-		if err := doSetupDev(rootFd, config); err != nil {
+		if err := doSetupDev(rootFd, config, nil); err != nil {
 			return fmt.Errorf("configuring container /dev: %w", err)
 		}
 	}
@@ -231,30 +235,11 @@ func prepareRootfs(pipe *syncSocket, iConfig *initConfig) (err error) {
 		}
 	}
 
-	if config.NoPivotRoot {
-		err = msMoveRoot(config.Rootfs)
-	} else if config.Namespaces.Contains(configs.NEWNS) {
-		err = pivotRoot(rootFd)
-	} else {
-		err = chroot()
-	}
-	if err != nil {
-		return fmt.Errorf("error jailing process inside rootfs: %w", err)
+	if err := jailRootfs(config, rootFd, nil); err != nil {
+		return err
 	}
 	rootFd.Close()
 
-	// Apply root mount propagation flags.
-	// This must be done after pivot_root/chroot because the mount propagation flag is applied
-	// to the current root ("/"), and not to the old rootfs before it becomes "/". Applying the
-	// flag in prepareRoot would affect the host mount namespace if the container's
-	// root mount is shared.
-	// MS_PRIVATE or MS_SLAVE is skipped as rootfsParentMountPropagation() is already called.
-	if config.RootPropagation != 0 && config.RootPropagation&(unix.MS_PRIVATE|unix.MS_SLAVE) == 0 {
-		if err := mount("", "/", "", uintptr(config.RootPropagation), ""); err != nil {
-			return fmt.Errorf("unable to apply root propagation flags: %w", err)
-		}
-	}
-
 	if setupDev {
 		if err := reOpenDevNull(); err != nil {
 			return fmt.Errorf("error reopening /dev/null inside container: %w", err)
@@ -272,9 +257,40 @@ func prepareRootfs(pipe *syncSocket, iConfig *initConfig) (err error) {
 	return nil
 }
 
+// jailRootfs jails the process inside the rootfs (opened as rootFd), and
+// applies the root mount propagation flags. If rec is set, the operations are
+// recorded instead.
+func jailRootfs(config *configs.Config, rootFd *os.File, rec *mountRecorder) error {
+	op, jail := "chroot", chroot
+	if config.NoPivotRoot {
+		op, jail = "move_root", func() error { return msMoveRoot(config.Rootfs) }
+	} else if config.Namespaces.Contains(configs.NEWNS) {
+		op, jail = "pivot_root", func() error { return pivotRoot(rootFd) }
+	}
+	if rec != nil {
+		rec.add(MountOp{Op: op, Source: config.Rootfs, Destination: "/"})
+	} else if err := jail(); err != nil {
+		return fmt.Errorf("error jailing process inside rootfs: %w", err)
+	}
+
+	// Apply root mount propagation flags.
+	// This must be done after pivot_root/chroot because the mount propagation flag is applied
+	// to the current root ("/"), and not to the old rootfs before it becomes "/". Applying the
+	// flag in prepareRoot would affect the host mount namespace if the container's
+	// root mount is shared.
+	// MS_PRIVATE or MS_SLAVE is skipped as rootfsParentMountPropagation() is already called.
+	if config.RootPropagation != 0 && config.RootPropagation&(unix.MS_PRIVATE|unix.MS_SLAVE) == 0 {
+		if err := rec.mount("", "/", "", uintptr(config.RootPropagation), ""); err != nil {
+			return fmt.Errorf("unable to apply root propagation flags: %w", err)
+		}
+	}
+	return nil
+}
+
 // finalizeRootfs sets anything to ro if necessary. You must call
-// prepareRootfs first.
-func finalizeRootfs(config *configs.Config) (err error) {
+// prepareRootfs first. If rec is set, the mount operations are recorded
+// instead.
+func finalizeRootfs(config *configs.Config, rec *mountRecorder) (err error) {
 	// All tmpfs mounts and /dev were previously mounted as rw
 	// by mountPropagate. Remount them read-only as requested.
 	for _, m := range config.Mounts {
@@ -282,7 +298,7 @@ func finalizeRootfs(config *configs.Config) (err error) {
 			continue
 		}
 		if m.Device == "tmpfs" || pathrs.LexicallyCleanPath(m.Destination) == "/dev" {
-			if err := remountReadonly(m); err != nil {
+			if err := remountReadonly(m, rec); err != nil {
 				return err
 			}
 		}
@@ -290,11 +306,15 @@ func finalizeRootfs(config *configs.Config) (err error) {
 
 	// set rootfs ( / ) as readonly
 	if config.Readonlyfs {
-		if err := setReadonly(); err != nil {
+		if err := setReadonly(rec); err != nil {
 			return fmt.Errorf("error setting rootfs as readonly: %w", err)
 		}
 	}
 
+	if rec != nil {
+		return nil
+	}
+
 	if config.Umask != nil {
 		unix.Umask(int(*config.Umask))
 	} else {
@@ -350,28 +370,32 @@ func mountCgroupV1(m mountEntry, c *mountConfig) error {
 
 	for _, b := range binds {
 		if c.cgroupns {
+			subsystemName := filepath.Base(b.Destination)
+			flags := defaultMountFlags
+			if m.Flags&unix.MS_RDONLY != 0 {
+				flags = flags | unix.MS_RDONLY
+			}
+			var (
+				source = "cgroup"
+				data   = subsystemName
+			)
+			if data == "systemd" {
+				data = cgroups.CgroupNamePrefix + data
+				source = "systemd"
+			}
+			if c.rec != nil {
+				c.rec.record(source, b.Destination, "cgroup", uintptr(flags), data)
+				continue
+			}
 			// We just created the tmpfs, and so we can just use filepath.Join
 			// here (not to mention we want to make sure we create the path
 			// inside the tmpfs, so we don't want to resolve symlinks).
-			subsystemName := filepath.Base(b.Destination)
 			subsystemDir, err := pathrs.MkdirAllInRoot(c.root, b.Destination, 0o755)
 			if err != nil {
 				return err
 			}
 			defer subsystemDir.Close()
 			if err := utils.WithProcfdFile(subsystemDir, func(dstFd string) error {
-				flags := defaultMountFlags
-				if m.Flags&unix.MS_RDONLY != 0 {
-					flags = flags | unix.MS_RDONLY
-				}
-				var (
-					source = "cgroup"
-					data   = subsystemName
-				)
-				if data == "systemd" {
-					data = cgroups.CgroupNamePrefix + data
-					source = "systemd"
-				}
 				return mountViaFds(source, nil, b.Destination, dstFd, "cgroup", uintptr(flags), data)
 			}); err != nil {
 				return err
@@ -388,6 +412,10 @@ func mountCgroupV1(m mountEntry, c *mountConfig) error {
 			// the link and doesn't do any checks or relative path
 			// conversion. Also, don't error out if the cgroup already exists.
 			ssPath := filepath.Join(m.Destination, ss)
+			if c.rec != nil {
+				c.rec.add(MountOp{Op: "symlink", Source: mc, Destination: ssPath})
+				continue
+			}
 			if err := pathrs.SymlinkInRoot(mc, c.root, ssPath); err != nil && !errors.Is(err, os.ErrExist) {
 				return err
 			}
@@ -397,6 +425,11 @@ func mountCgroupV1(m mountEntry, c *mountConfig) error {
 }
 
 func mountCgroupV2(m mountEntry, c *mountConfig) error {
+	if c.rec != nil {
+		c.rec.record(m.Source, m.Destination, "cgroup2", uintptr(m.Flags), m.Data)
+		c.rec.note("if not permitted, the container cgroup (or " + fs2.UnifiedMountpoint + ") is bind-mounted instead")
+		return nil
+	}
 	err := utils.WithProcfdFile(m.dstFile, func(dstFd string) error {
 		return mountViaFds(m.Source, nil, m.Destination, dstFd, "cgroup2", uintptr(m.Flags), m.Data)
 	})
@@ -434,6 +467,18 @@ func mountCgroupV2(m mountEntry, c *mountConfig) error {
 }
 
 func doTmpfsCopyUp(m mountEntry, mountLabel string) (Err error) {
+	if m.rec != nil {
+		// The tmpfs is mounted on the host as below, and then moved.
+		if err := m.mountPropagate(nil, mountLabel); err != nil {
+			return err
+		}
+		m.rec.add(MountOp{
+			Op:          "tmpcopyup",
+			Destination: m.Destination,
+			Note:        "the tmpfs is mounted on the host, populated with the contents of the destination directory, then moved over it",
+		})
+		return nil
+	}
 	// Set up a scratch dir for the tmpfs on the host.
 	tmpdir, err := prepareTmp("/tmp")
 	if err != nil {
@@ -608,6 +653,7 @@ func (m *mountEntry) createOpenMountpoint(root *os.File) (Err error) {
 }
 
 func mountToRootfs(c *mountConfig, m mountEntry) error {
+	m.rec = c.rec
 	defer func() {
 		if m.dstFile != nil {
 			_ = m.dstFile.Close()
@@ -619,6 +665,9 @@ func mountToRootfs(c *mountConfig, m mountEntry) error {
 	// mounted on a specific path in a container without any funny business.
 	switch m.Device {
 	case "proc", "sysfs":
+		if m.rec != nil {
+			return m.mountPropagate(c.root, "")
+		}
 		// If the destination already exists and is not a directory, we bail
 		// out. This is to avoid mounting through a symlink or similar -- which
 		// has been a "fun" attack scenario in the past.
@@ -652,8 +701,11 @@ func mountToRootfs(c *mountConfig, m mountEntry) error {
 	}
 
 	mountLabel := c.label
-	if err := m.createOpenMountpoint(c.root); err != nil {
-		return fmt.Errorf("create mountpoint for %s mount: %w", m.Destination, err)
+	// The mountpoint is not created when only recording the operations.
+	if m.rec == nil {
+		if err := m.createOpenMountpoint(c.root); err != nil {
+			return fmt.Errorf("create mountpoint for %s mount: %w", m.Destination, err)
+		}
 	}
 
 	switch m.Device {
@@ -661,6 +713,9 @@ func mountToRootfs(c *mountConfig, m mountEntry) error {
 		if err := m.mountPropagate(c.root, ""); err != nil {
 			return err
 		}
+		if m.rec != nil {
+			return nil
+		}
 		return utils.WithProcfdFile(m.dstFile, func(dstFd string) error {
 			return label.SetFileLabel(dstFd, mountLabel)
 		})
@@ -688,8 +743,11 @@ func mountToRootfs(c *mountConfig, m mountEntry) error {
 		// contrast to mount(8)'s current behaviour, but is what users probably
 		// expect. See <https://github.com/util-linux/util-linux/issues/2433>.
 		if m.Flags & ^(unix.MS_BIND|unix.MS_REC|unix.MS_REMOUNT) != 0 || m.ClearedFlags != 0 {
-			if err := utils.WithProcfdFile(m.dstFile, func(dstFd string) error {
-				flags := m.Flags | unix.MS_BIND | unix.MS_REMOUNT
+			flags := m.Flags | unix.MS_BIND | unix.MS_REMOUNT
+			if m.rec != nil {
+				m.rec.record("", m.Destination, "", uintptr(flags), "")
+				m.rec.note("retried with the locked flags of the source mount re-applied, if needed")
+			} else if err := utils.WithProcfdFile(m.dstFile, func(dstFd string) error {
 				// The runtime-spec says we SHOULD map to the relevant mount(8)
 				// behaviour. However, it's not clear whether we want the
 				// "mount --bind -o ..." or "mount --bind -o remount,..."
@@ -901,7 +959,8 @@ func checkProcMount(rootfs, dest string, m mountEntry) error {
 	return fmt.Errorf("%q cannot be mounted because it is inside /proc", dest)
 }
 
-func setupDevSymlinks(rootFd *os.File) error {
+// devSymlinks returns the list of {target, name} symlinks to create in /dev.
+func devSymlinks() [][2]string {
 	// In theory, these should be links to /proc/thread-self, but systems
 	// expect these to be /proc/self and this matches how most distributions
 	// work.
@@ -916,8 +975,16 @@ func setupDevSymlinks(rootFd *os.File) error {
 	if _, err := os.Stat("/proc/kcore"); err == nil {
 		links = append(links, [2]string{"/proc/kcore", "/dev/core"})
 	}
-	for _, link := range links {
+	return links
+}
+
+func setupDevSymlinks(rootFd *os.File, rec *mountRecorder) error {
+	for _, link := range devSymlinks() {
 		target, devName := link[0], link[1]
+		if rec != nil {
+			rec.add(MountOp{Op: "symlink", Source: target, Destination: devName})
+			continue
+		}
 		if err := pathrs.SymlinkInRoot(target, rootFd, devName); err != nil && !errors.Is(err, os.ErrExist) {
 			return err
 		}
@@ -954,7 +1021,7 @@ func reOpenDevNull() error {
 }
 
 // Create the device nodes in the container.
-func createDevices(rootFd *os.File, config *configs.Config) error {
+func createDevices(rootFd *os.File, config *configs.Config, rec *mountRecorder) error {
 	useBindMount := userns.RunningInUserNS() || config.Namespaces.Contains(configs.NEWUSER)
 	for _, node := range config.Devices {
 
@@ -965,7 +1032,7 @@ func createDevices(rootFd *os.File, config *configs.Config) error {
 
 		// containers running in a user namespace are not allowed to mknod
 		// devices so we can just bind mount it from the host.
-		if err := createDeviceNode(rootFd, node, useBindMount); err != nil {
+		if err := createDeviceNode(rootFd, node, useBindMount, rec); err != nil {
 			return err
 		}
 	}
@@ -984,12 +1051,25 @@ func bindMountDeviceNode(destDir *os.File, destName string, node *devices.Device
 	})
 }
 
-// Creates the device node in the rootfs of the container.
-func createDeviceNode(rootFd *os.File, node *devices.Device, bind bool) error {
+// Creates the device node in the rootfs of the container. If rec is set, the
+// operation is recorded instead.
+func createDeviceNode(rootFd *os.File, node *devices.Device, bind bool, rec *mountRecorder) error {
 	if node.Path == "" {
 		// The node only exists for cgroup reasons, ignore it here.
 		return nil
 	}
+	if rec != nil {
+		if bind {
+			rec.record(node.Path, node.Path, "bind", unix.MS_BIND, "")
+		} else {
+			rec.add(MountOp{
+				Op:          "mknod",
+				Destination: node.Path,
+				Note:        "the host device is bind-mounted instead, if not permitted",
+			})
+		}
+		return nil
+	}
 	destDir, destName, err := pathrs.MkdirAllParentInRoot(rootFd, node.Path, 0o755)
 	if err != nil {
 		return fmt.Errorf("mkdir parent of device inode %q: %w", node.Path, err)
@@ -1078,9 +1158,14 @@ func rootfsParentMountPropagationFlags(rootPropagation int) uintptr {
 //   - pivot_root() will fail if parent mount is shared;
 //   - when we bind mount rootfs, if its parent is (r)shared, the new mount
 //     will propagate (leak!) to parent namespace and we don't want that.
-func rootfsParentMountPropagation(path string, rootPropagation int) error {
+func rootfsParentMountPropagation(path string, rootPropagation int, rec *mountRecorder) error {
 	var err error
 	flags := rootfsParentMountPropagationFlags(rootPropagation)
+	if rec != nil {
+		rec.record("", path, "", flags, "")
+		rec.note("applied to the mount containing the path")
+		return nil
+	}
 	// Assuming path is absolute and clean (this is checked in
 	// libcontainer/validate). Any error other than EINVAL means we failed,
 	// and EINVAL means this is not a mount point, so traverse up until we
@@ -1103,26 +1188,28 @@ func rootfsParentMountPropagation(path string, rootPropagation int) error {
 	}
 }
 
-func prepareRoot(config *configs.Config) error {
+// prepareRoot prepares the host mounts for the rootfs. If rec is set, the
+// operations are recorded instead.
+func prepareRoot(config *configs.Config, rec *mountRecorder) error {
 	flag := unix.MS_SLAVE | unix.MS_REC
 	if config.RootPropagation != 0 {
 		flag = config.RootPropagation
 	}
-	if err := mount("", "/", "", uintptr(flag), ""); err != nil {
+	if err := rec.mount("", "/", "", uintptr(flag), ""); err != nil {
 		return err
 	}
 
-	if err := rootfsParentMountPropagation(config.Rootfs, config.RootPropagation); err != nil {
+	if err := rootfsParentMountPropagation(config.Rootfs, config.RootPropagation, rec); err != nil {
 		return err
 	}
 
-	return mount(config.Rootfs, config.Rootfs, "bind", unix.MS_BIND|unix.MS_REC, "")
+	return rec.mount(config.Rootfs, config.Rootfs, "bind", unix.MS_BIND|unix.MS_REC, "")
 }
 
-func setReadonly() error {
+func setReadonly(rec *mountRecorder) error {
 	flags := uintptr(unix.MS_BIND | unix.MS_REMOUNT | unix.MS_RDONLY)
 
-	err := mount("", "/", "", flags, "")
+	err := rec.mount("", "/", "", flags, "")
 	if err == nil {
 		return nil
 	}
@@ -1134,7 +1221,11 @@ func setReadonly() error {
 	return mount("", "/", "", flags, "")
 }
 
-func setupPtmx(rootFd *os.File) error {
+func setupPtmx(rootFd *os.File, rec *mountRecorder) error {
+	if rec != nil {
+		rec.add(MountOp{Op: "symlink", Source: "pts/ptmx", Destination: "/dev/ptmx"})
+		return nil
+	}
 	if err := pathrs.UnlinkInRoot(rootFd, "/dev/ptmx", 0); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
@@ -1290,7 +1381,7 @@ func readonlyPath(path string) error {
 }
 
 // remountReadonly will remount an existing mount point and ensure that it is read-only.
-func remountReadonly(m *configs.Mount) error {
+func remountReadonly(m *configs.Mount, rec *mountRecorder) error {
 	var (
 		dest  = m.Destination
 		flags = m.Flags
@@ -1303,7 +1394,7 @@ func remountReadonly(m *configs.Mount) error {
 		// nosuid, etc.). So, let's use that case so that we can do
 		// this re-mount without failing in a userns.
 		flags |= unix.MS_REMOUNT | unix.MS_BIND | unix.MS_RDONLY
-		if err := mount("", dest, "", uintptr(flags), ""); err != nil {
+		if err := rec.mount("", dest, "", uintptr(flags), ""); err != nil {
 			if errors.Is(err, unix.EBUSY) {
 				time.Sleep(100 * time.Millisecond)
 				continue
@@ -1499,6 +1590,14 @@ func (m *mountEntry) mountPropagate(rootFd *os.File, mountLabel string) error {
 		flags &= ^unix.MS_RDONLY
 	}
 
+	if m.rec != nil {
+		m.rec.record(m.Source, m.Destination, m.Device, uintptr(flags), data)
+		for _, pflag := range m.PropagationFlags {
+			m.rec.record("", m.Destination, "", uintptr(pflag), "")
+		}
+		return nil
+	}
+
 	if err := utils.WithProcfdFile(m.dstFile, func(dstFd string) error {
 		return mountViaFds(m.Source, m.srcFile, m.Destination, dstFd, m.Device, uintptr(flags), data)
 	}); err != nil {
@@ -1537,6 +1636,10 @@ func setRecAttr(m mountEntry) error {
 	if m.RecAttr == nil {
 		return nil
 	}
+	if m.rec != nil {
+		m.rec.add(MountOp{Op: "setattr", Destination: m.Destination, Note: "mount_setattr(2) with AT_RECURSIVE"})
+		return nil
+	}
 	return utils.WithProcfdFile(m.dstFile, func(procfd string) error {
 		return unix.MountSetattr(-1, procfd, unix.AT_RECURSIVE, m.RecAttr)
 	})
@@ -104,6 +104,39 @@ func ConvertStringToArch(in string) (string, error) {
 	return "", fmt.Errorf("string %s is not a valid arch for seccomp", in)
 }
 
+// ConvertOperatorToString converts a Seccomp comparison operator into its
+// Libseccomp name. It is the inverse of [ConvertStringToOperator].
+func ConvertOperatorToString(op configs.Operator) (string, error) {
+	for name, o := range operators {
+		if o == op {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("operator %d is not a valid operator for seccomp", op)
+}
+
+// ConvertActionToString converts a Seccomp rule match action into its
+// Libseccomp name. It is the inverse of [ConvertStringToAction].
+func ConvertActionToString(act configs.Action) (string, error) {
+	for name, a := range actions {
+		if a == act {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("action %d is not a valid action for seccomp", act)
+}
+
+// ConvertArchToString converts a Seccomp arch into its Libseccomp name.
+// It is the inverse of [ConvertStringToArch].
+func ConvertArchToString(arch string) (string, error) {
+	for name, a := range archs {
+		if a == arch {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("arch %s is not a valid arch for seccomp", arch)
+}
+
 // List of flags known to this version of runc.
 var flags = []string{
 	flagTsync,
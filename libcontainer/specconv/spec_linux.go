@@ -3,6 +3,7 @@
 package specconv
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
@@ -24,6 +25,7 @@ import (
 	devices "github.com/opencontainers/cgroups/devices/config"
 	"github.com/opencontainers/runc/internal/linux"
 	"github.com/opencontainers/runc/internal/pathrs"
+	"github.com/opencontainers/runc/internal/specerr"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/internal/userns"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
@@ -391,7 +393,7 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 	}
 	spec := opts.Spec
 	if spec.Root == nil {
-		return nil, errors.New("root must be specified")
+		return nil, specerr.WithPath(errors.New("root must be specified"), "root")
 	}
 	rootfsPath := spec.Root.Path
 	if !filepath.IsAbs(rootfsPath) {
@@ -413,13 +415,18 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		RootlessCgroups: opts.RootlessCgroups,
 	}
 
-	for _, m := range spec.Mounts {
+	var mountErrs []error
+	for i, m := range spec.Mounts {
 		cm, err := createLibcontainerMount(cwd, m)
 		if err != nil {
-			return nil, fmt.Errorf("invalid mount %+v: %w", m, err)
+			mountErrs = append(mountErrs, specerr.WithPath(fmt.Errorf("invalid mount %+v: %w", m, err), "mounts", i))
+			continue
 		}
 		config.Mounts = append(config.Mounts, cm)
 	}
+	if err := errors.Join(mountErrs...); err != nil {
+		return nil, err
+	}
 
 	defaultDevs, err := createDevices(spec, config)
 	if err != nil {
@@ -439,20 +446,20 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		if spec.Linux.RootfsPropagation != "" {
 			var exists bool
 			if config.RootPropagation, exists = mountPropagationMapping[spec.Linux.RootfsPropagation]; !exists {
-				return nil, fmt.Errorf("rootfsPropagation=%v is not supported", spec.Linux.RootfsPropagation)
+				return nil, specerr.WithPath(fmt.Errorf("rootfsPropagation=%v is not supported", spec.Linux.RootfsPropagation), "linux", "rootfsPropagation")
 			}
 			if config.NoPivotRoot && (config.RootPropagation&unix.MS_PRIVATE != 0) {
-				return nil, errors.New("rootfsPropagation of [r]private is not safe without pivot_root")
+				return nil, specerr.WithPath(errors.New("rootfsPropagation of [r]private is not safe without pivot_root"), "linux", "rootfsPropagation")
 			}
 		}
 
-		for _, ns := range spec.Linux.Namespaces {
+		for i, ns := range spec.Linux.Namespaces {
 			t, exists := namespaceMapping[ns.Type]
 			if !exists {
-				return nil, fmt.Errorf("namespace %q does not exist", ns)
+				return nil, specerr.WithPath(fmt.Errorf("namespace %q does not exist", ns), "linux", "namespaces", i, "type")
 			}
 			if config.Namespaces.Contains(t) {
-				return nil, fmt.Errorf("malformed spec file: duplicated ns %q", ns)
+				return nil, specerr.WithPath(fmt.Errorf("malformed spec file: duplicated ns %q", ns), "linux", "namespaces", i)
 			}
 			config.Namespaces.Add(t, ns.Path)
 		}
@@ -509,16 +516,16 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 			confMp := &configs.LinuxMemoryPolicy{}
 			confMp.Mode, ok = mpolModeMap[string(specMp.Mode)]
 			if !ok {
-				return nil, fmt.Errorf("invalid memory policy mode %q", specMp.Mode)
+				return nil, specerr.WithPath(fmt.Errorf("invalid memory policy mode %q", specMp.Mode), "linux", "memoryPolicy", "mode")
 			}
 			confMp.Nodes, err = configs.ToCPUSet(specMp.Nodes)
 			if err != nil {
-				return nil, fmt.Errorf("invalid memory policy nodes %q: %w", specMp.Nodes, err)
+				return nil, specerr.WithPath(fmt.Errorf("invalid memory policy nodes %q: %w", specMp.Nodes, err), "linux", "memoryPolicy", "nodes")
 			}
-			for _, specFlag := range specMp.Flags {
+			for i, specFlag := range specMp.Flags {
 				confFlag, ok := mpolModeFMap[string(specFlag)]
 				if !ok {
-					return nil, fmt.Errorf("invalid memory policy flag %q", specFlag)
+					return nil, specerr.WithPath(fmt.Errorf("invalid memory policy flag %q", specFlag), "linux", "memoryPolicy", "flags", i)
 				}
 				confMp.Flags |= confFlag
 			}
@@ -530,7 +537,7 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 			}
 			domain, err := getLinuxPersonalityFromStr(string(spec.Linux.Personality.Domain))
 			if err != nil {
-				return nil, err
+				return nil, specerr.WithPath(err, "linux", "personality", "domain")
 			}
 			config.Personality = &configs.LinuxPersonality{
 				Domain: domain,
@@ -545,6 +552,9 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 				Name: netdev.Name,
 			}
 		}
+		if err := initNetDevicesExt(spec, config.NetDevices); err != nil {
+			return nil, err
+		}
 	}
 
 	// Set the host UID that should own the container's cgroup.
@@ -614,7 +624,7 @@ func CreateLibcontainerConfig(opts *CreateOpts) (*configs.Config, error) {
 		}
 		config.ExecCPUAffinity, err = configs.ConvertCPUAffinity(spec.Process.ExecCPUAffinity)
 		if err != nil {
-			return nil, err
+			return nil, specerr.WithPath(err, "process", "execCPUAffinity")
 		}
 
 	}
@@ -741,6 +751,45 @@ func convertSecToUSec(value dbus.Variant) (dbus.Variant, error) {
 	return dbus.MakeVariant(sec), nil
 }
 
+// NetDeviceAnnotationPrefix is the prefix of the annotations setting the
+// addresses, MTU, and routes of the network devices moved to the container
+// (see [configs.LinuxNetDevice]), which the runtime spec does not provide.
+// The annotation name is the prefix followed by the device name on the host,
+// and the value is a JSON object, such as
+//
+//	{"addresses": ["192.0.2.2/24"], "mtu": 1400, "routes": [{"gateway": "192.0.2.1"}]}
+const NetDeviceAnnotationPrefix = "org.opencontainers.runc.netdevice."
+
+// netDeviceExt is the value of a [NetDeviceAnnotationPrefix] annotation.
+type netDeviceExt struct {
+	Addresses []string         `json:"addresses,omitempty"`
+	MTU       int              `json:"mtu,omitempty"`
+	Routes    []*configs.Route `json:"routes,omitempty"`
+}
+
+func initNetDevicesExt(spec *specs.Spec, netDevices map[string]*configs.LinuxNetDevice) error {
+	for k, v := range spec.Annotations {
+		name, ok := strings.CutPrefix(k, NetDeviceAnnotationPrefix)
+		if !ok {
+			continue
+		}
+		netdev, ok := netDevices[name]
+		if !ok {
+			return specerr.WithPath(fmt.Errorf("annotation %s: no network device %q", k, name), "annotations", k)
+		}
+		var ext netDeviceExt
+		dec := json.NewDecoder(strings.NewReader(v))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&ext); err != nil {
+			return specerr.WithPath(fmt.Errorf("annotation %s value parse error: %w", k, err), "annotations", k)
+		}
+		netdev.Addresses = ext.Addresses
+		netdev.MTU = ext.MTU
+		netdev.Routes = ext.Routes
+	}
+	return nil
+}
+
 func initSystemdProps(spec *specs.Spec) ([]systemdDbus.Property, error) {
 	const keyPrefix = "org.systemd.property."
 	var sp []systemdDbus.Property
@@ -1204,6 +1253,11 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 
 	newConfig := new(configs.Seccomp)
 	newConfig.Syscalls = []*configs.Syscall{}
+	// All the errors are collected, so they can be reported at once.
+	var errs []error
+	fail := func(err error, tokens ...any) {
+		errs = append(errs, specerr.WithPath(err, append([]any{"linux", "seccomp"}, tokens...)...))
+	}
 
 	// The list of flags defined in runtime-spec is a subset of the flags
 	// in the seccomp() syscall.
@@ -1216,9 +1270,10 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 		}
 	} else {
 		// Fail early if some flags are unknown or unsupported.
-		for _, flag := range config.Flags {
+		for i, flag := range config.Flags {
 			if err := seccomp.FlagSupported(flag); err != nil {
-				return nil, err
+				fail(err, "flags", i)
+				continue
 			}
 			newConfig.Flags = append(newConfig.Flags, flag)
 		}
@@ -1226,10 +1281,11 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 
 	if len(config.Architectures) > 0 {
 		newConfig.Architectures = []string{}
-		for _, arch := range config.Architectures {
+		for i, arch := range config.Architectures {
 			newArch, err := seccomp.ConvertStringToArch(string(arch))
 			if err != nil {
-				return nil, err
+				fail(err, "architectures", i)
+				continue
 			}
 			newConfig.Architectures = append(newConfig.Architectures, newArch)
 		}
@@ -1238,7 +1294,7 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 	// Convert default action from string representation
 	newDefaultAction, err := seccomp.ConvertStringToAction(string(config.DefaultAction))
 	if err != nil {
-		return nil, err
+		fail(err, "defaultAction")
 	}
 	newConfig.DefaultAction = newDefaultAction
 	newConfig.DefaultErrnoRet = config.DefaultErrnoRet
@@ -1247,13 +1303,17 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 	newConfig.ListenerMetadata = config.ListenerMetadata
 
 	// Loop through all syscall blocks and convert them to libcontainer format
-	for _, call := range config.Syscalls {
+	for i, call := range config.Syscalls {
 		newAction, err := seccomp.ConvertStringToAction(string(call.Action))
 		if err != nil {
-			return nil, err
+			fail(err, "syscalls", i, "action")
 		}
 
-		for _, name := range call.Names {
+		for j, name := range call.Names {
+			if name == "" {
+				fail(errors.New("empty string is not a valid syscall"), "syscalls", i, "names", j)
+				continue
+			}
 			newCall := configs.Syscall{
 				Name:     name,
 				Action:   newAction,
@@ -1261,10 +1321,14 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 				Args:     []*configs.Arg{},
 			}
 			// Loop through all the arguments of the syscall and convert them
-			for _, arg := range call.Args {
+			for k, arg := range call.Args {
 				newOp, err := seccomp.ConvertStringToOperator(string(arg.Op))
 				if err != nil {
-					return nil, err
+					// Only report it once, not for every name.
+					if j == 0 {
+						fail(err, "syscalls", i, "args", k, "op")
+					}
+					continue
 				}
 
 				newArg := configs.Arg{
@@ -1280,6 +1344,9 @@ func SetupSeccomp(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
 		}
 	}
 
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
 	return newConfig, nil
 }
 
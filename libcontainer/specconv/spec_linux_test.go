@@ -3,6 +3,7 @@ package specconv
 import (
 	"errors"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -1026,3 +1027,44 @@ func TestCreateNetDevices(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateNetDevicesAnnotation(t *testing.T) {
+	spec := Example()
+	spec.Linux.NetDevices = map[string]specs.LinuxNetDevice{
+		"eth1": {Name: "ctr_eth1"},
+		"eth2": {},
+	}
+	spec.Annotations = map[string]string{
+		NetDeviceAnnotationPrefix + "eth1": `{"addresses": ["192.0.2.2/24"], "mtu": 1400, "routes": [{"gateway": "192.0.2.1"}]}`,
+	}
+	config, err := CreateLibcontainerConfig(&CreateOpts{
+		CgroupName: "ContainerID",
+		Spec:       spec,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &configs.LinuxNetDevice{
+		Name:      "ctr_eth1",
+		Addresses: []string{"192.0.2.2/24"},
+		MTU:       1400,
+		Routes:    []*configs.Route{{Gateway: "192.0.2.1"}},
+	}
+	if !reflect.DeepEqual(config.NetDevices["eth1"], expected) {
+		t.Errorf("expected %+v, got %+v", expected, config.NetDevices["eth1"])
+	}
+	if !reflect.DeepEqual(config.NetDevices["eth2"], &configs.LinuxNetDevice{}) {
+		t.Errorf("expected no settings for eth2, got %+v", config.NetDevices["eth2"])
+	}
+
+	for _, annotations := range []map[string]string{
+		{NetDeviceAnnotationPrefix + "eth3": `{"mtu": 1400}`},
+		{NetDeviceAnnotationPrefix + "eth1": `{"mtu": "1400"}`},
+		{NetDeviceAnnotationPrefix + "eth1": `{"metric": 1}`},
+	} {
+		spec.Annotations = annotations
+		if _, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec}); err == nil {
+			t.Errorf("expected error for %v, got nil", annotations)
+		}
+	}
+}
@@ -0,0 +1,613 @@
+package specconv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/cgroups"
+	devices "github.com/opencontainers/cgroups/devices/config"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/seccomp"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+var rlimitNames = map[int]string{
+	unix.RLIMIT_CPU:        "RLIMIT_CPU",
+	unix.RLIMIT_FSIZE:      "RLIMIT_FSIZE",
+	unix.RLIMIT_DATA:       "RLIMIT_DATA",
+	unix.RLIMIT_STACK:      "RLIMIT_STACK",
+	unix.RLIMIT_CORE:       "RLIMIT_CORE",
+	unix.RLIMIT_RSS:        "RLIMIT_RSS",
+	unix.RLIMIT_NPROC:      "RLIMIT_NPROC",
+	unix.RLIMIT_NOFILE:     "RLIMIT_NOFILE",
+	unix.RLIMIT_MEMLOCK:    "RLIMIT_MEMLOCK",
+	unix.RLIMIT_AS:         "RLIMIT_AS",
+	unix.RLIMIT_LOCKS:      "RLIMIT_LOCKS",
+	unix.RLIMIT_SIGPENDING: "RLIMIT_SIGPENDING",
+	unix.RLIMIT_MSGQUEUE:   "RLIMIT_MSGQUEUE",
+	unix.RLIMIT_NICE:       "RLIMIT_NICE",
+	unix.RLIMIT_RTPRIO:     "RLIMIT_RTPRIO",
+	unix.RLIMIT_RTTIME:     "RLIMIT_RTTIME",
+}
+
+// ToSpec creates a specification from a given libcontainer configuration.
+// It is the inverse of [CreateLibcontainerConfig], and is used to show the
+// effective specification of a container.
+//
+// The conversion is lossy: the container process arguments, environment,
+// user and working directory are not a part of the configuration, and so
+// are not set in the returned specification. The default devices and
+// device rules added by [CreateLibcontainerConfig] are included as well.
+func ToSpec(config *configs.Config) (*specs.Spec, error) {
+	if config == nil {
+		return nil, errors.New("config cannot be nil")
+	}
+	initMaps()
+	_, annotations := utils.Annotations(config.Labels)
+	spec := &specs.Spec{
+		Version: config.Version,
+		Root: &specs.Root{
+			Path:     config.Rootfs,
+			Readonly: config.Readonlyfs,
+		},
+		Hostname:   config.Hostname,
+		Domainname: config.Domainname,
+		Linux: &specs.Linux{
+			UIDMappings:   toSpecIDMap(config.UIDMappings),
+			GIDMappings:   toSpecIDMap(config.GIDMappings),
+			Sysctl:        config.Sysctl,
+			MaskedPaths:   config.MaskPaths,
+			ReadonlyPaths: config.ReadonlyPaths,
+			MountLabel:    config.MountLabel,
+			TimeOffsets:   config.TimeOffsets,
+		},
+	}
+	if len(annotations) > 0 {
+		spec.Annotations = annotations
+	}
+	if spec.Version == "" {
+		spec.Version = specs.Version
+	}
+
+	for _, m := range config.Mounts {
+		spec.Mounts = append(spec.Mounts, toSpecMount(m))
+	}
+
+	for _, d := range config.Devices {
+		fileMode := d.FileMode
+		uid, gid := d.Uid, d.Gid
+		spec.Linux.Devices = append(spec.Linux.Devices, specs.LinuxDevice{
+			Path:     d.Path,
+			Type:     string(d.Type),
+			Major:    d.Major,
+			Minor:    d.Minor,
+			FileMode: &fileMode,
+			UID:      &uid,
+			GID:      &gid,
+		})
+	}
+
+	for _, ns := range config.Namespaces {
+		t, err := toSpecNamespaceType(ns.Type)
+		if err != nil {
+			return nil, err
+		}
+		spec.Linux.Namespaces = append(spec.Linux.Namespaces, specs.LinuxNamespace{
+			Type: t,
+			Path: ns.Path,
+		})
+	}
+
+	if config.RootPropagation != 0 {
+		for name, flag := range mountPropagationMapping {
+			if flag == config.RootPropagation {
+				spec.Linux.RootfsPropagation = name
+				break
+			}
+		}
+	}
+
+	if config.Cgroups != nil {
+		c := config.Cgroups
+		if c.Systemd {
+			if c.Parent != "" || c.ScopePrefix != "" {
+				spec.Linux.CgroupsPath = c.Parent + ":" + c.ScopePrefix + ":" + c.Name
+			}
+		} else {
+			spec.Linux.CgroupsPath = c.Path
+		}
+		if c.Resources != nil {
+			spec.Linux.Resources = toSpecResources(c.Resources)
+		}
+	}
+
+	if config.Seccomp != nil {
+		s, err := toSpecSeccomp(config.Seccomp)
+		if err != nil {
+			return nil, err
+		}
+		spec.Linux.Seccomp = s
+	}
+
+	if config.IntelRdt != nil {
+		spec.Linux.IntelRdt = &specs.LinuxIntelRdt{
+			ClosID:           config.IntelRdt.ClosID,
+			Schemata:         config.IntelRdt.Schemata,
+			L3CacheSchema:    config.IntelRdt.L3CacheSchema,
+			MemBwSchema:      config.IntelRdt.MemBwSchema,
+			EnableMonitoring: config.IntelRdt.EnableMonitoring,
+		}
+	}
+
+	if mp := config.MemoryPolicy; mp != nil {
+		policy := &specs.LinuxMemoryPolicy{Nodes: cpuSetToString(mp.Nodes)}
+		for name, mode := range mpolModeMap {
+			if mode == mp.Mode {
+				policy.Mode = specs.MemoryPolicyModeType(name)
+				break
+			}
+		}
+		if policy.Mode == "" {
+			return nil, fmt.Errorf("invalid memory policy mode %d", mp.Mode)
+		}
+		for _, name := range slices.Sorted(maps.Keys(mpolModeFMap)) {
+			if mp.Flags&mpolModeFMap[name] != 0 {
+				policy.Flags = append(policy.Flags, specs.MemoryPolicyFlagType(name))
+			}
+		}
+		spec.Linux.MemoryPolicy = policy
+	}
+
+	if config.Personality != nil {
+		var domain specs.LinuxPersonalityDomain
+		switch config.Personality.Domain {
+		case configs.PerLinux:
+			domain = specs.PerLinux
+		case configs.PerLinux32:
+			domain = specs.PerLinux32
+		default:
+			return nil, fmt.Errorf("invalid personality domain %d", config.Personality.Domain)
+		}
+		spec.Linux.Personality = &specs.LinuxPersonality{Domain: domain}
+	}
+
+	for name, netdev := range config.NetDevices {
+		if spec.Linux.NetDevices == nil {
+			spec.Linux.NetDevices = make(map[string]specs.LinuxNetDevice)
+		}
+		spec.Linux.NetDevices[name] = specs.LinuxNetDevice{Name: netdev.Name}
+		// The rest is not in the runtime spec, so it is set by an
+		// annotation, which is what CreateLibcontainerConfig reads.
+		if len(netdev.Addresses) == 0 && netdev.MTU == 0 && len(netdev.Routes) == 0 {
+			continue
+		}
+		data, err := json.Marshal(netDeviceExt{
+			Addresses: netdev.Addresses,
+			MTU:       netdev.MTU,
+			Routes:    netdev.Routes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if spec.Annotations == nil {
+			spec.Annotations = make(map[string]string)
+		}
+		spec.Annotations[NetDeviceAnnotationPrefix+name] = string(data)
+	}
+
+	p, err := toSpecProcess(config)
+	if err != nil {
+		return nil, err
+	}
+	spec.Process = p
+
+	spec.Hooks = toSpecHooks(config.Hooks)
+	return spec, nil
+}
+
+func toSpecIDMap(idmaps []configs.IDMap) []specs.LinuxIDMapping {
+	if idmaps == nil {
+		return nil
+	}
+	specMaps := make([]specs.LinuxIDMapping, len(idmaps))
+	for i, id := range idmaps {
+		specMaps[i] = specs.LinuxIDMapping{
+			ContainerID: uint32(id.ContainerID),
+			HostID:      uint32(id.HostID),
+			Size:        uint32(id.Size),
+		}
+	}
+	return specMaps
+}
+
+func toSpecNamespaceType(t configs.NamespaceType) (specs.LinuxNamespaceType, error) {
+	for specType, configType := range namespaceMapping {
+		if configType == t {
+			return specType, nil
+		}
+	}
+	return "", fmt.Errorf("namespace %q does not exist", t)
+}
+
+// toSpecMount creates a mount, with the options which [parseMountOptions]
+// parses into m.
+func toSpecMount(m *configs.Mount) specs.Mount {
+	var options []string
+	flags := m.Flags
+	if flags&unix.MS_BIND != 0 {
+		if flags&unix.MS_REC != 0 {
+			options = append(options, "rbind")
+		} else {
+			options = append(options, "bind")
+		}
+		flags &^= unix.MS_BIND | unix.MS_REC
+	}
+	for _, name := range slices.Sorted(maps.Keys(mountFlags)) {
+		f := mountFlags[name]
+		if f.flag == 0 || f.flag&(unix.MS_BIND|unix.MS_REC) != 0 {
+			continue
+		}
+		if f.clear && m.ClearedFlags&f.flag != 0 || !f.clear && flags&f.flag != 0 {
+			options = append(options, name)
+		}
+	}
+	for _, pflag := range m.PropagationFlags {
+		for name, flag := range mountPropagationMapping {
+			if flag == pflag {
+				options = append(options, name)
+				break
+			}
+		}
+	}
+	if m.RecAttr != nil {
+		options = append(options, toSpecRecAttrOptions(m.RecAttr)...)
+	}
+	for name, f := range extensionFlags {
+		if !f.clear && m.Extensions&f.flag != 0 {
+			options = append(options, name)
+		}
+	}
+
+	mount := specs.Mount{
+		Destination: m.Destination,
+		Type:        m.Device,
+		Source:      m.Source,
+	}
+	if m.IDMapping != nil {
+		if m.IDMapping.Recursive {
+			options = append(options, "ridmap")
+		} else {
+			options = append(options, "idmap")
+		}
+		mount.UIDMappings = toSpecIDMap(m.IDMapping.UIDMappings)
+		mount.GIDMappings = toSpecIDMap(m.IDMapping.GIDMappings)
+	}
+	if m.Data != "" {
+		options = append(options, strings.Split(m.Data, ",")...)
+	}
+	mount.Options = options
+	return mount
+}
+
+// toSpecRecAttrOptions returns the recursive mount options for attr.
+func toSpecRecAttrOptions(attr *unix.MountAttr) []string {
+	var options []string
+	for _, name := range slices.Sorted(maps.Keys(recAttrFlags)) {
+		f := recAttrFlags[name]
+		// The access time settings are handled below.
+		if f.flag&unix.MOUNT_ATTR__ATIME == f.flag {
+			continue
+		}
+		if f.clear && attr.Attr_clr&f.flag != 0 || !f.clear && attr.Attr_set&f.flag != 0 {
+			options = append(options, name)
+		}
+	}
+	if attr.Attr_clr&unix.MOUNT_ATTR__ATIME == unix.MOUNT_ATTR__ATIME {
+		switch attr.Attr_set & unix.MOUNT_ATTR__ATIME {
+		case unix.MOUNT_ATTR_RELATIME:
+			options = append(options, "rrelatime")
+		case unix.MOUNT_ATTR_NOATIME:
+			options = append(options, "rnoatime")
+		case unix.MOUNT_ATTR_STRICTATIME:
+			options = append(options, "rstrictatime")
+		}
+	}
+	return options
+}
+
+func toSpecResources(r *cgroups.Resources) *specs.LinuxResources {
+	res := &specs.LinuxResources{}
+	for _, d := range r.Devices {
+		rule := specs.LinuxDeviceCgroup{
+			Allow:  d.Allow,
+			Type:   string(d.Type),
+			Access: string(d.Permissions),
+		}
+		if d.Major != devices.Wildcard {
+			major := d.Major
+			rule.Major = &major
+		}
+		if d.Minor != devices.Wildcard {
+			minor := d.Minor
+			rule.Minor = &minor
+		}
+		res.Devices = append(res.Devices, rule)
+	}
+
+	if r.Memory != 0 || r.MemoryReservation != 0 || r.MemorySwap != 0 ||
+		r.MemorySwappiness != nil || r.OomKillDisable || r.MemoryCheckBeforeUpdate {
+		res.Memory = &specs.LinuxMemory{
+			Limit:       nonZero(r.Memory),
+			Reservation: nonZero(r.MemoryReservation),
+			Swap:        nonZero(r.MemorySwap),
+			Swappiness:  r.MemorySwappiness,
+		}
+		if r.OomKillDisable {
+			res.Memory.DisableOOMKiller = &r.OomKillDisable
+		}
+		if r.MemoryCheckBeforeUpdate {
+			res.Memory.CheckBeforeUpdate = &r.MemoryCheckBeforeUpdate
+		}
+	}
+
+	if r.CpuShares != 0 || r.CpuQuota != 0 || r.CpuBurst != nil || r.CpuPeriod != 0 ||
+		r.CpuRtRuntime != 0 || r.CpuRtPeriod != 0 || r.CpusetCpus != "" || r.CpusetMems != "" || r.CPUIdle != nil {
+		res.CPU = &specs.LinuxCPU{
+			Shares:          nonZero(r.CpuShares),
+			Quota:           nonZero(r.CpuQuota),
+			Burst:           r.CpuBurst,
+			Period:          nonZero(r.CpuPeriod),
+			RealtimeRuntime: nonZero(r.CpuRtRuntime),
+			RealtimePeriod:  nonZero(r.CpuRtPeriod),
+			Cpus:            r.CpusetCpus,
+			Mems:            r.CpusetMems,
+			Idle:            r.CPUIdle,
+		}
+	}
+
+	if r.PidsLimit != nil {
+		res.Pids = &specs.LinuxPids{Limit: r.PidsLimit}
+	}
+
+	if r.BlkioWeight != 0 || r.BlkioLeafWeight != 0 || len(r.BlkioWeightDevice) > 0 ||
+		len(r.BlkioThrottleReadBpsDevice) > 0 || len(r.BlkioThrottleWriteBpsDevice) > 0 ||
+		len(r.BlkioThrottleReadIOPSDevice) > 0 || len(r.BlkioThrottleWriteIOPSDevice) > 0 {
+		blkio := &specs.LinuxBlockIO{
+			Weight:     nonZero(r.BlkioWeight),
+			LeafWeight: nonZero(r.BlkioLeafWeight),
+		}
+		for _, wd := range r.BlkioWeightDevice {
+			d := specs.LinuxWeightDevice{
+				Weight:     nonZero(wd.Weight),
+				LeafWeight: nonZero(wd.LeafWeight),
+			}
+			d.Major, d.Minor = wd.Major, wd.Minor
+			blkio.WeightDevice = append(blkio.WeightDevice, d)
+		}
+		blkio.ThrottleReadBpsDevice = toSpecThrottleDevices(r.BlkioThrottleReadBpsDevice)
+		blkio.ThrottleWriteBpsDevice = toSpecThrottleDevices(r.BlkioThrottleWriteBpsDevice)
+		blkio.ThrottleReadIOPSDevice = toSpecThrottleDevices(r.BlkioThrottleReadIOPSDevice)
+		blkio.ThrottleWriteIOPSDevice = toSpecThrottleDevices(r.BlkioThrottleWriteIOPSDevice)
+		res.BlockIO = blkio
+	}
+
+	for _, l := range r.HugetlbLimit {
+		res.HugepageLimits = append(res.HugepageLimits, specs.LinuxHugepageLimit{
+			Pagesize: l.Pagesize,
+			Limit:    l.Limit,
+		})
+	}
+
+	if len(r.Rdma) > 0 {
+		res.Rdma = make(map[string]specs.LinuxRdma, len(r.Rdma))
+		for k, v := range r.Rdma {
+			res.Rdma[k] = specs.LinuxRdma{
+				HcaHandles: v.HcaHandles,
+				HcaObjects: v.HcaObjects,
+			}
+		}
+	}
+
+	if r.NetClsClassid != 0 || len(r.NetPrioIfpriomap) > 0 {
+		res.Network = &specs.LinuxNetwork{ClassID: nonZero(r.NetClsClassid)}
+		for _, m := range r.NetPrioIfpriomap {
+			res.Network.Priorities = append(res.Network.Priorities, specs.LinuxInterfacePriority{
+				Name:     m.Interface,
+				Priority: uint32(m.Priority),
+			})
+		}
+	}
+
+	if len(r.Unified) > 0 {
+		res.Unified = maps.Clone(r.Unified)
+	}
+	return res
+}
+
+func toSpecThrottleDevices(tds []*cgroups.ThrottleDevice) []specs.LinuxThrottleDevice {
+	var res []specs.LinuxThrottleDevice
+	for _, td := range tds {
+		d := specs.LinuxThrottleDevice{Rate: td.Rate}
+		d.Major, d.Minor = td.Major, td.Minor
+		res = append(res, d)
+	}
+	return res
+}
+
+// nonZero returns a pointer to a copy of v, or nil if v is zero.
+func nonZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+// toSpecSeccomp creates a seccomp configuration from s. The consecutive
+// rules which only differ in the syscall name are merged into one.
+func toSpecSeccomp(s *configs.Seccomp) (*specs.LinuxSeccomp, error) {
+	defaultAction, err := seccomp.ConvertActionToString(s.DefaultAction)
+	if err != nil {
+		return nil, err
+	}
+	res := &specs.LinuxSeccomp{
+		DefaultAction:    specs.LinuxSeccompAction(defaultAction),
+		DefaultErrnoRet:  s.DefaultErrnoRet,
+		Flags:            s.Flags,
+		ListenerPath:     s.ListenerPath,
+		ListenerMetadata: s.ListenerMetadata,
+	}
+	for _, arch := range s.Architectures {
+		name, err := seccomp.ConvertArchToString(arch)
+		if err != nil {
+			return nil, err
+		}
+		res.Architectures = append(res.Architectures, specs.Arch(name))
+	}
+	for _, call := range s.Syscalls {
+		action, err := seccomp.ConvertActionToString(call.Action)
+		if err != nil {
+			return nil, err
+		}
+		syscall := specs.LinuxSyscall{
+			Names:    []string{call.Name},
+			Action:   specs.LinuxSeccompAction(action),
+			ErrnoRet: call.ErrnoRet,
+		}
+		for _, arg := range call.Args {
+			op, err := seccomp.ConvertOperatorToString(arg.Op)
+			if err != nil {
+				return nil, err
+			}
+			syscall.Args = append(syscall.Args, specs.LinuxSeccompArg{
+				Index:    arg.Index,
+				Value:    arg.Value,
+				ValueTwo: arg.ValueTwo,
+				Op:       specs.LinuxSeccompOperator(op),
+			})
+		}
+		if n := len(res.Syscalls); n > 0 && sameSyscallRule(&res.Syscalls[n-1], &syscall) {
+			res.Syscalls[n-1].Names = append(res.Syscalls[n-1].Names, call.Name)
+			continue
+		}
+		res.Syscalls = append(res.Syscalls, syscall)
+	}
+	return res, nil
+}
+
+// sameSyscallRule reports whether a and b only differ in the syscall names.
+func sameSyscallRule(a, b *specs.LinuxSyscall) bool {
+	if a.Action != b.Action || !slices.Equal(a.Args, b.Args) {
+		return false
+	}
+	if a.ErrnoRet == nil || b.ErrnoRet == nil {
+		return a.ErrnoRet == b.ErrnoRet
+	}
+	return *a.ErrnoRet == *b.ErrnoRet
+}
+
+func toSpecProcess(config *configs.Config) (*specs.Process, error) {
+	p := &specs.Process{
+		OOMScoreAdj:     config.OomScoreAdj,
+		NoNewPrivileges: config.NoNewPrivileges,
+		ApparmorProfile: config.AppArmorProfile,
+		SelinuxLabel:    config.ProcessLabel,
+		Scheduler:       config.Scheduler,
+		IOPriority:      config.IOPriority,
+	}
+	p.User.Umask = config.Umask
+	if c := config.Capabilities; c != nil {
+		p.Capabilities = &specs.LinuxCapabilities{
+			Bounding:    c.Bounding,
+			Effective:   c.Effective,
+			Permitted:   c.Permitted,
+			Inheritable: c.Inheritable,
+			Ambient:     c.Ambient,
+		}
+	}
+	for _, rl := range config.Rlimits {
+		name, ok := rlimitNames[rl.Type]
+		if !ok {
+			return nil, fmt.Errorf("wrong rlimit type: %d", rl.Type)
+		}
+		p.Rlimits = append(p.Rlimits, specs.POSIXRlimit{
+			Type: name,
+			Hard: rl.Hard,
+			Soft: rl.Soft,
+		})
+	}
+	if a := config.ExecCPUAffinity; a != nil {
+		p.ExecCPUAffinity = &specs.CPUAffinity{
+			Initial: cpuSetToString(a.Initial),
+			Final:   cpuSetToString(a.Final),
+		}
+	}
+	return p, nil
+}
+
+func toSpecHooks(hooks configs.Hooks) *specs.Hooks {
+	if len(hooks) == 0 {
+		return nil
+	}
+	res := &specs.Hooks{}
+	for name, list := range map[configs.HookName]*[]specs.Hook{
+		configs.Prestart:        &res.Prestart, //nolint:staticcheck // Ignore SA1019. Need to keep deprecated package for compatibility.
+		configs.CreateRuntime:   &res.CreateRuntime,
+		configs.CreateContainer: &res.CreateContainer,
+		configs.StartContainer:  &res.StartContainer,
+		configs.Poststart:       &res.Poststart,
+		configs.Poststop:        &res.Poststop,
+	} {
+		for _, h := range hooks[name] {
+			// Only the command hooks can be represented in a spec.
+			ch, ok := h.(configs.CommandHook)
+			if !ok {
+				continue
+			}
+			hook := specs.Hook{
+				Path: ch.Path,
+				Args: ch.Args,
+				Env:  ch.Env,
+			}
+			if ch.Timeout != nil {
+				timeout := int(ch.Timeout.Seconds())
+				hook.Timeout = &timeout
+			}
+			*list = append(*list, hook)
+		}
+	}
+	return res
+}
+
+// cpuSetToString returns set in the list format (e.g. "0-3,5,7-9"),
+// which is the inverse of [configs.ToCPUSet].
+func cpuSetToString(set unix.CPUSetDynamic) string {
+	var ranges []string
+	start := -1
+	for cpu, left := 0, set.Count(); left > 0 || start >= 0; cpu++ {
+		if set.IsSet(cpu) {
+			left--
+			if start < 0 {
+				start = cpu
+			}
+			continue
+		}
+		if start >= 0 {
+			if start == cpu-1 {
+				ranges = append(ranges, strconv.Itoa(start))
+			} else {
+				ranges = append(ranges, strconv.Itoa(start)+"-"+strconv.Itoa(cpu-1))
+			}
+			start = -1
+		}
+	}
+	return strings.Join(ranges, ",")
+}
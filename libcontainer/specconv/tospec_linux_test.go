@@ -0,0 +1,174 @@
+package specconv
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+func TestToSpec(t *testing.T) {
+	spec := Example()
+	spec.Root.Path = "/"
+	spec.Hostname = "host"
+	spec.Annotations = map[string]string{"key": "value"}
+	spec.Mounts = append(spec.Mounts,
+		specs.Mount{
+			Destination: "/data",
+			Type:        "bind",
+			Source:      "/tmp",
+			Options:     []string{"rbind", "ro", "nosuid", "rslave", "rnoexec", "rnoatime"},
+		},
+		specs.Mount{
+			Destination: "/scratch",
+			Type:        "tmpfs",
+			Source:      "tmpfs",
+			Options:     []string{"rw", "tmpcopyup", "size=64k", "mode=755"},
+		},
+	)
+	limit, quota, period, pids := int64(1<<20), int64(50000), uint64(100000), int64(10)
+	spec.Linux.Resources.Memory = &specs.LinuxMemory{Limit: &limit}
+	spec.Linux.Resources.CPU = &specs.LinuxCPU{Quota: &quota, Period: &period, Cpus: "0-1"}
+	spec.Linux.Resources.Pids = &specs.LinuxPids{Limit: &pids}
+	errno := uint(38)
+	spec.Linux.Seccomp = &specs.LinuxSeccomp{
+		DefaultAction: specs.ActAllow,
+		Architectures: []specs.Arch{specs.ArchX86_64},
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:    []string{"kexec_load", "reboot"},
+				Action:   specs.ActErrno,
+				ErrnoRet: &errno,
+			},
+			{
+				Names:  []string{"personality"},
+				Action: specs.ActKill,
+				Args: []specs.LinuxSeccompArg{
+					{Index: 0, Value: 8, Op: specs.OpNotEqual},
+				},
+			},
+		},
+	}
+	timeout := 5
+	spec.Hooks = &specs.Hooks{
+		CreateRuntime: []specs.Hook{{Path: "/bin/hook", Args: []string{"hook", "create"}, Timeout: &timeout}},
+		Poststop:      []specs.Hook{{Path: "/bin/hook", Args: []string{"hook", "stop"}}},
+	}
+
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Rlimits = []configs.Rlimit{{Type: unix.RLIMIT_NOFILE, Hard: 1024, Soft: 512}}
+
+	got, err := ToSpec(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Hostname != "host" || !reflect.DeepEqual(got.Annotations, spec.Annotations) {
+		t.Errorf("unexpected hostname %q or annotations %v", got.Hostname, got.Annotations)
+	}
+	if !slices.Equal(got.Process.Capabilities.Bounding, spec.Process.Capabilities.Bounding) {
+		t.Errorf("expected bounding capabilities %v, got %v", spec.Process.Capabilities.Bounding, got.Process.Capabilities.Bounding)
+	}
+	expRlimits := []specs.POSIXRlimit{{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 512}}
+	if !slices.Equal(got.Process.Rlimits, expRlimits) {
+		t.Errorf("expected rlimits %+v, got %+v", expRlimits, got.Process.Rlimits)
+	}
+	if n := len(got.Linux.Seccomp.Syscalls); n != 2 {
+		t.Errorf("expected 2 seccomp rules, got %d", n)
+	} else if names := got.Linux.Seccomp.Syscalls[0].Names; !slices.Equal(names, []string{"kexec_load", "reboot"}) {
+		t.Errorf("expected merged seccomp rule names, got %v", names)
+	}
+
+	// Converting the spec back must give the same configuration.
+	config2, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: got})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		name      string
+		exp, got  any
+		jsonCheck bool
+	}{
+		{"mounts", config.Mounts, config2.Mounts, false},
+		{"devices", devicePaths(config), devicePaths(config2), false},
+		{"namespaces", config.Namespaces, config2.Namespaces, false},
+		{"capabilities", config.Capabilities, config2.Capabilities, false},
+		{"seccomp", config.Seccomp, config2.Seccomp, false},
+		{"mask paths", config.MaskPaths, config2.MaskPaths, false},
+		{"readonly paths", config.ReadonlyPaths, config2.ReadonlyPaths, false},
+		{"hooks", config.Hooks, config2.Hooks, true},
+		{"memory", config.Cgroups.Memory, config2.Cgroups.Memory, false},
+		{"cpu quota", config.Cgroups.CpuQuota, config2.Cgroups.CpuQuota, false},
+		{"cpus", config.Cgroups.CpusetCpus, config2.Cgroups.CpusetCpus, false},
+		{"pids", config.Cgroups.PidsLimit, config2.Cgroups.PidsLimit, false},
+	} {
+		exp, got := tc.exp, tc.got
+		if tc.jsonCheck {
+			e, _ := json.Marshal(exp)
+			g, _ := json.Marshal(got)
+			exp, got = string(e), string(g)
+		}
+		if !reflect.DeepEqual(exp, got) {
+			t.Errorf("%s: expected %+v, got %+v", tc.name, exp, got)
+		}
+	}
+}
+
+func TestToSpecNetDevices(t *testing.T) {
+	spec := Example()
+	spec.Linux.NetDevices = map[string]specs.LinuxNetDevice{
+		"eth1": {Name: "ctr_eth1"},
+		"eth2": {},
+	}
+	config, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: spec})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Set the rest directly, so it is not carried over by the annotations.
+	netdev := config.NetDevices["eth1"]
+	netdev.Addresses = []string{"192.0.2.2/24", "2001:db8::2/64"}
+	netdev.MTU = 1400
+	netdev.Routes = []*configs.Route{{Gateway: "192.0.2.1"}, {Destination: "198.51.100.0/24", Gateway: "192.0.2.254", Metric: 10}}
+
+	got, err := ToSpec(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config2, err := CreateLibcontainerConfig(&CreateOpts{CgroupName: "ContainerID", Spec: got})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(config.NetDevices, config2.NetDevices) {
+		t.Errorf("expected network devices %+v, got %+v", config.NetDevices, config2.NetDevices)
+	}
+	if _, ok := got.Annotations[NetDeviceAnnotationPrefix+"eth2"]; ok {
+		t.Error("unexpected annotation for eth2")
+	}
+}
+
+func devicePaths(config *configs.Config) []string {
+	var paths []string
+	for _, d := range config.Devices {
+		paths = append(paths, d.Path)
+	}
+	return paths
+}
+
+func TestCPUSetToString(t *testing.T) {
+	for _, str := range []string{"", "0", "0-3", "0-3,5,7-9", "1,3,5", "63-65"} {
+		set, err := configs.ToCPUSet(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := cpuSetToString(set); got != str {
+			t.Errorf("expected %q, got %q", str, got)
+		}
+	}
+}
@@ -114,7 +114,7 @@ func (l *linuxStandardInit) Init() error {
 
 	// Finish the rootfs setup.
 	if l.config.Config.Namespaces.Contains(configs.NEWNS) {
-		if err := finalizeRootfs(l.config.Config); err != nil {
+		if err := finalizeRootfs(l.config.Config, nil); err != nil {
 			return err
 		}
 	}
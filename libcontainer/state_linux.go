@@ -57,6 +57,19 @@ func destroy(c *Container) error {
 			return fmt.Errorf("unable to remove container's IntelRDT group: %w", err)
 		}
 	}
+	if err := destroyNetworks(c.config); err != nil {
+		return fmt.Errorf("unable to remove container's network interfaces: %w", err)
+	}
+	restoreNetDevices(c.config, c.netDevices)
+	c.netDevices = nil
+	pid := 0
+	if c.initProcess != nil {
+		pid = c.initProcess.pid()
+	}
+	c.publishEvent(EventDeleted, pid, nil)
+	if err := c.store.Delete(c.id); err != nil {
+		return fmt.Errorf("unable to remove container state: %w", err)
+	}
 	if err := os.RemoveAll(c.stateDir); err != nil {
 		return fmt.Errorf("unable to remove container state dir: %w", err)
 	}
@@ -0,0 +1,64 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StateSchemaVersion is the version of the schema of the persisted container
+// state ([State], including the container configuration) written by this
+// version of libcontainer.
+//
+// It must be incremented, and a migration added to stateMigrations, whenever
+// a change to the state makes the states persisted by the older versions
+// load incorrectly, such as a field being renamed or changing its meaning.
+const StateSchemaVersion = 1
+
+// stateMigrations[v] migrates the persisted state from the schema version v
+// to v+1. The state is given as a JSON object, rather than a [State], so that
+// the renamed or removed fields can be handled.
+var stateMigrations = [StateSchemaVersion]func(state map[string]json.RawMessage) error{
+	// Version 0 is the state persisted before the schema version was
+	// introduced. Version 1 only adds the version itself.
+	0: func(map[string]json.RawMessage) error { return nil },
+}
+
+// migrateState migrates the persisted state data to [StateSchemaVersion].
+// It returns an error wrapping [ErrStateVersion] if the state was persisted
+// with an unknown schema version, such as by a newer version of libcontainer.
+func migrateState(data []byte) ([]byte, error) {
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	version := 0
+	if v, ok := state["schema_version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			return nil, fmt.Errorf("invalid state schema version: %w", err)
+		}
+	}
+	if version < 0 || version > StateSchemaVersion {
+		return nil, fmt.Errorf("%w %d (the supported version is %d or older; was the container created by a newer runc?)", ErrStateVersion, version, StateSchemaVersion)
+	}
+	if version == StateSchemaVersion {
+		return data, nil
+	}
+	for ; version < StateSchemaVersion; version++ {
+		if err := stateMigrations[version](state); err != nil {
+			return nil, fmt.Errorf("unable to migrate state from schema version %d: %w", version, err)
+		}
+	}
+	state["schema_version"], _ = json.Marshal(StateSchemaVersion)
+	return json.Marshal(state)
+}
+
+// syncDir makes the changes to the entries of the directory durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,193 @@
+package libcontainer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// StateStore persists the container states, and provides the per-container
+// locks serializing the state-changing operations on the containers.
+//
+// The default StateStore is [DirStateStore]. Note that, regardless of the
+// StateStore used, the container runtime files (such as the exec fifo and
+// the lifecycle events log) are kept in the container directory under the
+// root directory given to [Create] and [Load].
+type StateStore interface {
+	// Save persists the state of the container with the given id,
+	// atomically replacing the previously saved one, if any.
+	Save(id string, state *State) error
+	// Load returns the saved state of the container with the given id,
+	// or an error wrapping [ErrNotExist] if there is none.
+	Load(id string) (*State, error)
+	// Delete removes the saved state of the container with the given id.
+	// Deleting a non-existent state is not an error.
+	Delete(id string) error
+	// List returns the ids of the containers with a saved state.
+	List() ([]string, error)
+	// Lock acquires the lock of the container with the given id, which is
+	// exclusive or shared, waiting for up to [LockTimeout] and failing with
+	// an error wrapping [ErrLocked] if it can not be acquired. The lock is
+	// released by closing the returned Closer.
+	//
+	// Lock must succeed for a container with no saved state (that is, being
+	// created), and fail with an error wrapping [ErrNotExist] if the state
+	// of the container is deleted while waiting for the lock.
+	Lock(id string, exclusive bool) (io.Closer, error)
+}
+
+// Option is an optional setting of [Create] and [Load].
+type Option func(*options)
+
+type options struct {
+	store StateStore
+}
+
+// WithStateStore sets the [StateStore] to persist the container state with.
+// The default is a [DirStateStore] of the root directory.
+func WithStateStore(store StateStore) Option {
+	return func(o *options) {
+		o.store = store
+	}
+}
+
+func newOptions(root string, opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = NewDirStateStore(root)
+	}
+	return o
+}
+
+// DirStateStore is a [StateStore] which keeps the state of each container in
+// the state.json file in the container directory under the root directory,
+// and uses an flock(2) on the state.lock file in the same directory as the
+// container lock.
+type DirStateStore struct {
+	root string
+}
+
+// NewDirStateStore returns a [DirStateStore] with the given root directory.
+func NewDirStateStore(root string) *DirStateStore {
+	return &DirStateStore{root: root}
+}
+
+func (s *DirStateStore) dir(id string) (string, error) {
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+	return securejoin.SecureJoin(s.root, id)
+}
+
+// Save implements [StateStore.Save]. The container directory must exist.
+func (s *DirStateStore) Save(id string, state *State) (retErr error) {
+	dir, err := s.dir(id)
+	if err != nil {
+		return err
+	}
+	tmpFile, err := os.CreateTemp(dir, "state-")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if retErr != nil {
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
+		}
+	}()
+
+	err = utils.WriteJSON(tmpFile, state)
+	if err != nil {
+		return err
+	}
+	// Make sure the new state is on disk before it replaces the old one,
+	// so that the state file is never left empty or truncated after a
+	// crash.
+	err = tmpFile.Sync()
+	if err != nil {
+		return err
+	}
+	err = tmpFile.Close()
+	if err != nil {
+		return err
+	}
+
+	stateFilePath := filepath.Join(dir, stateFilename)
+	if err := os.Rename(tmpFile.Name(), stateFilePath); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// Load implements [StateStore.Load].
+func (s *DirStateStore) Load(id string) (*State, error) {
+	dir, err := s.dir(id)
+	if err != nil {
+		return nil, err
+	}
+	return loadState(dir)
+}
+
+// Delete implements [StateStore.Delete]. It leaves the container directory
+// itself in place.
+func (s *DirStateStore) Delete(id string) error {
+	dir, err := s.dir(id)
+	if err != nil {
+		return err
+	}
+	// The lock file is removed last, so that the waiters for the lock find
+	// the state gone once they get it.
+	for _, name := range []string{stateFilename, lockFilename} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List implements [StateStore.List]. A non-existent root directory is
+// treated as an empty one.
+func (s *DirStateStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() || validateID(e.Name()) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(s.root, e.Name(), stateFilename)); err != nil {
+			// No state yet (or any more).
+			continue
+		}
+		ids = append(ids, e.Name())
+	}
+	return ids, nil
+}
+
+// Lock implements [StateStore.Lock]. The container directory must exist.
+func (s *DirStateStore) Lock(id string, exclusive bool) (io.Closer, error) {
+	dir, err := s.dir(id)
+	if err != nil {
+		return nil, err
+	}
+	how := unix.LOCK_SH
+	if exclusive {
+		how = unix.LOCK_EX
+	}
+	return lockStateDir(dir, how, LockTimeout)
+}
@@ -0,0 +1,201 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/opencontainers/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// memStateStore is an in-memory StateStore.
+type memStateStore struct {
+	mu     sync.Mutex
+	states map[string][]byte
+	locks  map[string]*sync.RWMutex
+}
+
+func newMemStateStore() *memStateStore {
+	return &memStateStore{
+		states: make(map[string][]byte),
+		locks:  make(map[string]*sync.RWMutex),
+	}
+}
+
+func (s *memStateStore) Save(id string, state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[id] = data
+	return nil
+}
+
+func (s *memStateStore) Load(id string) (*State, error) {
+	s.mu.Lock()
+	data, ok := s.states[id]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotExist
+	}
+	var state *State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func (s *memStateStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.states, id)
+	return nil
+}
+
+func (s *memStateStore) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.states {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids, nil
+}
+
+type unlockFunc func()
+
+func (f unlockFunc) Close() error {
+	f()
+	return nil
+}
+
+func (s *memStateStore) Lock(id string, exclusive bool) (io.Closer, error) {
+	s.mu.Lock()
+	l, ok := s.locks[id]
+	if !ok {
+		l = &sync.RWMutex{}
+		s.locks[id] = l
+	}
+	s.mu.Unlock()
+	if exclusive {
+		l.Lock()
+		return unlockFunc(l.Unlock), nil
+	}
+	l.RLock()
+	return unlockFunc(l.RUnlock), nil
+}
+
+func TestDirStateStore(t *testing.T) {
+	root := t.TempDir()
+	store := NewDirStateStore(root)
+	if err := os.Mkdir(filepath.Join(root, "c1"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	// A container being created, with no state yet.
+	if err := os.Mkdir(filepath.Join(root, "c2"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("c1"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+
+	lock, err := store.Lock("c1", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &State{
+		SchemaVersion: StateSchemaVersion,
+		BaseState: BaseState{
+			ID:             "c1",
+			InitProcessPid: 1024,
+			Config:         configs.Config{Cgroups: &cgroups.Cgroup{}},
+		},
+	}
+	if err := store.Save("c1", s); err != nil {
+		t.Fatal(err)
+	}
+	lock.Close()
+	entries, err := os.ReadDir(filepath.Join(root, "c1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if e.Name() != stateFilename && e.Name() != lockFilename {
+			t.Errorf("unexpected file %s left in the container directory", e.Name())
+		}
+	}
+
+	state, err := store.Load("c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.ID != "c1" || state.InitProcessPid != 1024 || state.SchemaVersion != StateSchemaVersion {
+		t.Errorf("unexpected state %+v", state)
+	}
+	ids, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(ids, []string{"c1"}) {
+		t.Errorf("expected [c1], got %v", ids)
+	}
+
+	if err := store.Delete("c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("c1"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	if err := store.Delete("c1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("../c1"); !errors.Is(err, ErrInvalidID) {
+		t.Fatalf("expected ErrInvalidID, got %v", err)
+	}
+}
+
+func TestLoadWithStateStore(t *testing.T) {
+	root := t.TempDir()
+	store := newMemStateStore()
+	if _, err := Load(root, "c1", WithStateStore(store)); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("expected ErrNotExist, got %v", err)
+	}
+	s := &State{
+		SchemaVersion: StateSchemaVersion,
+		BaseState: BaseState{
+			ID:             "c1",
+			InitProcessPid: 1024,
+			Config: configs.Config{
+				Rootfs:  "/mycontainer/root",
+				Cgroups: &cgroups.Cgroup{},
+			},
+		},
+	}
+	if err := store.Save("c1", s); err != nil {
+		t.Fatal(err)
+	}
+	container, err := Load(root, "c1", WithStateStore(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if container.Config().Rootfs != "/mycontainer/root" {
+		t.Fatalf("expected rootfs /mycontainer/root, got %q", container.Config().Rootfs)
+	}
+	// Nothing is persisted in the root directory.
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected an empty root directory, got %v", entries)
+	}
+}
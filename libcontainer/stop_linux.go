@@ -0,0 +1,99 @@
+package libcontainer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/opencontainers/cgroups"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// Stop gracefully stops the container. It sends the signal s to the
+// container's init (or to all the container processes, if the container
+// does not have its own PID namespace), and waits for init to exit. If it
+// has not exited after timeout, all the container processes are killed
+// with SIGKILL. A negative timeout means to wait indefinitely.
+//
+// A paused container is resumed after sending s, so it can handle it.
+//
+// The exit status of init is returned, as by [Container.Wait]. If the
+// container is already stopped, Stop only returns its exit status.
+func (c *Container) Stop(s os.Signal, timeout time.Duration) (*ExitStatus, error) {
+	c.m.Lock()
+	err := c.signalStop(s)
+	c.m.Unlock()
+	if err != nil {
+		if errors.Is(err, ErrNotRunning) {
+			return c.Wait()
+		}
+		return nil, err
+	}
+
+	type result struct {
+		status *ExitStatus
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		status, err := c.Wait()
+		done <- result{status, err}
+	}()
+	if timeout >= 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case r := <-done:
+			return r.status, r.err
+		case <-timer.C:
+		}
+		logrus.Debugf("container %s has not stopped in %s, killing it", c.id, timeout)
+		c.m.Lock()
+		err := c.signal(unix.SIGKILL)
+		c.m.Unlock()
+		if err != nil && !errors.Is(err, ErrNotRunning) {
+			return nil, err
+		}
+	}
+	r := <-done
+	return r.status, r.err
+}
+
+// signalStop sends s to init, or to all processes if the PID namespace is
+// shared, and thaws the container if it is paused.
+func (c *Container) signalStop(s os.Signal) error {
+	if s == unix.SIGKILL {
+		// Handles both cases, and thaws the container.
+		return c.signal(s)
+	}
+	if c.config.Namespaces.IsPrivate(configs.NEWPID) {
+		if err := c.signalInit(s); err != nil {
+			return err
+		}
+	} else {
+		sig, ok := s.(unix.Signal)
+		if !ok {
+			return fmt.Errorf("unsupported signal %v", s)
+		}
+		if !c.hasInit() {
+			return ErrNotRunning
+		}
+		if err := signalAllProcesses(c.cgroupManager, sig); err != nil {
+			if !c.config.RootlessCgroups {
+				return fmt.Errorf("unable to signal all processes: %w", err)
+			}
+			logrus.WithError(err).Warn("failed to signal all processes, possibly due to lack of cgroup (Hint: enable cgroup v2 delegation)")
+			if err := c.signalInit(s); err != nil {
+				return err
+			}
+		}
+	}
+	if paused, _ := c.isPaused(); paused {
+		_ = c.cgroupManager.Freeze(cgroups.Thawed)
+	}
+	return nil
+}
@@ -62,9 +62,26 @@ type Stat_t struct {
 	// State is the state of the process.
 	State State
 
+	// PPid is the PID of the parent process.
+	PPid int
+
+	// UTime and STime are the amount of time the process has been
+	// scheduled in user and kernel mode, in clock ticks.
+	UTime, STime uint64
+
 	// StartTime is the number of clock ticks after system boot (since
 	// Linux 2.6).
 	StartTime uint64
+
+	// RSS is the number of pages the process has in real memory.
+	RSS uint64
+
+	// ExitCode is the exit status of the process in the form reported by
+	// waitpid(2), if the process is a zombie (since Linux 3.5).
+	ExitCode int
+
+	// HasExitCode is set if ExitCode is reported by the kernel.
+	HasExitCode bool
 }
 
 // Stat returns a Stat_t instance for the specified process.
@@ -93,7 +110,11 @@ func parseStat(data string) (stat Stat_t, err error) {
 	//  * field 2: process name. It is the only field enclosed into
 	//    parenthesis, as it can contain spaces (and parenthesis) inside.
 	//  * field 3: process state, a single character (%c)
+	//  * field 4: parent PID, an integer (%d).
+	//  * fields 14 and 15: user and system time, unsigned longs (%lu).
 	//  * field 22: process start time, a long unsigned integer (%llu).
+	//  * field 24: resident set size, a long (%ld).
+	//  * field 52: exit code, an integer (%d), only present since Linux 3.5.
 
 	// 1. Look for the first '(' and the last ')' first, what's in between is Name.
 	//    We expect at least 20 fields and a space after the last one.
@@ -116,13 +137,26 @@ func parseStat(data string) (stat Stat_t, err error) {
 	data = data[last+2:]
 	stat.State = State(data[0])
 
-	// 3. StartTime is field 22, data is at field 3 now, so we need to skip 19 spaces.
-	skipSpaces := 22 - 3
-	for first = 0; skipSpaces > 0 && first < len(data); first++ {
+	// 3. StartTime is field 22, data is at field 3 now, so we need to skip
+	// 19 spaces, remembering the fields we skip.
+	var fields [22 - 3]string
+	n, start := 0, 0
+	for first = 0; n < len(fields) && first < len(data); first++ {
 		if data[first] == ' ' {
-			skipSpaces--
+			fields[n] = data[start:first]
+			n++
+			start = first + 1
 		}
 	}
+	if stat.PPid, err = strconv.Atoi(fields[4-3]); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad ppid): %w", err)
+	}
+	if stat.UTime, err = strconv.ParseUint(fields[14-3], 10, 64); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad utime): %w", err)
+	}
+	if stat.STime, err = strconv.ParseUint(fields[15-3], 10, 64); err != nil {
+		return stat, fmt.Errorf("invalid stat data (bad stime): %w", err)
+	}
 	// Now first points to StartTime; look for space right after.
 	i := strings.IndexByte(data[first:], ' ')
 	if i < 0 {
@@ -133,5 +167,135 @@ func parseStat(data string) (stat Stat_t, err error) {
 		return stat, fmt.Errorf("invalid stat data (bad start time): %w", err)
 	}
 
+	// 4. RSS is field 24, if present. The kernel reports a signed value,
+	// which is never negative in practice.
+	data = strings.TrimSpace(data[first+i:])
+	if _, rest, ok := strings.Cut(data, " "); ok {
+		rss, _, _ := strings.Cut(rest, " ")
+		if stat.RSS, err = strconv.ParseUint(rss, 10, 64); err != nil {
+			return stat, fmt.Errorf("invalid stat data (bad rss): %w", err)
+		}
+	}
+
+	// 5. ExitCode is field 52, if present. Data is at field 23 now, and
+	// newer kernels may add more fields after it.
+	if rest := strings.Fields(data); len(rest) > 52-23 {
+		if stat.ExitCode, err = strconv.Atoi(rest[52-23]); err != nil {
+			return stat, fmt.Errorf("invalid stat data (bad exit code): %w", err)
+		}
+		stat.HasExitCode = true
+	}
+
 	return stat, nil
 }
+
+// Status_t represents some of the information from /proc/[pid]/status,
+// as described in proc(5).
+type Status_t struct {
+	// Uid and Gid are the real, effective, saved set, and filesystem user
+	// and group IDs of the process, as seen from the user namespace of the
+	// reader.
+	Uid, Gid [4]uint32
+
+	// NSpid is the PID of the process in each of the PID namespaces it is a
+	// member of, from the reader's one to the innermost one (since Linux 4.1).
+	NSpid []int
+
+	// CapInh, CapPrm, CapEff, CapBnd, and CapAmb are the inheritable,
+	// permitted, effective, bounding, and ambient capability sets.
+	CapInh, CapPrm, CapEff, CapBnd, CapAmb uint64
+
+	// Seccomp is the seccomp mode of the process: 0 (disabled), 1 (strict),
+	// or 2 (filter).
+	Seccomp int
+}
+
+// Status returns a Status_t instance for the specified process.
+func Status(pid int) (Status_t, error) {
+	statusFile, err := pathrs.ProcPidOpen(pid, "status", os.O_RDONLY)
+	if err != nil {
+		return Status_t{}, err
+	}
+	defer statusFile.Close()
+
+	bytes, err := io.ReadAll(statusFile)
+	if err != nil {
+		return Status_t{}, err
+	}
+	return parseStatus(string(bytes))
+}
+
+func parseStatus(data string) (status Status_t, err error) {
+	for line := range strings.Lines(data) {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		switch key {
+		case "Uid", "Gid":
+			ids := &status.Uid
+			if key == "Gid" {
+				ids = &status.Gid
+			}
+			if len(fields) != len(ids) {
+				return status, fmt.Errorf("invalid status data (bad %s): %q", key, value)
+			}
+			for i, f := range fields {
+				id, err := strconv.ParseUint(f, 10, 32)
+				if err != nil {
+					return status, fmt.Errorf("invalid status data (bad %s): %w", key, err)
+				}
+				ids[i] = uint32(id)
+			}
+		case "NSpid":
+			status.NSpid = make([]int, len(fields))
+			for i, f := range fields {
+				if status.NSpid[i], err = strconv.Atoi(f); err != nil {
+					return status, fmt.Errorf("invalid status data (bad NSpid): %w", err)
+				}
+			}
+		case "CapInh", "CapPrm", "CapEff", "CapBnd", "CapAmb":
+			caps := map[string]*uint64{
+				"CapInh": &status.CapInh,
+				"CapPrm": &status.CapPrm,
+				"CapEff": &status.CapEff,
+				"CapBnd": &status.CapBnd,
+				"CapAmb": &status.CapAmb,
+			}[key]
+			if len(fields) != 1 {
+				return status, fmt.Errorf("invalid status data (bad %s): %q", key, value)
+			}
+			if *caps, err = strconv.ParseUint(fields[0], 16, 64); err != nil {
+				return status, fmt.Errorf("invalid status data (bad %s): %w", key, err)
+			}
+		case "Seccomp":
+			if len(fields) != 1 {
+				return status, fmt.Errorf("invalid status data (bad %s): %q", key, value)
+			}
+			if status.Seccomp, err = strconv.Atoi(fields[0]); err != nil {
+				return status, fmt.Errorf("invalid status data (bad %s): %w", key, err)
+			}
+		}
+	}
+	return status, nil
+}
+
+// Cmdline returns the command line arguments of the specified process. It is
+// empty for kernel threads and zombies.
+func Cmdline(pid int) ([]string, error) {
+	cmdlineFile, err := pathrs.ProcPidOpen(pid, "cmdline", os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer cmdlineFile.Close()
+
+	bytes, err := io.ReadAll(cmdlineFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(bytes), "\x00"), "\x00"), nil
+}
@@ -11,29 +11,52 @@ import (
 
 var procdata = map[string]Stat_t{
 	"4902 (gunicorn: maste) S 4885 4902 4902 0 -1 4194560 29683 29929 61 83 78 16 96 17 20 0 1 0 9126532 52965376 1903 18446744073709551615 4194304 7461796 140733928751520 140733928698072 139816984959091 0 0 16781312 137447943 1 0 0 17 3 0 0 9 0 0 9559488 10071156 33050624 140733928758775 140733928758945 140733928758945 140733928759264 0": {
-		Name:      "gunicorn: maste",
-		State:     'S',
-		StartTime: 9126532,
+		Name:        "gunicorn: maste",
+		State:       'S',
+		PPid:        4885,
+		UTime:       78,
+		STime:       16,
+		StartTime:   9126532,
+		RSS:         1903,
+		HasExitCode: true,
 	},
 	"9534 (cat) R 9323 9534 9323 34828 9534 4194304 95 0 0 0 0 0 0 0 20 0 1 0 9214966 7626752 168 18446744073709551615 4194304 4240332 140732237651568 140732237650920 140570710391216 0 0 0 0 0 0 0 17 1 0 0 0 0 0 6340112 6341364 21553152 140732237653865 140732237653885 140732237653885 140732237656047 0": {
-		Name:      "cat",
-		State:     'R',
-		StartTime: 9214966,
+		Name:        "cat",
+		State:       'R',
+		PPid:        9323,
+		StartTime:   9214966,
+		RSS:         168,
+		HasExitCode: true,
 	},
 	"12345 ((ugly )pr()cess() R 9323 9534 9323 34828 9534 4194304 95 0 0 0 0 0 0 0 20 0 1 0 9214966 7626752 168 18446744073709551615 4194304 4240332 140732237651568 140732237650920 140570710391216 0 0 0 0 0 0 0 17 1 0 0 0 0 0 6340112 6341364 21553152 140732237653865 140732237653885 140732237653885 140732237656047 0": {
-		Name:      "(ugly )pr()cess(",
-		State:     'R',
-		StartTime: 9214966,
+		Name:        "(ugly )pr()cess(",
+		State:       'R',
+		PPid:        9323,
+		StartTime:   9214966,
+		RSS:         168,
+		HasExitCode: true,
 	},
 	"24767 (irq/44-mei_me) S 2 0 0 0 -1 2129984 0 0 0 0 0 0 0 0 -51 0 1 0 8722075 0 0 18446744073709551615 0 0 0 0 0 0 0 2147483647 0 0 0 0 17 1 50 1 0 0 0 0 0 0 0 0 0 0 0": {
-		Name:      "irq/44-mei_me",
-		State:     'S',
-		StartTime: 8722075,
+		Name:        "irq/44-mei_me",
+		State:       'S',
+		PPid:        2,
+		StartTime:   8722075,
+		HasExitCode: true,
+	},
+	"31307 (sleep) Z 1 31307 31307 0 -1 4228108 112 0 0 0 0 0 0 0 20 0 1 0 4631620 0 0 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 9": {
+		Name:        "sleep",
+		State:       'Z',
+		PPid:        1,
+		StartTime:   4631620,
+		ExitCode:    9,
+		HasExitCode: true,
 	},
 	"0 () I 3 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0": {
-		Name:      "",
-		State:     'I',
-		StartTime: 0,
+		Name:        "",
+		State:       'I',
+		PPid:        3,
+		StartTime:   0,
+		HasExitCode: true,
 	},
 	// Not entirely correct, but minimally viable input (StartTime and a space after).
 	"1 (woo hoo) S 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 4 ": {
@@ -41,6 +64,22 @@ var procdata = map[string]Stat_t{
 		State:     'S',
 		StartTime: 4,
 	},
+	// Before Linux 3.5, there is no exit code.
+	"31308 (sleep) Z 1 31307 31307 0 -1 4228108 112 0 0 0 0 0 0 0 20 0 1 0 4631620 0 0 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0": {
+		Name:      "sleep",
+		State:     'Z',
+		PPid:      1,
+		StartTime: 4631620,
+	},
+	// Fields added after the exit code by a newer kernel.
+	"31309 (sleep) Z 1 31307 31307 0 -1 4228108 112 0 0 0 0 0 0 0 20 0 1 0 4631620 0 0 18446744073709551615 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0 0 0 0 0 0 0 0 256 7 8": {
+		Name:        "sleep",
+		State:       'Z',
+		PPid:        1,
+		StartTime:   4631620,
+		ExitCode:    256,
+		HasExitCode: true,
+	},
 }
 
 func TestParseStat(t *testing.T) {
@@ -54,6 +93,54 @@ func TestParseStat(t *testing.T) {
 	}
 }
 
+func TestParseStatus(t *testing.T) {
+	const data = `Name:	sleep
+Umask:	0022
+State:	S (sleeping)
+Tgid:	4128
+NSpid:	4128	1
+PPid:	4100
+Uid:	100000	100000	100000	100000
+Gid:	100001	100002	100003	100004
+Groups:	100000
+CapInh:	0000000000000000
+CapPrm:	00000000a80425fb
+CapEff:	00000000a80425fb
+CapBnd:	00000000a80425fb
+CapAmb:	0000000000000000
+NoNewPrivs:	0
+Seccomp:	2
+`
+	exp := Status_t{
+		Uid:     [4]uint32{100000, 100000, 100000, 100000},
+		Gid:     [4]uint32{100001, 100002, 100003, 100004},
+		NSpid:   []int{4128, 1},
+		CapPrm:  0xa80425fb,
+		CapEff:  0xa80425fb,
+		CapBnd:  0xa80425fb,
+		Seccomp: 2,
+	}
+	st, err := parseStatus(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(st, exp) {
+		t.Errorf("expected %+v, got %+v", exp, st)
+	}
+
+	for _, bad := range []string{
+		"Uid:\t0\t0\t0\n",
+		"Gid:\t0\t0\t0\tx\n",
+		"NSpid:\t1\tx\n",
+		"CapEff:\tzz\n",
+		"Seccomp:\tx\n",
+	} {
+		if st, err := parseStatus(bad); err == nil {
+			t.Errorf("input %q, expected error, got nil, %+v", bad, st)
+		}
+	}
+}
+
 func TestParseStatBadInput(t *testing.T) {
 	cases := []struct {
 		desc, input string
@@ -106,6 +193,14 @@ func TestParseStatBadInput(t *testing.T) {
 			"bad stime",
 			"123 (cmd) S 2 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 1",
 		},
+		{
+			"bad ppid",
+			"123 (cmd) S x 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 4 ",
+		},
+		{
+			"bad rss",
+			"123 (cmd) S 2 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 4 0 x ",
+		},
 	}
 	for _, c := range cases {
 		st, err := parseStat(c.input)
@@ -0,0 +1,287 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/internal/sys"
+	"github.com/opencontainers/runc/libcontainer/system"
+	"github.com/opencontainers/runc/libcontainer/utils"
+)
+
+// ExitStatus describes how the container's init process has ended.
+type ExitStatus struct {
+	// Code is the exit code of init. If init was killed by a signal, it is
+	// 128 plus the signal number (the same convention shells use).
+	Code int `json:"exit_code"`
+
+	// Signal is the signal which killed init, or 0 if init has exited.
+	Signal unix.Signal `json:"exit_signal,omitempty"`
+
+	// CoreDumped tells whether init was killed by a signal and dumped core.
+	CoreDumped bool `json:"core_dumped,omitempty"`
+
+	// ExitedAt is the time when init was found to be exited.
+	ExitedAt time.Time `json:"exited_at"`
+}
+
+func newExitStatus(ws unix.WaitStatus, exitedAt time.Time) *ExitStatus {
+	s := &ExitStatus{
+		Code:     utils.ExitStatus(ws),
+		ExitedAt: exitedAt.UTC(),
+	}
+	if ws.Signaled() {
+		s.Signal = ws.Signal()
+		s.CoreDumped = ws.CoreDump()
+	}
+	return s
+}
+
+// Wait waits for the container's init process to exit, and returns its exit
+// status, which is also recorded in the container state.
+//
+// If the caller is not the parent of init, the exit status can only be
+// obtained before init is reaped by its parent, on Linux 6.15 or later, or if
+// it was recorded by whoever reaped init (see [Container.RecordExit]).
+// Otherwise, [ErrExitUnknown] is returned.
+func (c *Container) Wait() (*ExitStatus, error) {
+	c.m.Lock()
+	init := c.initProcess
+	status := c.exitStatus
+	c.m.Unlock()
+
+	if status != nil {
+		return status, nil
+	}
+	if init == nil {
+		return nil, ErrNotRunning
+	}
+
+	var (
+		ws  unix.WaitStatus
+		err error
+	)
+	if _, ok := init.(*nonChildProcess); ok {
+		ws, err = waitNonChild(context.Background(), init.pid(), c.initProcessStartTime)
+	} else {
+		ws, err = waitChild(init)
+	}
+	return c.waitDone(ws, err, time.Now())
+}
+
+// waitDone records the result of waiting for init.
+func (c *Container) waitDone(ws unix.WaitStatus, err error, exitedAt time.Time) (*ExitStatus, error) {
+	if errors.Is(err, ErrExitUnknown) {
+		status, err := c.recordedExitStatus()
+		if errors.Is(err, ErrExitUnknown) {
+			c.m.Lock()
+			c.publishExited(nil)
+			c.m.Unlock()
+		}
+		return status, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.recordExit(ws, exitedAt)
+}
+
+// RecordExit records ws as the exit status of the container's init process,
+// so it can later be obtained from [Container.State] or [Container.Wait].
+// It is meant to be used by callers which reap init themselves, since
+// otherwise the exit status is lost once init is gone.
+func (c *Container) RecordExit(ws unix.WaitStatus) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+	_, err := c.recordExit(ws, time.Now())
+	return err
+}
+
+func (c *Container) recordExit(ws unix.WaitStatus, exitedAt time.Time) (*ExitStatus, error) {
+	c.exitStatus = newExitStatus(ws, exitedAt)
+	if err := c.lockState(); err != nil {
+		// The container might have been destroyed in the meantime.
+		if errors.Is(err, ErrNotExist) {
+			return c.exitStatus, nil
+		}
+		return c.exitStatus, fmt.Errorf("unable to record exit status: %w", err)
+	}
+	defer c.unlockState()
+	c.publishExited(c.exitStatus)
+	if err := c.saveState(c.currentState()); err != nil {
+		return c.exitStatus, fmt.Errorf("unable to record exit status: %w", err)
+	}
+	return c.exitStatus, nil
+}
+
+// publishExited publishes the exited event, unless it was already published
+// (for example, by another runc wait), as recorded by the exited marker file
+// in the container state directory.
+func (c *Container) publishExited(status *ExitStatus) {
+	f, err := os.OpenFile(filepath.Join(c.stateDir, exitedFilename), os.O_WRONLY|os.O_CREATE|os.O_EXCL|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		// Either published already, or the container is destroyed.
+		if !errors.Is(err, os.ErrExist) && !errors.Is(err, os.ErrNotExist) {
+			logrus.WithError(err).Warnf("unable to publish %s event", EventExited)
+		}
+		return
+	}
+	f.Close()
+	pid := 0
+	if c.initProcess != nil {
+		pid = c.initProcess.pid()
+	}
+	c.publishEvent(EventExited, pid, status)
+}
+
+// recordedExitStatus returns the exit status of init as recorded in the
+// container state by someone else (such as the process that reaped init).
+func (c *Container) recordedExitStatus() (*ExitStatus, error) {
+	state, err := c.store.Load(c.id)
+	if err != nil {
+		return nil, err
+	}
+	if state.ExitStatus == nil {
+		return nil, ErrExitUnknown
+	}
+	c.m.Lock()
+	c.exitStatus = state.ExitStatus
+	c.m.Unlock()
+	return state.ExitStatus, nil
+}
+
+// waitChild reaps init, which is a child of the current process.
+func waitChild(p parentProcess) (unix.WaitStatus, error) {
+	ps, _ := p.wait()
+	if ps == nil {
+		// Most probably, init was already reaped by someone else.
+		return 0, ErrExitUnknown
+	}
+	return unix.WaitStatus(ps.Sys().(syscall.WaitStatus)), nil
+}
+
+// waitNonChild waits for a process which is not a child of the current
+// process (or which must not be reaped) to exit, and returns its exit status.
+// If the exit status can not be obtained, ErrExitUnknown is returned once the
+// process is gone. If ctx is done first, its error is returned.
+func waitNonChild(ctx context.Context, pid int, startTime uint64) (unix.WaitStatus, error) {
+	// TODO: switch to os.Process.WithHandle once go < 1.26 is no longer supported.
+	pidFd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		if errors.Is(err, unix.ESRCH) {
+			return 0, ErrExitUnknown
+		}
+		// Kernels older than 5.3 do not have pidfd_open(2).
+		return waitPidGone(ctx, pid, startTime)
+	}
+	defer unix.Close(pidFd)
+	// Make sure the pidfd refers to init and not to a recycled PID.
+	if stat, err := system.Stat(pid); err != nil || stat.StartTime != startTime {
+		return 0, ErrExitUnknown
+	}
+
+	pfd := []unix.PollFd{{Fd: int32(pidFd), Events: unix.POLLIN}}
+	if ctx.Done() != nil {
+		cancelFd, release, err := newCancelFd(ctx)
+		if err != nil {
+			return 0, err
+		}
+		defer release()
+		pfd = append(pfd, unix.PollFd{Fd: int32(cancelFd), Events: unix.POLLIN})
+	}
+	for {
+		_, err := unix.Poll(pfd, -1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("poll pidfd: %w", err)
+		}
+		if pfd[0].Revents == 0 {
+			return 0, ctx.Err()
+		}
+		break
+	}
+	for {
+		// Until the process is reaped by its parent, its exit status can
+		// be read from /proc. After that, it is only available via pidfd.
+		if ws, ok := zombieExitStatus(pid, startTime); ok {
+			return ws, nil
+		}
+		ws, ok, err := sys.PidfdExitStatus(pidFd)
+		if err != nil {
+			return 0, ErrExitUnknown
+		}
+		if ok {
+			return ws, nil
+		}
+		// Reaped in between the above two checks.
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitPidGone polls until the process with the given pid and start time
+// is either gone or became a zombie, and returns its exit status.
+func waitPidGone(ctx context.Context, pid int, startTime uint64) (unix.WaitStatus, error) {
+	for {
+		if ws, ok := zombieExitStatus(pid, startTime); ok {
+			return ws, nil
+		}
+		stat, err := system.Stat(pid)
+		if err != nil || stat.StartTime != startTime || stat.State == system.Dead {
+			return 0, ErrExitUnknown
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// newCancelFd returns an eventfd which becomes readable once ctx is done, so
+// that a poll(2) on it together with other file descriptors can be cancelled.
+// The eventfd is closed by the returned release function.
+func newCancelFd(ctx context.Context) (int, func(), error) {
+	fd, err := unix.Eventfd(0, unix.EFD_CLOEXEC)
+	if err != nil {
+		return -1, nil, os.NewSyscallError("eventfd", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	stop := context.AfterFunc(ctx, func() {
+		defer wg.Done()
+		_, _ = unix.Write(fd, []byte{1, 0, 0, 0, 0, 0, 0, 0})
+	})
+	release := func() {
+		if stop() {
+			wg.Done()
+		}
+		// Do not close fd while it might still be written to.
+		wg.Wait()
+		unix.Close(fd)
+	}
+	return fd, release, nil
+}
+
+// zombieExitStatus returns the exit status of the process if it is a zombie,
+// and the kernel reports it.
+func zombieExitStatus(pid int, startTime uint64) (unix.WaitStatus, bool) {
+	stat, err := system.Stat(pid)
+	if err != nil || stat.StartTime != startTime || stat.State != system.Zombie || !stat.HasExitCode {
+		return 0, false
+	}
+	return unix.WaitStatus(stat.ExitCode), true
+}
@@ -0,0 +1,47 @@
+package libcontainer
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer/system"
+)
+
+func TestWaitNonChildCancel(t *testing.T) {
+	cmd := exec.Command("sleep", "1h")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	stat, err := system.Stat(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := waitNonChild(ctx, cmd.Process.Pid, stat.StartTime); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	// Once the process exits, its exit status is obtained without reaping it.
+	if err := cmd.Process.Kill(); err != nil {
+		t.Fatal(err)
+	}
+	ws, err := waitNonChild(context.Background(), cmd.Process.Pid, stat.StartTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ws.Signaled() {
+		t.Fatalf("expected the process to be killed, got %v", ws)
+	}
+	if stat, err := system.Stat(cmd.Process.Pid); err != nil || stat.State != system.Zombie {
+		t.Fatalf("expected the process not to be reaped (err: %v)", err)
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"os"
 	"os/user"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"text/tabwriter"
+	"text/template"
 	"time"
 
 	"github.com/opencontainers/runc/libcontainer"
@@ -40,6 +43,9 @@ type containerState struct {
 	Annotations map[string]string `json:"annotations,omitempty"`
 	// The owner of the state directory (the owner of the container).
 	Owner string `json:"owner"`
+	// ExitStatus is the exit status of the container's init process,
+	// if the container is stopped and the exit status is known.
+	ExitStatus *exitStatus `json:"exitStatus,omitempty"`
 }
 
 var listCommand = &cli.Command{
@@ -56,7 +62,11 @@ To list containers created via the default "--root":
 
 EXAMPLE 2:
 To list containers created using a non-default value for "--root":
-       # runc --root value list`,
+       # runc --root value list
+
+EXAMPLE 3:
+To list the IDs and PIDs of the running containers:
+       # runc list --filter status=running --format '{{.ID}} {{.InitProcessPid}}'`,
 	// Disable comma as separator for slice flags.
 	DisableSliceFlagSeparator: true,
 	Flags: []cli.Flag{
@@ -64,19 +74,42 @@ To list containers created using a non-default value for "--root":
 			Name:    "format",
 			Aliases: []string{"f"},
 			Value:   "table",
-			Usage:   `select one of: ` + formatOptions,
+			Usage:   `select one of: ` + formatOptions + `, or a Go template`,
 		},
 		&cli.BoolFlag{
 			Name:    "quiet",
 			Aliases: []string{"q"},
 			Usage:   "display only container IDs",
 		},
+		&cli.StringSliceFlag{
+			Name:  "filter",
+			Usage: "only list the containers matching a filter, as status=STATUS, label=KEY=VALUE, or annotation=KEY=VALUE (can be repeated)",
+		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 0, exactArgs); err != nil {
 			return err
 		}
-		s, err := getContainers(cmd)
+		var tmpl *template.Template
+		switch format := cmd.String("format"); format {
+		case "table", "json":
+		default:
+			if !strings.Contains(format, "{{") {
+				return errors.New("invalid format option")
+			}
+			var err error
+			tmpl, err = template.New("format").Parse(format)
+			if err != nil {
+				return fmt.Errorf("invalid format template: %w", err)
+			}
+		}
+		filter, err := parseListFilter(cmd.StringSlice("filter"))
+		if err != nil {
+			return err
+		}
+		// The container status is not needed to only show the IDs.
+		filter.SkipStatus = cmd.Bool("quiet")
+		s, err := getContainers(cmd, filter)
 		if err != nil {
 			return err
 		}
@@ -88,8 +121,15 @@ To list containers created using a non-default value for "--root":
 			return nil
 		}
 
-		switch cmd.String("format") {
-		case "table":
+		switch {
+		case tmpl != nil:
+			for _, item := range s {
+				if err := tmpl.Execute(os.Stdout, item); err != nil {
+					return err
+				}
+				fmt.Println()
+			}
+		case cmd.String("format") == "table":
 			w := tabwriter.NewWriter(os.Stdout, 12, 1, 3, ' ', 0)
 			fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\tOWNER\n")
 			for _, item := range s {
@@ -104,21 +144,61 @@ To list containers created using a non-default value for "--root":
 			if err := w.Flush(); err != nil {
 				return err
 			}
-		case "json":
+		default:
 			if err := json.NewEncoder(os.Stdout).Encode(s); err != nil {
 				return err
 			}
-		default:
-			return errors.New("invalid format option")
 		}
 		return nil
 	},
 }
 
-func getContainers(cmd *cli.Command) ([]containerState, error) {
+// parseListFilter parses the runc list --filter values.
+func parseListFilter(filters []string) (*libcontainer.ListFilter, error) {
+	filter := &libcontainer.ListFilter{}
+	for _, f := range filters {
+		key, value, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --filter %q: must be KEY=VALUE", f)
+		}
+		switch key {
+		case "status":
+			status, ok := listStatuses[value]
+			if !ok {
+				return nil, fmt.Errorf("invalid --filter %q: unknown status", f)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		case "label", "annotation":
+			k, v, ok := strings.Cut(value, "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("invalid --filter %q: must be %s=KEY=VALUE", f, key)
+			}
+			m := &filter.Labels
+			if key == "annotation" {
+				m = &filter.Annotations
+			}
+			if *m == nil {
+				*m = make(map[string]string)
+			}
+			(*m)[k] = v
+		default:
+			return nil, fmt.Errorf("invalid --filter %q: unknown filter %q", f, key)
+		}
+	}
+	return filter, nil
+}
+
+// listStatuses are the container statuses accepted by runc list --filter.
+var listStatuses = map[string]libcontainer.Status{
+	libcontainer.Created.String(): libcontainer.Created,
+	libcontainer.Running.String(): libcontainer.Running,
+	libcontainer.Paused.String():  libcontainer.Paused,
+	libcontainer.Stopped.String(): libcontainer.Stopped,
+}
+
+func getContainers(cmd *cli.Command, filter *libcontainer.ListFilter) ([]containerState, error) {
 	root := cmd.String("root")
-	list, err := os.ReadDir(root)
-	if err != nil {
+	if _, err := os.Stat(root); err != nil {
 		if errors.Is(err, os.ErrNotExist) && !cmd.IsSet("root") {
 			// Ignore non-existing default root directory
 			// (no containers created yet).
@@ -127,12 +207,18 @@ func getContainers(cmd *cli.Command) ([]containerState, error) {
 		// Report other errors, including non-existent custom --root.
 		return nil, err
 	}
+	list, err := libcontainer.List(root, filter)
+	if err != nil {
+		return nil, err
+	}
+	owners := make(map[uint32]string)
 	var s []containerState
 	for _, item := range list {
-		if !item.IsDir() {
+		if item.Err != nil {
+			fmt.Fprintln(os.Stderr, item.Err)
 			continue
 		}
-		st, err := item.Info()
+		st, err := os.Stat(filepath.Join(root, item.ID))
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
 				// Possible race with runc delete.
@@ -142,29 +228,19 @@ func getContainers(cmd *cli.Command) ([]containerState, error) {
 		}
 		// This cast is safe on Linux.
 		uid := st.Sys().(*syscall.Stat_t).Uid
-		owner := "#" + strconv.Itoa(int(uid))
-		u, err := user.LookupId(owner[1:])
-		if err == nil {
-			owner = u.Username
+		owner, ok := owners[uid]
+		if !ok {
+			owner = "#" + strconv.Itoa(int(uid))
+			u, err := user.LookupId(owner[1:])
+			if err == nil {
+				owner = u.Username
+			}
+			owners[uid] = owner
 		}
 
-		container, err := libcontainer.Load(root, item.Name())
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "load container %s: %v\n", item.Name(), err)
-			continue
-		}
-		containerStatus, err := container.Status()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "status for %s: %v\n", item.Name(), err)
-			continue
-		}
-		state, err := container.State()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "state for %s: %v\n", item.Name(), err)
-			continue
-		}
+		state := item.State
 		pid := state.BaseState.InitProcessPid
-		if containerStatus == libcontainer.Stopped {
+		if item.Status == libcontainer.Stopped {
 			pid = 0
 		}
 		bundle, annotations := utils.Annotations(state.Config.Labels)
@@ -172,12 +248,13 @@ func getContainers(cmd *cli.Command) ([]containerState, error) {
 			Version:        state.BaseState.Config.Version,
 			ID:             state.BaseState.ID,
 			InitProcessPid: pid,
-			Status:         containerStatus.String(),
+			Status:         item.Status.String(),
 			Bundle:         bundle,
 			Rootfs:         state.BaseState.Config.Rootfs,
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
 			Owner:          owner,
+			ExitStatus:     convertExitStatus(state.ExitStatus),
 		})
 	}
 	return s, nil
@@ -17,6 +17,7 @@ import (
 
 	//nolint:revive // Enable cgroup manager to manage devices
 	_ "github.com/opencontainers/cgroups/devices"
+	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/seccomp"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
@@ -132,13 +133,21 @@ func main() {
 			Value: "auto",
 			Usage: "ignore cgroup permission errors ('true', 'false', or 'auto')",
 		},
+		&cli.DurationFlag{
+			Name:  "lock-timeout",
+			Value: libcontainer.LockTimeout,
+			Usage: "how long to wait for another runc operation on the same container to finish (0 to fail immediately, negative to wait indefinitely)",
+		},
 	}
 	app.Commands = []*cli.Command{
+		bundleCommand,
 		checkpointCommand,
 		createCommand,
 		deleteCommand,
 		eventsCommand,
 		execCommand,
+		gcCommand,
+		inspectCommand,
 		killCommand,
 		listCommand,
 		pauseCommand,
@@ -149,7 +158,10 @@ func main() {
 		specCommand,
 		startCommand,
 		stateCommand,
+		stopCommand,
 		updateCommand,
+		validateCommand,
+		waitCommand,
 		featuresCommand,
 	}
 	app.Before = func(ctx context.Context, cmd *cli.Command) (context.Context, error) {
@@ -173,6 +185,7 @@ func main() {
 		if err := configLogrus(cmd); err != nil {
 			return ctx, err
 		}
+		libcontainer.LockTimeout = cmd.Duration("lock-timeout")
 
 		return ctx, nil
 	}
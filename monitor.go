@@ -0,0 +1,220 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	securejoin "github.com/cyphar/filepath-securejoin"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/internal/third_party/systemd/activation"
+	"github.com/opencontainers/runc/libcontainer"
+)
+
+// monitorSyncFdEnv is set by runc create and runc run --monitor for the
+// monitor it spawns, to the file descriptor of the pipe to report back the
+// container start result.
+const monitorSyncFdEnv = "_RUNC_MONITOR_SYNC_FD"
+
+// monitorStarted is written by the monitor to the sync pipe once the
+// container is started.
+const monitorStarted = 's'
+
+// monitorLogFilename is the file in the container directory the monitor logs
+// to, unless runc is given a log file.
+const monitorLogFilename = "monitor.log"
+
+var monitorFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "monitor",
+		Usage: "leave a monitor process to reap the container init and record its exit status once runc exits",
+	},
+	&cli.BoolFlag{
+		Name:  "auto-remove",
+		Usage: "remove the container (running the poststop hooks) once its init exits (implies --monitor)",
+	},
+}
+
+// monitor supervises a detached container: it is the parent process of the
+// container init, so it is able to reap init and record its exit status,
+// and to remove the container once init exits.
+type monitor struct {
+	root       string
+	autoRemove bool
+	// logToFile is set if runc logs to a file (rather than to stderr).
+	logToFile bool
+	sync      *os.File
+}
+
+// useMonitor reports whether a monitor is requested for the container.
+// It returns an error if the monitor can not be used.
+func useMonitor(cmd *cli.Command, detach bool) (bool, error) {
+	if !cmd.Bool("monitor") && !cmd.Bool("auto-remove") {
+		return false, nil
+	}
+	if !detach {
+		return false, errors.New("--monitor and --auto-remove require --detach")
+	}
+	return true, nil
+}
+
+// spawnMonitor runs runc again, with the same arguments, as a monitor in a
+// new session, which creates the container. It returns once the container is
+// started, or the monitor fails to start it, in which case its exit code is
+// returned.
+func spawnMonitor(cmd *cli.Command) (int, error) {
+	// The monitor must have the socket activation and the preserved file
+	// descriptors at the same numbers as runc does.
+	extraFiles := activation.Files()
+	base := 3 + len(extraFiles)
+	for i := range int(cmd.Int("preserve-fds")) {
+		fd := base + i
+		extraFiles = append(extraFiles, os.NewFile(uintptr(fd), "PreserveFD:"+strconv.Itoa(fd)))
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return -1, err
+	}
+	defer r.Close()
+	extraFiles = append(extraFiles, w)
+
+	c := exec.Command("/proc/self/exe", os.Args[1:]...)
+	c.Args[0] = os.Args[0]
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	c.ExtraFiles = extraFiles
+	c.Env = append(os.Environ(), monitorSyncFdEnv+"="+strconv.Itoa(2+len(extraFiles)))
+	c.SysProcAttr = &unix.SysProcAttr{Setsid: true}
+	err = c.Start()
+	w.Close()
+	if err != nil {
+		return -1, fmt.Errorf("unable to start monitor: %w", err)
+	}
+
+	var buf [1]byte
+	if n, _ := r.Read(buf[:]); n == 1 && buf[0] == monitorStarted {
+		_ = c.Process.Release()
+		return 0, nil
+	}
+	// The monitor has failed, and reported the error itself.
+	if err := c.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode(), nil
+		}
+		return -1, err
+	}
+	return -1, errors.New("monitor exited without starting the container")
+}
+
+// newMonitor returns the monitor, if runc is running as one, or nil.
+func newMonitor(cmd *cli.Command) (*monitor, error) {
+	env := os.Getenv(monitorSyncFdEnv)
+	if env == "" {
+		return nil, nil
+	}
+	_ = os.Unsetenv(monitorSyncFdEnv)
+	fd, err := strconv.Atoi(env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", monitorSyncFdEnv, err)
+	}
+	unix.CloseOnExec(fd)
+	// The socket activation file descriptors were passed to runc, which
+	// has passed them on.
+	if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getppid()) {
+		_ = os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	}
+	return &monitor{
+		root:       cmd.String("root"),
+		autoRemove: cmd.Bool("auto-remove"),
+		logToFile:  cmd.String("log") != "",
+		sync:       os.NewFile(uintptr(fd), "monitor-sync"),
+	}, nil
+}
+
+// isMonitor reports whether runc is running as a monitor.
+func isMonitor() bool {
+	return os.Getenv(monitorSyncFdEnv) != ""
+}
+
+// run reports to the parent that the container is started, and waits for
+// the container init to exit. The errors are logged, as there is nobody else
+// to report them to: unless runc is given a log file, to the monitor.log file
+// in the container directory.
+func (m *monitor) run(container *libcontainer.Container) {
+	if _, err := m.sync.Write([]byte{monitorStarted}); err != nil {
+		logrus.Warnf("monitor: unable to report the container start: %v", err)
+	}
+	m.sync.Close()
+	if !m.logToFile {
+		if err := logToContainerDir(m.root, container.ID()); err != nil {
+			logrus.Warnf("monitor: %v", err)
+		}
+	}
+	// Detach from the stdio of runc, which the caller may wait to be closed.
+	if err := detachStdio(); err != nil {
+		logrus.Warnf("monitor: %v", err)
+	}
+
+	status, err := container.Wait()
+	if err != nil && !errors.Is(err, libcontainer.ErrExitUnknown) {
+		logrus.Errorf("monitor: unable to wait for container %s: %v", container.ID(), err)
+		return
+	}
+	if status != nil {
+		logrus.Debugf("monitor: container %s init exited with status %d", container.ID(), status.Code)
+	}
+	if !m.autoRemove {
+		return
+	}
+	// The container might have been removed in the meantime.
+	c, err := libcontainer.Load(m.root, container.ID())
+	if err != nil {
+		if !errors.Is(err, libcontainer.ErrNotExist) {
+			logrus.Errorf("monitor: unable to load container %s: %v", container.ID(), err)
+		}
+		return
+	}
+	if err := c.Destroy(); err != nil {
+		logrus.Errorf("monitor: unable to remove container %s: %v", container.ID(), err)
+	}
+}
+
+// logToContainerDir redirects the logs to the monitor log file in the
+// directory of the container with the given id.
+func logToContainerDir(root, id string) error {
+	dir, err := securejoin.SecureJoin(root, id)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, monitorLogFilename), os.O_CREATE|os.O_WRONLY|os.O_APPEND|unix.O_CLOEXEC, 0o600)
+	if err != nil {
+		return fmt.Errorf("unable to open the log file: %w", err)
+	}
+	logrus.SetOutput(f)
+	return nil
+}
+
+// detachStdio replaces the standard input and outputs of the current process
+// with /dev/null.
+func detachStdio() error {
+	devNull, err := os.OpenFile("/dev/null", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer devNull.Close()
+	for fd := range 3 {
+		if err := unix.Dup3(int(devNull.Fd()), fd, 0); err != nil {
+			return os.NewSyscallError("dup3", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/types"
+)
+
+// openMetricsContentType is the HTTP content type of the OpenMetrics text format.
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// writeMetrics writes the container stats to w in the OpenMetrics text format.
+func writeMetrics(w io.Writer, container *libcontainer.Container) error {
+	ls, err := container.Stats()
+	if err != nil {
+		return err
+	}
+	m := newMetricsWriter(container.ID(), container.Config().Labels)
+	if s := convertLibcontainerStats(ls); s != nil {
+		m.addStats(s)
+	}
+	_, err = m.WriteTo(w)
+	return err
+}
+
+// serveMetrics serves the container stats in the OpenMetrics text format over
+// HTTP on addr (which must be unix:///path), until the container is stopped
+// or runc is interrupted.
+func serveMetrics(ctx context.Context, container *libcontainer.Container, addr string) error {
+	path, ok := strings.CutPrefix(addr, "unix://")
+	if !ok || path == "" {
+		return fmt.Errorf("invalid listen address %q: only unix:///path is supported", addr)
+	}
+	ctx, stop := signal.NotifyContext(ctx, unix.SIGINT, unix.SIGTERM)
+	defer stop()
+	events, err := container.Events(ctx, nil)
+	if err != nil {
+		if errors.Is(err, libcontainer.ErrNotRunning) {
+			return fmt.Errorf("container with id %s is not running", container.ID())
+		}
+		return err
+	}
+	// The socket is removed once the listener is closed.
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		stop()
+		for range events {
+		}
+		return err
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			var b bytes.Buffer
+			if err := writeMetrics(&b, container); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", openMetricsContentType)
+			_, _ = b.WriteTo(w)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		// The channel is closed once the container is stopped or ctx is done.
+		for range events {
+		}
+		srv.Close()
+	}()
+	if err := srv.Serve(l); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// metricFamily is a set of OpenMetrics samples sharing the same name and type.
+type metricFamily struct {
+	name, typ, unit, help string
+	samples               []string
+}
+
+// metricsWriter collects container stats as OpenMetrics metric families.
+type metricsWriter struct {
+	// labels are the labels added to every sample, already formatted.
+	labels   string
+	families []*metricFamily
+}
+
+// newMetricsWriter returns a metricsWriter for the container with the given
+// id and labels (in the "key=value" form, as in the container config).
+func newMetricsWriter(id string, labels []string) *metricsWriter {
+	l := []string{"id", id}
+	for _, kv := range labels {
+		k, v, _ := strings.Cut(kv, "=")
+		l = append(l, "label_"+sanitizeLabelName(k), v)
+	}
+	return &metricsWriter{labels: formatLabels(l)}
+}
+
+func (m *metricsWriter) family(name, typ, unit, help string) *metricFamily {
+	f := &metricFamily{name: name, typ: typ, unit: unit, help: help}
+	m.families = append(m.families, f)
+	return f
+}
+
+func (m *metricsWriter) counter(name, unit, help string) *metricFamily {
+	return m.family(name, "counter", unit, help)
+}
+
+func (m *metricsWriter) gauge(name, unit, help string) *metricFamily {
+	return m.family(name, "gauge", unit, help)
+}
+
+// add adds a sample with the given value and extra labels (name-value pairs)
+// to the family. For counters, the "_total" suffix is added to the name.
+func (m *metricsWriter) add(f *metricFamily, value string, labels ...string) {
+	name := f.name
+	if f.typ == "counter" {
+		name += "_total"
+	}
+	l := m.labels
+	if len(labels) > 0 {
+		l += "," + formatLabels(labels)
+	}
+	f.samples = append(f.samples, name+"{"+l+"} "+value)
+}
+
+func (m *metricsWriter) addUint(f *metricFamily, value uint64, labels ...string) {
+	m.add(f, strconv.FormatUint(value, 10), labels...)
+}
+
+func (m *metricsWriter) addFloat(f *metricFamily, value float64, labels ...string) {
+	m.add(f, strconv.FormatFloat(value, 'g', -1, 64), labels...)
+}
+
+// addNanoseconds adds a sample given in nanoseconds, in seconds.
+func (m *metricsWriter) addNanoseconds(f *metricFamily, ns uint64, labels ...string) {
+	m.addFloat(f, float64(ns)/1e9, labels...)
+}
+
+// WriteTo writes the metric families having samples, followed by "# EOF".
+func (m *metricsWriter) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, f := range m.families {
+		if len(f.samples) == 0 {
+			continue
+		}
+		b.WriteString("# TYPE " + f.name + " " + f.typ + "\n")
+		if f.unit != "" {
+			b.WriteString("# UNIT " + f.name + " " + f.unit + "\n")
+		}
+		b.WriteString("# HELP " + f.name + " " + f.help + "\n")
+		for _, s := range f.samples {
+			b.WriteString(s + "\n")
+		}
+	}
+	b.WriteString("# EOF\n")
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// formatLabels formats name-value pairs as OpenMetrics labels.
+func formatLabels(l []string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(l); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l[i] + `="` + escapeLabelValue(l[i+1]) + `"`)
+	}
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+// sanitizeLabelName replaces the characters not allowed in a label name with
+// underscores.
+func sanitizeLabelName(n string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, n)
+}
+
+// addStats adds the container stats to m.
+func (m *metricsWriter) addStats(s *types.Stats) {
+	m.addCPUStats(&s.CPU)
+	m.addMemoryStats(&s.Memory)
+	m.addPidsStats(&s.Pids)
+	m.addBlkioStats(&s.Blkio)
+	m.addHugetlbStats(s.Hugetlb)
+	m.addPSIStats(&s.CPU, &s.Memory, &s.Blkio)
+	m.addIntelRdtStats(&s.IntelRdt)
+	m.addNetworkStats(s.NetworkInterfaces)
+}
+
+func (m *metricsWriter) addCPUStats(c *types.Cpu) {
+	f := m.counter("runc_cpu_usage_seconds", "seconds", "Total CPU time consumed.")
+	m.addNanoseconds(f, c.Usage.Total)
+	f = m.counter("runc_cpu_kernel_seconds", "seconds", "CPU time consumed in kernel mode.")
+	m.addNanoseconds(f, c.Usage.Kernel)
+	f = m.counter("runc_cpu_user_seconds", "seconds", "CPU time consumed in user mode.")
+	m.addNanoseconds(f, c.Usage.User)
+	f = m.counter("runc_cpu_usage_per_cpu_seconds", "seconds", "CPU time consumed on each CPU.")
+	for i, v := range c.Usage.Percpu {
+		m.addNanoseconds(f, v, "cpu", strconv.Itoa(i))
+	}
+
+	f = m.counter("runc_cpu_periods", "", "Number of enforcement periods elapsed.")
+	m.addUint(f, c.Throttling.Periods)
+	f = m.counter("runc_cpu_throttled_periods", "", "Number of enforcement periods the container was throttled in.")
+	m.addUint(f, c.Throttling.ThrottledPeriods)
+	f = m.counter("runc_cpu_throttled_seconds", "seconds", "Total time the container was throttled for.")
+	m.addNanoseconds(f, c.Throttling.ThrottledTime)
+}
+
+func (m *metricsWriter) addMemoryStats(mem *types.Memory) {
+	f := m.gauge("runc_memory_cache_bytes", "bytes", "Page cache memory.")
+	m.addUint(f, mem.Cache)
+
+	usage := m.gauge("runc_memory_usage_bytes", "bytes", "Current memory usage.")
+	maxUsage := m.gauge("runc_memory_max_usage_bytes", "bytes", "Maximum recorded memory usage.")
+	limit := m.gauge("runc_memory_limit_bytes", "bytes", "Memory limit.")
+	failcnt := m.counter("runc_memory_failures", "", "Number of times the memory limit was hit.")
+	for _, e := range []struct {
+		typ   string
+		entry *types.MemoryEntry
+	}{
+		{"memory", &mem.Usage},
+		{"swap", &mem.Swap},
+		{"kernel", &mem.Kernel},
+		{"kernel_tcp", &mem.KernelTCP},
+	} {
+		if *e.entry == (types.MemoryEntry{}) {
+			continue
+		}
+		m.addUint(usage, e.entry.Usage, "type", e.typ)
+		m.addUint(maxUsage, e.entry.Max, "type", e.typ)
+		m.addUint(limit, e.entry.Limit, "type", e.typ)
+		m.addUint(failcnt, e.entry.Failcnt, "type", e.typ)
+	}
+
+	f = m.gauge("runc_memory_stat", "", "Memory statistics, as reported by the memory controller.")
+	for _, k := range slices.Sorted(maps.Keys(mem.Raw)) {
+		m.addUint(f, mem.Raw[k], "name", k)
+	}
+}
+
+func (m *metricsWriter) addPidsStats(p *types.Pids) {
+	f := m.gauge("runc_pids_current", "", "Number of processes.")
+	m.addUint(f, p.Current)
+	if p.Limit != 0 {
+		f = m.gauge("runc_pids_limit", "", "Maximum number of processes.")
+		m.addUint(f, p.Limit)
+	}
+}
+
+func (m *metricsWriter) addBlkioStats(b *types.Blkio) {
+	for _, s := range []struct {
+		name, typ, help string
+		entries         []types.BlkioEntry
+	}{
+		{"runc_blkio_io_service_bytes", "counter", "Number of bytes transferred to and from the device.", b.IoServiceBytesRecursive},
+		{"runc_blkio_io_serviced", "counter", "Number of I/O operations issued to the device.", b.IoServicedRecursive},
+		{"runc_blkio_io_queued", "gauge", "Number of I/O operations queued.", b.IoQueuedRecursive},
+		{"runc_blkio_io_service_time", "counter", "Total time between dispatch and completion of I/O operations.", b.IoServiceTimeRecursive},
+		{"runc_blkio_io_wait_time", "counter", "Total time I/O operations spent waiting in the scheduler queues.", b.IoWaitTimeRecursive},
+		{"runc_blkio_io_merged", "counter", "Number of I/O operations merged into other requests.", b.IoMergedRecursive},
+		{"runc_blkio_io_time", "counter", "Disk time allocated to the container.", b.IoTimeRecursive},
+		{"runc_blkio_sectors", "counter", "Number of sectors transferred to and from the device.", b.SectorsRecursive},
+	} {
+		f := m.family(s.name, s.typ, "", s.help)
+		for _, e := range s.entries {
+			l := []string{"major", strconv.FormatUint(e.Major, 10), "minor", strconv.FormatUint(e.Minor, 10)}
+			if e.Op != "" {
+				l = append(l, "op", strings.ToLower(e.Op))
+			}
+			m.addUint(f, e.Value, l...)
+		}
+	}
+}
+
+func (m *metricsWriter) addHugetlbStats(h map[string]types.Hugetlb) {
+	usage := m.gauge("runc_hugetlb_usage_bytes", "bytes", "Current hugetlb usage.")
+	maxUsage := m.gauge("runc_hugetlb_max_usage_bytes", "bytes", "Maximum recorded hugetlb usage.")
+	failcnt := m.counter("runc_hugetlb_failures", "", "Number of times the hugetlb limit was hit.")
+	for _, size := range slices.Sorted(maps.Keys(h)) {
+		e := h[size]
+		m.addUint(usage, e.Usage, "pagesize", size)
+		m.addUint(maxUsage, e.Max, "pagesize", size)
+		m.addUint(failcnt, e.Failcnt, "pagesize", size)
+	}
+}
+
+func (m *metricsWriter) addPSIStats(c *types.Cpu, mem *types.Memory, b *types.Blkio) {
+	avg10 := m.gauge("runc_pressure_avg10", "", "Percentage of time tasks were stalled, over the last 10 seconds.")
+	avg60 := m.gauge("runc_pressure_avg60", "", "Percentage of time tasks were stalled, over the last 60 seconds.")
+	avg300 := m.gauge("runc_pressure_avg300", "", "Percentage of time tasks were stalled, over the last 300 seconds.")
+	total := m.counter("runc_pressure_stalled_seconds", "seconds", "Total time tasks were stalled for.")
+	for _, r := range []struct {
+		resource string
+		psi      *types.PSIStats
+	}{
+		{"cpu", c.PSI},
+		{"memory", mem.PSI},
+		{"io", b.PSI},
+	} {
+		if r.psi == nil {
+			continue
+		}
+		for _, k := range []struct {
+			kind string
+			data *types.PSIData
+		}{
+			{"some", &r.psi.Some},
+			{"full", &r.psi.Full},
+		} {
+			l := []string{"resource", r.resource, "kind", k.kind}
+			m.addFloat(avg10, k.data.Avg10, l...)
+			m.addFloat(avg60, k.data.Avg60, l...)
+			m.addFloat(avg300, k.data.Avg300, l...)
+			// The total stall time is in microseconds.
+			m.addFloat(total, float64(k.data.Total)/1e6, l...)
+		}
+	}
+}
+
+func (m *metricsWriter) addIntelRdtStats(r *types.IntelRdt) {
+	if r.MBMStats != nil {
+		total := m.counter("runc_intel_rdt_mbm_total_bytes", "bytes", "Total memory bandwidth used, per NUMA node.")
+		local := m.counter("runc_intel_rdt_mbm_local_bytes", "bytes", "Local memory bandwidth used, per NUMA node.")
+		for i, s := range *r.MBMStats {
+			node := strconv.Itoa(i)
+			m.addUint(total, s.MBMTotalBytes, "node", node)
+			m.addUint(local, s.MBMLocalBytes, "node", node)
+		}
+	}
+	if r.CMTStats != nil {
+		f := m.gauge("runc_intel_rdt_llc_occupancy_bytes", "bytes", "Last level cache occupancy, per NUMA node.")
+		for i, s := range *r.CMTStats {
+			m.addUint(f, s.LLCOccupancy, "node", strconv.Itoa(i))
+		}
+	}
+}
+
+func (m *metricsWriter) addNetworkStats(ifaces []*types.NetworkInterface) {
+	rxBytes := m.counter("runc_network_receive_bytes", "bytes", "Number of bytes received.")
+	rxPackets := m.counter("runc_network_receive_packets", "", "Number of packets received.")
+	rxErrors := m.counter("runc_network_receive_errors", "", "Number of receive errors.")
+	rxDropped := m.counter("runc_network_receive_dropped", "", "Number of received packets dropped.")
+	txBytes := m.counter("runc_network_transmit_bytes", "bytes", "Number of bytes transmitted.")
+	txPackets := m.counter("runc_network_transmit_packets", "", "Number of packets transmitted.")
+	txErrors := m.counter("runc_network_transmit_errors", "", "Number of transmit errors.")
+	txDropped := m.counter("runc_network_transmit_dropped", "", "Number of transmitted packets dropped.")
+	for _, i := range ifaces {
+		m.addUint(rxBytes, i.RxBytes, "interface", i.Name)
+		m.addUint(rxPackets, i.RxPackets, "interface", i.Name)
+		m.addUint(rxErrors, i.RxErrors, "interface", i.Name)
+		m.addUint(rxDropped, i.RxDropped, "interface", i.Name)
+		m.addUint(txBytes, i.TxBytes, "interface", i.Name)
+		m.addUint(txPackets, i.TxPackets, "interface", i.Name)
+		m.addUint(txErrors, i.TxErrors, "interface", i.Name)
+		m.addUint(txDropped, i.TxDropped, "interface", i.Name)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/runc/types"
+)
+
+func TestMetricsWriter(t *testing.T) {
+	m := newMetricsWriter("test", []string{"bundle=/some/path", "a.b=\"x\"\ny"})
+	m.addStats(&types.Stats{
+		CPU: types.Cpu{
+			Usage: types.CpuUsage{Total: 1500000000, Percpu: []uint64{1000000000, 500000000}},
+		},
+		Memory: types.Memory{
+			Usage: types.MemoryEntry{Usage: 4096, Limit: 8192},
+		},
+		Pids: types.Pids{Current: 3},
+		NetworkInterfaces: []*types.NetworkInterface{
+			{Name: "eth0", RxBytes: 100},
+		},
+	})
+	var b strings.Builder
+	if _, err := m.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	const labels = `id="test",label_bundle="/some/path",label_a_b="\"x\"\ny"`
+	for _, want := range []string{
+		"# TYPE runc_cpu_usage_seconds counter\n# UNIT runc_cpu_usage_seconds seconds\n",
+		"runc_cpu_usage_seconds_total{" + labels + "} 1.5\n",
+		"runc_cpu_usage_per_cpu_seconds_total{" + labels + `,cpu="1"} 0.5` + "\n",
+		"runc_memory_usage_bytes{" + labels + `,type="memory"} 4096` + "\n",
+		"runc_memory_limit_bytes{" + labels + `,type="memory"} 8192` + "\n",
+		"runc_pids_current{" + labels + "} 3\n",
+		"runc_network_receive_bytes_total{" + labels + `,interface="eth0"} 100` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{
+		// Families without samples.
+		"runc_pids_limit",
+		"runc_pressure_avg10",
+		`type="swap"`,
+	} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, out)
+		}
+	}
+	if !strings.HasSuffix(out, "\n# EOF\n") {
+		t.Errorf("expected output to end with # EOF, got:\n%s", out)
+	}
+}
@@ -10,14 +10,63 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/moby/sys/capability"
+	"github.com/moby/sys/user"
 	"github.com/urfave/cli/v3"
+
+	"github.com/opencontainers/runc/internal/pathrs"
+	"github.com/opencontainers/runc/libcontainer/system"
 )
 
+// clockTicks is the number of clock ticks per second (USER_HZ), which is
+// used for the times in /proc/[pid]/stat, and is 100 on all architectures
+// Linux supports.
+const clockTicks = 100
+
+// processInfo describes a process running inside a container.
+type processInfo struct {
+	// PID is the process ID in the host PID namespace.
+	PID int `json:"pid"`
+	// ContainerPID is the process ID in the container PID namespace.
+	ContainerPID int `json:"containerPid"`
+	// PPID is the parent process ID in the host PID namespace.
+	PPID int `json:"ppid"`
+	// UID and GID are the effective user and group IDs, as seen inside the
+	// container.
+	UID int `json:"uid"`
+	GID int `json:"gid"`
+	// State is the process state, as in proc(5).
+	State string `json:"state"`
+	// StartedAt is the time when the process was started.
+	StartedAt time.Time `json:"startedAt"`
+	// RSS is the resident set size, in bytes.
+	RSS uint64 `json:"rss"`
+	// CPUTime is the CPU time consumed by the process, in seconds.
+	CPUTime float64 `json:"cpuTime"`
+	// Capabilities are the effective capabilities of the process.
+	Capabilities []string `json:"capabilities"`
+	// Cmdline is the full command line of the process. It is empty for
+	// zombies.
+	Cmdline []string `json:"cmdline"`
+
+	// state is State in the short form, for the table format.
+	state system.State
+	// name is the process name, used if Cmdline is empty.
+	name string
+}
+
 var psCommand = &cli.Command{
 	Name:      "ps",
 	Usage:     "ps displays the processes running inside a container",
 	ArgsUsage: `<container-id> [ps options]`,
+	Description: `The ps command displays the processes running inside a container, based
+on the information from /proc.
+
+If any ps options are given, the host ps(1) utility is used instead, and its
+output is filtered to only contain the container processes.`,
 	// Stop parsing flags after the first positional argument (the container ID).
 	// This allows passing flags like -aux to the underlying ps command.
 	StopOnNthArg: mkPtr(1),
@@ -28,7 +77,7 @@ var psCommand = &cli.Command{
 			Name:    "format",
 			Aliases: []string{"f"},
 			Value:   "table",
-			Usage:   `select one of: ` + formatOptions,
+			Usage:   `select one of: ` + formatOptions + `, or json-detailed`,
 		},
 	},
 	Action: func(_ context.Context, cmd *cli.Command) error {
@@ -47,8 +96,9 @@ var psCommand = &cli.Command{
 			return err
 		}
 
-		switch cmd.String("format") {
-		case "table":
+		format := cmd.String("format")
+		switch format {
+		case "table", "json-detailed":
 		case "json":
 			return json.NewEncoder(os.Stdout).Encode(pids)
 		default:
@@ -59,42 +109,85 @@ var psCommand = &cli.Command{
 		// cmd.Args(): [container_id ps_arg1 ps_arg2 ...]
 		// psArgs:         [ps_arg1 ps_arg2 ...]
 		//
-		psArgs := cmd.Args().Slice()[1:]
-		if len(psArgs) == 0 {
-			psArgs = []string{"-ef"}
-		}
-
-		cmdExec := exec.Command("ps", psArgs...)
-		output, err := cmdExec.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("%w: %s", err, output)
+		if psArgs := cmd.Args().Slice()[1:]; len(psArgs) > 0 {
+			if format == "json-detailed" {
+				return errors.New("ps options can not be used with json-detailed format")
+			}
+			return execPs(psArgs, pids)
 		}
 
-		lines := strings.Split(string(output), "\n")
-		pidIndex, err := getPidIndex(lines[0])
+		bootTime, err := getBootTime()
 		if err != nil {
 			return err
 		}
-
-		fmt.Println(lines[0])
-		for _, line := range lines[1:] {
-			if len(line) == 0 {
-				continue
-			}
-			fields := strings.Fields(line)
-			p, err := strconv.Atoi(fields[pidIndex])
+		procs := make([]*processInfo, 0, len(pids))
+		for _, pid := range pids {
+			p, err := getProcessInfo(pid, bootTime)
 			if err != nil {
-				return fmt.Errorf("unable to parse pid: %w", err)
+				// The process might have exited in the meantime.
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return fmt.Errorf("unable to get process %d info: %w", pid, err)
 			}
+			procs = append(procs, p)
+		}
 
-			if slices.Contains(pids, p) {
-				fmt.Println(line)
-			}
+		if format == "json-detailed" {
+			return json.NewEncoder(os.Stdout).Encode(procs)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 1, 1, 2, ' ', 0)
+		fmt.Fprint(w, "PID\tCPID\tPPID\tUID\tGID\tSTAT\tSTARTED\tRSS\tTIME\tCMD\n")
+		for _, p := range procs {
+			fmt.Fprintf(w, "%d\t%d\t%d\t%d\t%d\t%c\t%s\t%d\t%s\t%s\n",
+				p.PID,
+				p.ContainerPID,
+				p.PPID,
+				p.UID,
+				p.GID,
+				p.state,
+				p.StartedAt.Local().Format(time.DateTime),
+				p.RSS/1024,
+				formatCPUTime(p.CPUTime),
+				p.command())
 		}
-		return nil
+		return w.Flush()
 	},
 }
 
+// execPs runs ps(1) with the given args, and prints its output lines
+// describing the given pids.
+func execPs(psArgs []string, pids []int) error {
+	cmdExec := exec.Command("ps", psArgs...)
+	output, err := cmdExec.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, output)
+	}
+
+	lines := strings.Split(string(output), "\n")
+	pidIndex, err := getPidIndex(lines[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(lines[0])
+	for _, line := range lines[1:] {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Fields(line)
+		p, err := strconv.Atoi(fields[pidIndex])
+		if err != nil {
+			return fmt.Errorf("unable to parse pid: %w", err)
+		}
+
+		if slices.Contains(pids, p) {
+			fmt.Println(line)
+		}
+	}
+	return nil
+}
+
 func getPidIndex(title string) (int, error) {
 	titles := strings.Fields(title)
 
@@ -107,3 +200,117 @@ func getPidIndex(title string) (int, error) {
 
 	return pidIndex, errors.New("couldn't find PID field in ps output")
 }
+
+// getProcessInfo reads the information about the process from /proc.
+func getProcessInfo(pid int, bootTime time.Time) (*processInfo, error) {
+	stat, err := system.Stat(pid)
+	if err != nil {
+		return nil, err
+	}
+	status, err := system.Status(pid)
+	if err != nil {
+		return nil, err
+	}
+	cmdline, err := system.Cmdline(pid)
+	if err != nil {
+		return nil, err
+	}
+	p := &processInfo{
+		PID:          pid,
+		ContainerPID: pid,
+		PPID:         stat.PPid,
+		UID:          int(status.Uid[1]),
+		GID:          int(status.Gid[1]),
+		State:        stat.State.String(),
+		StartedAt:    bootTime.Add(time.Duration(stat.StartTime) * time.Second / clockTicks).UTC(),
+		RSS:          stat.RSS * uint64(os.Getpagesize()),
+		CPUTime:      float64(stat.UTime+stat.STime) / clockTicks,
+		Capabilities: capabilityNames(status.CapEff),
+		Cmdline:      cmdline,
+		state:        stat.State,
+		name:         stat.Name,
+	}
+	if len(status.NSpid) > 0 {
+		// The last one is the PID in the innermost PID namespace.
+		p.ContainerPID = status.NSpid[len(status.NSpid)-1]
+	}
+	// Translate the IDs to the ones in the container user namespace, if any.
+	if uidMap, err := readIDMap(pid, "uid_map"); err == nil {
+		p.UID = containerID(status.Uid[1], uidMap)
+	}
+	if gidMap, err := readIDMap(pid, "gid_map"); err == nil {
+		p.GID = containerID(status.Gid[1], gidMap)
+	}
+	return p, nil
+}
+
+// command returns the process command line, or its name in square brackets
+// if the command line is not available (as ps(1) does).
+func (p *processInfo) command() string {
+	if len(p.Cmdline) == 0 {
+		return "[" + p.name + "]"
+	}
+	return strings.Join(p.Cmdline, " ")
+}
+
+// getBootTime returns the system boot time, from /proc/stat.
+func getBootTime() (time.Time, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, err
+	}
+	for line := range strings.Lines(string(data)) {
+		if v, ok := strings.CutPrefix(line, "btime "); ok {
+			btime, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid btime in /proc/stat: %w", err)
+			}
+			return time.Unix(btime, 0), nil
+		}
+	}
+	return time.Time{}, errors.New("no btime in /proc/stat")
+}
+
+func readIDMap(pid int, name string) ([]user.IDMap, error) {
+	f, err := pathrs.ProcPidOpen(pid, name, os.O_RDONLY)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return user.ParseIDMap(f)
+}
+
+// containerID translates a host ID to the ID in a user namespace with the
+// given ID mapping. Unmapped IDs are shown as the overflow ID, as the kernel
+// does.
+func containerID(hostID uint32, idMap []user.IDMap) int {
+	id := int64(hostID)
+	for _, m := range idMap {
+		if id >= m.ParentID && id < m.ParentID+m.Count {
+			return int(m.ID + id - m.ParentID)
+		}
+	}
+	return 65534
+}
+
+// capabilityNames returns the names of the capabilities in the mask.
+func capabilityNames(mask uint64) []string {
+	names := []string{}
+	for _, c := range capability.ListKnown() {
+		if c < 64 && mask&(1<<uint(c)) != 0 {
+			names = append(names, "CAP_"+strings.ToUpper(c.String()))
+		}
+	}
+	return names
+}
+
+// formatCPUTime formats the CPU time in seconds as [DD-]HH:MM:SS, as ps(1) does.
+func formatCPUTime(secs float64) string {
+	s := int64(secs)
+	days, s := s/86400, s%86400
+	t := fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+	if days > 0 {
+		t = fmt.Sprintf("%d-%s", days, t)
+	}
+	return t
+}
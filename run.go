@@ -27,7 +27,7 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 "runc spec --help" for more explanation.`,
 	// Disable comma as separator for slice flags.
 	DisableSliceFlagSeparator: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "bundle",
 			Aliases: []string{"b"},
@@ -73,11 +73,20 @@ command(s) that get executed on start, edit the args parameter of the spec. See
 			Name:  "preserve-fds",
 			Usage: "Pass N additional file descriptors to the container (stdio + $LISTEN_FDS + N in total)",
 		},
-	},
+	}, monitorFlags...),
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 1, exactArgs); err != nil {
 			return err
 		}
+		if ok, err := useMonitor(cmd, cmd.Bool("detach")); err != nil {
+			return err
+		} else if ok && !isMonitor() {
+			status, err := spawnMonitor(cmd)
+			if err == nil {
+				os.Exit(status)
+			}
+			return fmt.Errorf("runc run failed: %w", err)
+		}
 		status, err := startContainer(cmd, CT_ACT_RUN, nil)
 		if err == nil {
 			// exit with the container's exit status so any external supervisor is
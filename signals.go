@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 	"os/signal"
 
@@ -38,10 +39,10 @@ func newSignalHandler() chan *signalHandler {
 }
 
 // exit models a process exit status with the pid and
-// exit status.
+// wait status.
 type exit struct {
-	pid    int
-	status int
+	pid int
+	ws  unix.WaitStatus
 }
 
 type signalHandler struct {
@@ -49,13 +50,13 @@ type signalHandler struct {
 }
 
 // forward handles the main signal event loop forwarding, resizing, or reaping depending
-// on the signal received.
-func (h *signalHandler) forward(process *libcontainer.Process, tty *tty) (int, error) {
+// on the signal received. It returns the wait status of the process once it exits.
+func (h *signalHandler) forward(process *libcontainer.Process, tty *tty) (unix.WaitStatus, error) {
 	// make sure we know the pid of our main process so that we can return
 	// after it dies.
 	pid1, err := process.Pid()
 	if err != nil {
-		return -1, err
+		return 0, err
 	}
 
 	// Perform the initial tty resize. Always ignore errors resizing because
@@ -75,14 +76,14 @@ func (h *signalHandler) forward(process *libcontainer.Process, tty *tty) (int, e
 			for _, e := range exits {
 				logrus.WithFields(logrus.Fields{
 					"pid":    e.pid,
-					"status": e.status,
+					"status": utils.ExitStatus(e.ws),
 				}).Debug("process exited")
 				if e.pid == pid1 {
 					// call Wait() on the process even though we already have the exit
 					// status because we must ensure that any of the go specific process
 					// fun such as flushing pipes are complete before we return.
 					_, _ = process.Wait()
-					return e.status, nil
+					return e.ws, nil
 				}
 			}
 		case unix.SIGURG:
@@ -98,7 +99,7 @@ func (h *signalHandler) forward(process *libcontainer.Process, tty *tty) (int, e
 			}
 		}
 	}
-	return -1, nil
+	return 0, errors.New("signal channel closed")
 }
 
 // reap runs wait4 in a loop until we have finished processing any existing exits
@@ -120,8 +121,8 @@ func (h *signalHandler) reap() (exits []exit, err error) {
 			return exits, nil
 		}
 		exits = append(exits, exit{
-			pid:    pid,
-			status: utils.ExitStatus(ws),
+			pid: pid,
+			ws:  ws,
 		})
 	}
 }
@@ -47,6 +47,10 @@ In the run command above, "container1" is the name for the instance of the
 container that you are starting. The name you provide for the container instance
 must be unique on your host.
 
+Alternatively, if the image is available in a local OCI image layout, the bundle
+(both the root filesystem and the spec) can be created by "runc bundle create",
+see "runc bundle create --help".
+
 An alternative for generating a customized spec config is to use "oci-runtime-tool", the
 sub-command "oci-runtime-tool generate" has lots of options that can be used to do any
 customizations as you want, see runtime-tools (https://github.com/opencontainers/runtime-tools)
@@ -64,10 +68,16 @@ generate a proper rootless spec file.
 
 Note that --rootless is not needed when you execute runc as the root in a user namespace
 created by an unprivileged user.
+
+The generated spec can be customized with the options such as --args, --env,
+--mount, and so on. With --from, an existing specification file is patched
+instead, for example, to add a mount and an environment variable in place:
+
+    runc spec --from ` + specConfig + ` --mount /srv/data:/data:ro --env DEBUG=1
 `,
 	// Disable comma as separator for slice flags.
 	DisableSliceFlagSeparator: true,
-	Flags: []cli.Flag{
+	Flags: append([]cli.Flag{
 		&cli.StringFlag{
 			Name:    "bundle",
 			Aliases: []string{"b"},
@@ -78,21 +88,43 @@ created by an unprivileged user.
 			Name:  "rootless",
 			Usage: "generate a configuration for a rootless container",
 		},
-	},
+	}, specGenFlags...),
 	Action: func(_ context.Context, cmd *cli.Command) error {
 		if err := checkArgs(cmd, 0, exactArgs); err != nil {
 			return err
 		}
-		spec := specconv.Example()
+		var (
+			spec *specs.Spec
+			from os.FileInfo
+			err  error
+		)
+		if path := cmd.String("from"); path != "" {
+			if spec, err = loadSpec(path); err != nil {
+				return err
+			}
+			// Stat it before chdir to the bundle, as the path may be relative.
+			if from, err = os.Stat(path); err != nil {
+				return err
+			}
+		} else {
+			spec = specconv.Example()
+		}
 
 		rootless := cmd.Bool("rootless")
 		if rootless {
 			specconv.ToRootless(spec)
 		}
+		if err := applySpecGenFlags(cmd, spec); err != nil {
+			return err
+		}
 
 		checkNoFile := func(name string) error {
-			_, err := os.Stat(name)
+			fi, err := os.Stat(name)
 			if err == nil {
+				if from != nil && os.SameFile(fi, from) {
+					// Patch the spec in place.
+					return nil
+				}
 				return fmt.Errorf("File %s exists. Remove it first", name)
 			}
 			if !errors.Is(err, os.ErrNotExist) {
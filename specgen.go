@@ -0,0 +1,295 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-units"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli/v3"
+
+	"github.com/opencontainers/runc/libcontainer/capabilities"
+)
+
+// cpuPeriod is the CPU CFS period set by runc spec --cpus.
+const cpuPeriod = 100000
+
+// specGenFlags are the runc spec flags to customize the generated spec.
+var specGenFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "from",
+		Usage: "patch an existing specification file, rather than the default one",
+	},
+	&cli.StringSliceFlag{
+		Name:  "args",
+		Usage: "set the container process arguments (repeat for each argument)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "env",
+		Usage: "set an environment variable of the container process, as KEY=VALUE (can be repeated)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "mount",
+		Usage: "add a mount, as SOURCE:DESTINATION[:OPTIONS]; an absolute SOURCE path is bind-mounted, otherwise SOURCE is a filesystem type (can be repeated)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "cap-add",
+		Usage: "add a capability, or ALL (can be repeated)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "cap-drop",
+		Usage: "drop a capability, or ALL (can be repeated)",
+	},
+	&cli.StringFlag{
+		Name:  "memory",
+		Usage: "set the memory limit (in bytes, or with a unit suffix such as 512m)",
+	},
+	&cli.FloatFlag{
+		Name:  "cpus",
+		Usage: "set the number of CPUs the container can use (may be fractional)",
+	},
+	&cli.BoolFlag{
+		Name:  "readonly",
+		Usage: "make the container root filesystem read-only (use --readonly=false to make it writable)",
+	},
+	&cli.StringFlag{
+		Name:  "hostname",
+		Usage: "set the container hostname (implies a new UTS namespace)",
+	},
+	&cli.StringSliceFlag{
+		Name:  "userns-map",
+		Usage: "add a user and group ID mapping, as CONTAINERID:HOSTID:SIZE (implies a new user namespace; can be repeated)",
+	},
+	&cli.StringFlag{
+		Name:  "seccomp-profile",
+		Usage: "set the seccomp profile, from a JSON file in the format of the linux.seccomp field of the spec",
+	},
+	&cli.StringSliceFlag{
+		Name:  "annotation",
+		Usage: "set an annotation, as KEY=VALUE (can be repeated)",
+	},
+}
+
+// applySpecGenFlags updates spec according to the specGenFlags set.
+func applySpecGenFlags(cmd *cli.Command, spec *specs.Spec) error {
+	if spec.Process == nil && (cmd.IsSet("args") || cmd.IsSet("env") || cmd.IsSet("cap-add") || cmd.IsSet("cap-drop")) {
+		spec.Process = &specs.Process{}
+	}
+	if cmd.IsSet("args") {
+		spec.Process.Args = cmd.StringSlice("args")
+	}
+	for _, env := range cmd.StringSlice("env") {
+		key, _, ok := strings.Cut(env, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --env %q: must be KEY=VALUE", env)
+		}
+		spec.Process.Env = slices.DeleteFunc(spec.Process.Env, func(e string) bool {
+			return strings.HasPrefix(e, key+"=")
+		})
+		spec.Process.Env = append(spec.Process.Env, env)
+	}
+	for _, m := range cmd.StringSlice("mount") {
+		mount, err := parseMountFlag(m)
+		if err != nil {
+			return err
+		}
+		spec.Mounts = append(spec.Mounts, mount)
+	}
+	if err := applyCapFlags(cmd, spec.Process); err != nil {
+		return err
+	}
+
+	if cmd.IsSet("memory") || cmd.IsSet("cpus") {
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		if spec.Linux.Resources == nil {
+			spec.Linux.Resources = &specs.LinuxResources{}
+		}
+	}
+	if cmd.IsSet("memory") {
+		limit, err := units.RAMInBytes(cmd.String("memory"))
+		if err != nil {
+			return fmt.Errorf("invalid --memory: %w", err)
+		}
+		if spec.Linux.Resources.Memory == nil {
+			spec.Linux.Resources.Memory = &specs.LinuxMemory{}
+		}
+		spec.Linux.Resources.Memory.Limit = &limit
+	}
+	if cmd.IsSet("cpus") {
+		cpus := cmd.Float("cpus")
+		if cpus <= 0 {
+			return errors.New("invalid --cpus: must be positive")
+		}
+		if spec.Linux.Resources.CPU == nil {
+			spec.Linux.Resources.CPU = &specs.LinuxCPU{}
+		}
+		period := uint64(cpuPeriod)
+		quota := int64(cpus * cpuPeriod)
+		spec.Linux.Resources.CPU.Period = &period
+		spec.Linux.Resources.CPU.Quota = &quota
+	}
+
+	if cmd.IsSet("readonly") {
+		if spec.Root == nil {
+			spec.Root = &specs.Root{Path: "rootfs"}
+		}
+		spec.Root.Readonly = cmd.Bool("readonly")
+	}
+	if cmd.IsSet("hostname") {
+		spec.Hostname = cmd.String("hostname")
+		addNamespace(spec, specs.UTSNamespace)
+	}
+	if cmd.IsSet("userns-map") {
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		for _, m := range cmd.StringSlice("userns-map") {
+			idMap, err := parseIDMapFlag(m)
+			if err != nil {
+				return err
+			}
+			spec.Linux.UIDMappings = append(spec.Linux.UIDMappings, idMap)
+			spec.Linux.GIDMappings = append(spec.Linux.GIDMappings, idMap)
+		}
+		addNamespace(spec, specs.UserNamespace)
+	}
+	if path := cmd.String("seccomp-profile"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		var seccomp *specs.LinuxSeccomp
+		if err := json.Unmarshal(data, &seccomp); err != nil {
+			return fmt.Errorf("unable to parse seccomp profile %s: %w", path, err)
+		}
+		if spec.Linux == nil {
+			spec.Linux = &specs.Linux{}
+		}
+		spec.Linux.Seccomp = seccomp
+	}
+	for _, a := range cmd.StringSlice("annotation") {
+		key, value, ok := strings.Cut(a, "=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid --annotation %q: must be KEY=VALUE", a)
+		}
+		if spec.Annotations == nil {
+			spec.Annotations = make(map[string]string)
+		}
+		spec.Annotations[key] = value
+	}
+	return nil
+}
+
+// parseMountFlag parses the --mount value, SOURCE:DESTINATION[:OPTIONS].
+func parseMountFlag(m string) (specs.Mount, error) {
+	parts := strings.SplitN(m, ":", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return specs.Mount{}, fmt.Errorf("invalid --mount %q: must be SOURCE:DESTINATION[:OPTIONS]", m)
+	}
+	mount := specs.Mount{
+		Source:      parts[0],
+		Destination: parts[1],
+	}
+	if filepath.IsAbs(mount.Source) {
+		mount.Type = "bind"
+		mount.Options = []string{"rbind"}
+	} else {
+		mount.Type = mount.Source
+	}
+	if len(parts) == 3 && parts[2] != "" {
+		mount.Options = append(mount.Options, strings.Split(parts[2], ",")...)
+	}
+	return mount, nil
+}
+
+// parseIDMapFlag parses the --userns-map value, CONTAINERID:HOSTID:SIZE.
+func parseIDMapFlag(m string) (specs.LinuxIDMapping, error) {
+	var ids [3]uint32
+	parts := strings.Split(m, ":")
+	if len(parts) != len(ids) {
+		return specs.LinuxIDMapping{}, fmt.Errorf("invalid --userns-map %q: must be CONTAINERID:HOSTID:SIZE", m)
+	}
+	for i, p := range parts {
+		id, err := strconv.ParseUint(p, 10, 32)
+		if err != nil {
+			return specs.LinuxIDMapping{}, fmt.Errorf("invalid --userns-map %q: %w", m, err)
+		}
+		ids[i] = uint32(id)
+	}
+	return specs.LinuxIDMapping{ContainerID: ids[0], HostID: ids[1], Size: ids[2]}, nil
+}
+
+// applyCapFlags applies --cap-drop and then --cap-add to the bounding,
+// effective, and permitted capability sets of p, and drops the removed
+// capabilities from the other sets.
+func applyCapFlags(cmd *cli.Command, p *specs.Process) error {
+	if !cmd.IsSet("cap-add") && !cmd.IsSet("cap-drop") {
+		return nil
+	}
+	known := capabilities.KnownCapabilities()
+	parse := func(flag string) ([]string, error) {
+		var caps []string
+		for _, c := range cmd.StringSlice(flag) {
+			c = strings.ToUpper(c)
+			if c == "ALL" {
+				caps = append(caps, known...)
+				continue
+			}
+			if !strings.HasPrefix(c, "CAP_") {
+				c = "CAP_" + c
+			}
+			if !slices.Contains(known, c) {
+				return nil, fmt.Errorf("invalid --%s: unknown capability %q", flag, c)
+			}
+			caps = append(caps, c)
+		}
+		return caps, nil
+	}
+	drop, err := parse("cap-drop")
+	if err != nil {
+		return err
+	}
+	add, err := parse("cap-add")
+	if err != nil {
+		return err
+	}
+
+	if p.Capabilities == nil {
+		p.Capabilities = &specs.LinuxCapabilities{}
+	}
+	c := p.Capabilities
+	for _, set := range []*[]string{&c.Bounding, &c.Effective, &c.Permitted, &c.Inheritable, &c.Ambient} {
+		*set = slices.DeleteFunc(*set, func(name string) bool {
+			return slices.Contains(drop, name)
+		})
+	}
+	for _, set := range []*[]string{&c.Bounding, &c.Effective, &c.Permitted} {
+		for _, name := range add {
+			if !slices.Contains(*set, name) {
+				*set = append(*set, name)
+			}
+		}
+	}
+	return nil
+}
+
+// addNamespace adds a namespace of type t to spec, unless it is there.
+func addNamespace(spec *specs.Spec, t specs.LinuxNamespaceType) {
+	if spec.Linux == nil {
+		spec.Linux = &specs.Linux{}
+	}
+	for _, ns := range spec.Linux.Namespaces {
+		if ns.Type == t {
+			return
+		}
+	}
+	spec.Linux.Namespaces = append(spec.Linux.Namespaces, specs.LinuxNamespace{Type: t})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli/v3"
+
+	"github.com/opencontainers/runc/libcontainer/specconv"
+)
+
+// runSpecGen applies the given runc spec flags to spec.
+func runSpecGen(spec *specs.Spec, args ...string) error {
+	cmd := &cli.Command{
+		Name:                      "spec",
+		Flags:                     specGenFlags,
+		DisableSliceFlagSeparator: true,
+		Action: func(_ context.Context, cmd *cli.Command) error {
+			return applySpecGenFlags(cmd, spec)
+		},
+	}
+	return cmd.Run(context.Background(), append([]string{"spec"}, args...))
+}
+
+func TestApplySpecGenFlags(t *testing.T) {
+	spec := specconv.Example()
+	err := runSpecGen(spec,
+		"--args", "sh", "--args", "-c", "--args", "echo a,b",
+		"--env", "TERM=dumb",
+		"--mount", "/src:/dst:ro,nosuid",
+		"--mount", "tmpfs:/run",
+		"--cap-drop", "kill",
+		"--cap-add", "CAP_SYS_ADMIN",
+		"--memory", "1g",
+		"--cpus", "0.5",
+		"--readonly=false",
+		"--hostname", "test",
+		"--userns-map", "0:1000:1",
+		"--annotation", "key=value=1",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(spec.Process.Args, []string{"sh", "-c", "echo a,b"}) {
+		t.Errorf("unexpected args: %q", spec.Process.Args)
+	}
+	if !slices.Contains(spec.Process.Env, "TERM=dumb") || slices.Contains(spec.Process.Env, "TERM=xterm") {
+		t.Errorf("unexpected env: %q", spec.Process.Env)
+	}
+	mounts := spec.Mounts[len(spec.Mounts)-2:]
+	if m := mounts[0]; m.Type != "bind" || m.Source != "/src" || m.Destination != "/dst" || !slices.Equal(m.Options, []string{"rbind", "ro", "nosuid"}) {
+		t.Errorf("unexpected bind mount: %+v", m)
+	}
+	if m := mounts[1]; m.Type != "tmpfs" || m.Source != "tmpfs" || m.Destination != "/run" || m.Options != nil {
+		t.Errorf("unexpected tmpfs mount: %+v", m)
+	}
+	caps := spec.Process.Capabilities
+	for _, set := range [][]string{caps.Bounding, caps.Effective, caps.Permitted} {
+		if slices.Contains(set, "CAP_KILL") || !slices.Contains(set, "CAP_SYS_ADMIN") {
+			t.Errorf("unexpected capabilities: %q", set)
+		}
+	}
+	res := spec.Linux.Resources
+	if *res.Memory.Limit != 1<<30 || *res.CPU.Quota != 50000 || *res.CPU.Period != 100000 {
+		t.Errorf("unexpected resources: %+v %+v", res.Memory, res.CPU)
+	}
+	if spec.Root.Readonly {
+		t.Error("expected a writable rootfs")
+	}
+	if spec.Hostname != "test" {
+		t.Errorf("unexpected hostname: %q", spec.Hostname)
+	}
+	if len(spec.Linux.UIDMappings) != 1 || spec.Linux.GIDMappings[0] != (specs.LinuxIDMapping{ContainerID: 0, HostID: 1000, Size: 1}) {
+		t.Errorf("unexpected ID mappings: %+v %+v", spec.Linux.UIDMappings, spec.Linux.GIDMappings)
+	}
+	if !slices.Contains(spec.Linux.Namespaces, specs.LinuxNamespace{Type: specs.UserNamespace}) {
+		t.Errorf("expected a user namespace: %+v", spec.Linux.Namespaces)
+	}
+	if spec.Annotations["key"] != "value=1" {
+		t.Errorf("unexpected annotations: %v", spec.Annotations)
+	}
+}
+
+func TestApplySpecGenFlagsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"--env", "NOVALUE"},
+		{"--mount", "/src"},
+		{"--cap-add", "CAP_NO_SUCH_CAP"},
+		{"--memory", "lots"},
+		{"--cpus", "0"},
+		{"--userns-map", "0:1000"},
+		{"--annotation", "=value"},
+	} {
+		if err := runSpecGen(specconv.Example(), args...); err == nil {
+			t.Errorf("%q: expected an error, got nil", args)
+		}
+	}
+}
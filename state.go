@@ -50,6 +50,7 @@ instance of a container.`,
 			Rootfs:         state.BaseState.Config.Rootfs,
 			Created:        state.BaseState.Created,
 			Annotations:    annotations,
+			ExitStatus:     convertExitStatus(state.ExitStatus),
 		}
 		data, err := json.MarshalIndent(cs, "", "  ")
 		if err != nil {
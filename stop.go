@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v3"
+)
+
+// exitUnknownCode is the exit code of runc stop if the container is stopped,
+// but the exit status of its init is unknown.
+const exitUnknownCode = 255
+
+var stopCommand = &cli.Command{
+	Name:  "stop",
+	Usage: "stop gracefully stops the container, killing it after a timeout",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.
+
+EXAMPLE:
+For example, to give the container "ubuntu01" a minute to handle SIGINT
+before killing it:
+
+       # runc stop --signal INT --timeout 1m ubuntu01`,
+	Description: `The stop command sends a signal (SIGTERM by default) to the container's
+init process, or to all the container processes if the container does not
+have its own PID namespace, and waits for init to exit. If it has not exited
+within the timeout, all the container processes are killed with SIGKILL.
+
+Once the container is stopped, runc stop exits with the exit code of the
+container's init, if it is known, or with status 255 otherwise.`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "signal",
+			Aliases: []string{"s"},
+			Value:   "SIGTERM",
+			Usage:   "signal to send to the container first",
+		},
+		&cli.DurationFlag{
+			Name:    "timeout",
+			Aliases: []string{"t"},
+			Value:   10 * time.Second,
+			Usage:   "time to wait for the container to stop before killing it (negative to wait indefinitely)",
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		if err := checkArgs(cmd, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(cmd)
+		if err != nil {
+			return err
+		}
+		signal, err := parseSignal(cmd.String("signal"))
+		if err != nil {
+			return err
+		}
+		s, err := container.Stop(signal, cmd.Duration("timeout"))
+		if err != nil {
+			if errors.Is(err, libcontainer.ErrExitUnknown) {
+				logrus.Warnf("container %s is stopped, but its exit status is unknown", container.ID())
+				os.Exit(exitUnknownCode)
+			}
+			return err
+		}
+		os.Exit(s.Code)
+		return nil
+	},
+}
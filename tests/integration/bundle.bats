@@ -0,0 +1,111 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+# add_blob adds the file $2 to the image layout at $1 as a blob of type $3,
+# and prints its descriptor.
+function add_blob() {
+	local digest size
+	digest="$(sha256sum "$2" | cut -d' ' -f1)"
+	size="$(stat -c %s "$2")"
+	mkdir -p "$1/blobs/sha256"
+	cp "$2" "$1/blobs/sha256/$digest"
+	jq -cn --arg t "$3" --arg d "sha256:$digest" --argjson s "$size" \
+		'{"mediaType": $t, "digest": $d, "size": $s}'
+}
+
+# make_image creates an image layout at $1 with the busybox rootfs (and one
+# more layer) as the image tagged $2.
+function make_image() {
+	local layout="$1" tag="$2" layer1 layer2 config manifest
+
+	tar -C rootfs -czf layer1.tar.gz .
+	mkdir -p layer2/etc layer2/srv
+	touch layer2/etc/.wh.group layer2/srv/data
+	tar -C layer2 -cf layer2.tar .
+	layer1="$(add_blob "$layout" layer1.tar.gz application/vnd.oci.image.layer.v1.tar+gzip)"
+	layer2="$(add_blob "$layout" layer2.tar application/vnd.oci.image.layer.v1.tar)"
+
+	jq -n '{
+		"architecture": "amd64", "os": "linux",
+		"config": {
+			"Entrypoint": ["/bin/echo"], "Cmd": ["hello"],
+			"Env": ["FOO=bar"], "WorkingDir": "/srv", "User": "1000:1000",
+			"ExposedPorts": {"80/tcp": {}}, "StopSignal": "SIGINT"
+		}
+	}' >config.json.tmp
+	config="$(add_blob "$layout" config.json.tmp application/vnd.oci.image.config.v1+json)"
+
+	jq -n --argjson c "$config" --argjson l1 "$layer1" --argjson l2 "$layer2" \
+		'{"schemaVersion": 2, "mediaType": "application/vnd.oci.image.manifest.v1+json", "config": $c, "layers": [$l1, $l2]}' >manifest.json
+	manifest="$(add_blob "$layout" manifest.json application/vnd.oci.image.manifest.v1+json)"
+
+	echo '{"imageLayoutVersion": "1.0.0"}' >"$layout/oci-layout"
+	jq -n --argjson m "$manifest" --arg tag "$tag" \
+		'{"schemaVersion": 2, "manifests": [$m | .annotations = {"org.opencontainers.image.ref.name": $tag}]}' >"$layout/index.json"
+}
+
+@test "runc bundle create" {
+	requires root
+	make_image "$(pwd)/image" v1
+	mkdir new
+
+	runc bundle create --image "$(pwd)/image:v1" --bundle new
+	[ "$status" -eq 0 ]
+
+	[ -x new/rootfs/bin/echo ]
+	[ -e new/rootfs/srv/data ]
+	# Removed by a whiteout.
+	[ ! -e new/rootfs/etc/group ]
+
+	[ "$(jq -c .process.args new/config.json)" = '["/bin/echo","hello"]' ]
+	[ "$(jq -r .process.cwd new/config.json)" = "/srv" ]
+	[ "$(jq -c .process.user new/config.json)" = '{"uid":1000,"gid":1000}' ]
+	jq -e '.process.env | index("FOO=bar")' new/config.json
+	[ "$(jq -r '.annotations."org.opencontainers.image.exposedPorts"' new/config.json)" = "80/tcp" ]
+	[ "$(jq -r '.annotations."org.opencontainers.image.stopSignal"' new/config.json)" = "SIGINT" ]
+
+	# The bundle can be run.
+	(cd new && update_config '.process.terminal = false')
+	runc run --bundle new test_bundle
+	[ "$status" -eq 0 ]
+	[ "$output" = "hello" ]
+
+	# The bundle is not overwritten.
+	runc bundle create --image "$(pwd)/image:v1" --bundle new
+	[ "$status" -ne 0 ]
+}
+
+@test "runc bundle create [no such tag]" {
+	make_image "$(pwd)/image" v1
+	mkdir new
+
+	runc bundle create --image "$(pwd)/image:v2" --bundle new
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"image \"v2\" not found"* ]]
+}
+
+@test "runc bundle create [corrupted layer]" {
+	make_image "$(pwd)/image" v1
+	mkdir new
+	# Corrupt the second layer, keeping its size.
+	digest="$(jq -r ".manifests[0].digest" image/index.json | cut -d: -f2)"
+	layer="$(jq -r ".layers[1].digest" "image/blobs/sha256/$digest" | cut -d: -f2)"
+	chmod u+w "image/blobs/sha256/$layer"
+	sed -i 's/data/dat0/' "image/blobs/sha256/$layer"
+
+	runc bundle create --image "$(pwd)/image:v1" --bundle new
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"unexpected digest"* ]]
+	# Nothing is left of the bundle.
+	[ ! -e new/rootfs ]
+	[ ! -e new/config.json ]
+}
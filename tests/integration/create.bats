@@ -90,6 +90,20 @@ is_allowed_fdtarget() {
 	testcontainer test_busybox running
 }
 
+@test "runc create --dry-run" {
+	update_config '.mounts += [{"source": "tmpfs", "destination": "/etc", "type": "tmpfs", "options": ["tmpcopyup"]}]'
+
+	runc create --dry-run test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r .config.rootfs <<<"$output")" = "$(pwd)/rootfs" ]
+	[ "$(jq -r '.mounts[] | select(.destination == "/etc") | .op' <<<"$output")" = "tmpcopyup" ]
+	[ "$(jq -r '.mounts[] | select(.op == "mask") | .destination' <<<"$output" | head -1)" = "/proc/acpi" ]
+
+	# No container is created.
+	runc state test_busybox
+	[ "$status" -ne 0 ]
+}
+
 @test "runc create exec" {
 	runc create --console-socket "$CONSOLE_SOCKET" test_busybox
 	[ "$status" -eq 0 ]
@@ -57,6 +57,46 @@ function test_events() {
 	[[ "${lines[0]}" == *"data"* ]]
 }
 
+@test "events --format openmetrics" {
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+	init_cgroup_paths
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc events --format openmetrics test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *'# TYPE runc_cpu_usage_seconds counter'* ]]
+	[[ "$output" == *'runc_pids_current{id="test_busybox",'*'} 1'* ]]
+	[ "${lines[-1]}" = "# EOF" ]
+
+	runc events --format yaml test_busybox
+	[ "$status" -ne 0 ]
+}
+
+@test "events --listen" {
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+	command -v curl >/dev/null || skip "requires curl"
+	set_cgroups_path
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	local sock
+	sock="$(pwd)/metrics.sock"
+	(__runc events --listen "unix://$sock" test_busybox) &
+	retry 10 1 test -S "$sock"
+
+	output=$(curl -sSf --unix-socket "$sock" http://localhost/metrics)
+	[[ "$output" == *'runc_memory_usage_bytes{id="test_busybox",'* ]]
+	[[ "$output" == *"# EOF" ]]
+
+	runc delete -f test_busybox
+	[ "$status" -eq 0 ]
+	wait # for runc events to exit
+	[ ! -e "$sock" ]
+}
+
 @test "events --stats with psi data" {
 	# XXX: CPU PSI avg data only available to root.
 	requires root cgroups_v2 psi
@@ -86,6 +126,27 @@ function test_events() {
 	done
 }
 
+@test "events --pressure" {
+	requires root cgroups_v2 psi
+	init_cgroup_paths
+
+	update_config '.linux.resources.cpu |= { "quota": 1000 }'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(__runc events --pressure cpu:some:10ms:2s test_busybox >events.log) &
+	(
+		# Stress the CPU, so the container is throttled.
+		__runc exec -d test_busybox sh -c 'while :; do :; done'
+		retry 10 1 grep -q '"type":"pressure"' events.log
+		__runc delete -f test_busybox
+	) &
+	wait # for both subshells to finish
+
+	grep '"type":"pressure"' events.log | head -1 | jq -e '.data == {"resource": "cpu", "kind": "some", "stall": 10000, "window": 2000000}'
+}
+
 # See https://github.com/opencontainers/cgroups/pull/24
 @test "events --stats with hugetlb" {
 	requires cgroups_v2 cgroups_hugetlb
@@ -139,3 +200,34 @@ function test_events() {
 
 	grep -q '{"type":"oom","id":"test_busybox"}' events.log
 }
+
+@test "events --lifecycle" {
+	[ $EUID -ne 0 ] && requires rootless_cgroup
+	set_cgroups_path
+	update_config '.process.args = ["sh", "-c", "sleep 3; exit 7"]'
+
+	runc create --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(__runc events --lifecycle test_busybox >events.log) &
+
+	runc start test_busybox
+	[ "$status" -eq 0 ]
+	runc pause test_busybox
+	[ "$status" -eq 0 ]
+	runc resume test_busybox
+	[ "$status" -eq 0 ]
+	runc exec test_busybox true
+	[ "$status" -eq 0 ]
+
+	wait_for_container 10 1 test_busybox stopped
+	retry 10 1 grep -q '"type":"exited"' events.log
+	runc delete test_busybox
+	[ "$status" -eq 0 ]
+	wait # for the event logger to exit after the deleted event
+
+	mapfile -t types < <(grep -v '"type":"stats"' events.log | jq -r .type)
+	echo "${types[*]}"
+	[ "${types[*]}" = "created started paused resumed exec-started exec-exited exited deleted" ]
+	[ "$(grep '"type":"exited"' events.log | jq .data.exitCode)" = "7" ]
+}
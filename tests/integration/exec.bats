@@ -391,3 +391,33 @@ EOF
 	[ "$status" -eq 0 ]
 	[ "${lines[0]}" = "/home/tempuser" ]
 }
+
+@test "runc exec --timeout" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc exec --timeout 10s test_busybox true
+	[ "$status" -eq 0 ]
+
+	runc exec --timeout 10s test_busybox false
+	[ "$status" -eq 1 ]
+
+	runc exec --timeout 1s test_busybox sh -c 'sleep 100 & sleep 101'
+	[ "$status" -eq 124 ]
+
+	# The descendants of the exec process are killed, too.
+	runc exec test_busybox ps -o args
+	[ "$status" -eq 0 ]
+	[[ "$output" != *"sleep 10"* ]]
+
+	# The processes left by the exec process which has not timed out
+	# keep running.
+	runc exec --timeout 10s test_busybox sh -c 'sleep 102 &'
+	[ "$status" -eq 0 ]
+	runc exec test_busybox ps -o args
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"sleep 102"* ]]
+
+	runc exec --timeout 1s --detach test_busybox true
+	[ "$status" -ne 0 ]
+}
@@ -0,0 +1,98 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc gc [no containers]" {
+	runc gc
+	[ "$status" -eq 0 ]
+	[ "$output" = "[]" ]
+}
+
+@test "runc gc" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_stopped
+	[ "$status" -eq 0 ]
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_running
+	[ "$status" -eq 0 ]
+	runc create --console-socket "$CONSOLE_SOCKET" test_created
+	[ "$status" -eq 0 ]
+
+	# Emulate the container init being killed with its supervisor gone.
+	kill -9 "$(__runc state test_stopped | jq .pid)"
+	wait_for_container 10 1 test_stopped stopped
+
+	# Without --force, the exit status must be recorded.
+	runc gc
+	[ "$status" -eq 0 ]
+	[ "$output" = "[]" ]
+	testcontainer test_stopped stopped
+
+	runc gc --force --dry-run
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[].id' <<<"$output")" = "test_stopped" ]
+	[ "$(jq '.[0].removed' <<<"$output")" = "false" ]
+	testcontainer test_stopped stopped
+
+	runc gc --force
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[].id' <<<"$output")" = "test_stopped" ]
+	[ "$(jq '.[0].removed' <<<"$output")" = "true" ]
+
+	runc state test_stopped
+	[ "$status" -ne 0 ]
+	testcontainer test_running running
+	testcontainer test_created created
+}
+
+@test "runc gc [poststop hook]" {
+	update_config '.hooks |= . + {"poststop": [{"path": "/bin/sh", "args": ["/bin/sh", "-c", "touch '"$PWD"'/poststop.done"]}]}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	kill -9 "$(__runc state test_busybox | jq .pid)"
+	wait_for_container 10 1 test_busybox stopped
+
+	runc gc --force
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[].id' <<<"$output")" = "test_busybox" ]
+	[ -f "$PWD/poststop.done" ]
+}
+
+@test "runc gc [exit status recorded]" {
+	update_config '.process.args = ["sh", "-c", "exit 3"]'
+
+	runc run --keep test_busybox
+	[ "$status" -eq 3 ]
+
+	runc gc
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[].id' <<<"$output")" = "test_busybox" ]
+	[ "$(jq '.[0].removed' <<<"$output")" = "true" ]
+}
+
+@test "runc gc [incomplete state]" {
+	mkdir "$ROOT/state/test_incomplete" "$ROOT/state/test_locked"
+	touch "$ROOT/state/test_locked/state.lock"
+	touch -d '2 minutes ago' "$ROOT/state/test_incomplete" "$ROOT/state/test_locked"
+
+	# Emulate runc create still in progress.
+	flock "$ROOT/state/test_locked/state.lock" sleep 10 &
+	sleep 1
+
+	runc gc
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[].id' <<<"$output")" = "test_incomplete" ]
+	[ "$(jq '.[0].removed' <<<"$output")" = "true" ]
+	[ ! -e "$ROOT/state/test_incomplete" ]
+	[ -d "$ROOT/state/test_locked" ]
+
+	kill %1
+	wait
+}
@@ -26,11 +26,14 @@ function setup() {
 	# shellcheck disable=SC2153
 	runc="$(basename "$RUNC")"
 	local cmds=(
+		bundle
 		checkpoint
 		create
 		delete
 		events
 		exec
+		gc
+		inspect
 		kill
 		list
 		pause
@@ -41,7 +44,10 @@ function setup() {
 		spec
 		start
 		state
+		stop
 		update
+		validate
+		wait
 		features
 	)
 
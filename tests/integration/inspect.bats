@@ -0,0 +1,89 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc inspect" {
+	runc inspect test_busybox
+	[ "$status" -ne 0 ]
+
+	update_config '.linux.seccomp = {"defaultAction": "SCMP_ACT_ALLOW", "syscalls": [{"names": ["kexec_load"], "action": "SCMP_ACT_ERRNO"}]}'
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc inspect test_busybox
+	[ "$status" -eq 0 ]
+	[[ "$output" == *"Status:"*"running"* ]]
+	[[ "$output" == *"Namespaces:"* ]]
+
+	runc inspect --format json test_busybox
+	[ "$status" -eq 0 ]
+	local json="$output" pid
+	pid=$(__runc state test_busybox | jq .pid)
+	[ "$(jq -r .id <<<"$json")" = "test_busybox" ]
+	[ "$(jq .pid <<<"$json")" = "$pid" ]
+	[ "$(jq -r .seccomp <<<"$json")" = "filter" ]
+	# The inode numbers of the container namespaces are shown.
+	local ns
+	for ns in pid mnt; do
+		[ "$(jq -r ".namespaces[] | select(.type == \"$ns\") | .path" <<<"$json")" = "/proc/$pid/ns/$ns" ]
+		[ "$(jq ".namespaces[] | select(.type == \"$ns\") | .inode" <<<"$json")" = "$(stat -L -c %i "/proc/$pid/ns/$ns")" ]
+	done
+	[ "$(jq '.capabilities | index("CAP_KILL") != null' <<<"$json")" = "true" ]
+	[ "$(jq '.rootfs_mount_id > 0' <<<"$json")" = "true" ]
+
+	runc kill test_busybox KILL
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox stopped
+
+	runc inspect --format json test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r .status <<<"$output")" = "stopped" ]
+	[ "$(jq .pid <<<"$output")" = "0" ]
+	[ "$(jq .seccomp <<<"$output")" = "null" ]
+	[ "$(jq .namespaces <<<"$output")" = "null" ]
+}
+
+@test "runc inspect [userns]" {
+	requires root
+
+	update_config '	  .linux.namespaces += [{"type": "user"}]
+			| .linux.uidMappings += [{"containerID": 0, "hostID": 100000, "size": 65536}]
+			| .linux.gidMappings += [{"containerID": 0, "hostID": 200000, "size": 65536}]'
+	remap_rootfs
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc inspect --format json test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -c .uid_mappings <<<"$output")" = '[{"container_id":0,"host_id":100000,"size":65536}]' ]
+	[ "$(jq -c .gid_mappings <<<"$output")" = '[{"container_id":0,"host_id":200000,"size":65536}]' ]
+}
+
+@test "runc inspect --oci" {
+	update_config '	  .annotations = {"foo": "bar"}
+			| .process.capabilities.bounding = ["CAP_KILL"]
+			| .linux.resources.pids = {"limit": 100}
+			| .mounts += [{"destination": "/mnt", "type": "tmpfs", "source": "tmpfs", "options": ["nosuid", "size=64k"]}]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc inspect --oci test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r .annotations.foo <<<"$output")" = "bar" ]
+	[ "$(jq -c .process.capabilities.bounding <<<"$output")" = '["CAP_KILL"]' ]
+	[ "$(jq -c '.linux.namespaces | map(.type) | sort' <<<"$output")" = "$(jq -c '.linux.namespaces | map(.type) | sort' config.json)" ]
+	[ "$(jq -c '.mounts[] | select(.destination == "/mnt") | .options' <<<"$output")" = '["nosuid","size=64k"]' ]
+	if [ -v RUNC_USE_SYSTEMD ] || [ $EUID -eq 0 ]; then
+		[ "$(jq -r .linux.resources.pids.limit <<<"$output")" = "100" ]
+	fi
+}
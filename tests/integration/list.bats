@@ -52,6 +52,44 @@ function teardown() {
 	[[ "${lines[0]}" == *[,][\{]"\"ociVersion\""[:]"\""*[0-9][\.]*[0-9][\.]*[0-9]*"\""[,]"\"id\""[:]"\"test_box3\""[,]"\"pid\""[:]*[0-9][,]"\"status\""[:]*"\"running\""[,]"\"bundle\""[:]*$bundle*[,]"\"rootfs\""[:]"\""*"\""[,]"\"created\""[:]*[0-9]*[\}][\]] ]]
 }
 
+@test "list --filter and --format template" {
+	update_config '.annotations["tier"] = "frontend"'
+	ROOT=$ALT_ROOT runc run -d --console-socket "$CONSOLE_SOCKET" test_box1
+	[ "$status" -eq 0 ]
+
+	update_config '.annotations["tier"] = "backend"'
+	ROOT=$ALT_ROOT runc create --console-socket "$CONSOLE_SOCKET" test_box2
+	[ "$status" -eq 0 ]
+
+	ROOT=$ALT_ROOT runc list -q --filter status=running
+	[ "$status" -eq 0 ]
+	[ "$output" = "test_box1" ]
+
+	ROOT=$ALT_ROOT runc list -q --filter status=running --filter status=created
+	[ "$status" -eq 0 ]
+	[ "${lines[0]}" = "test_box1" ]
+	[ "${lines[1]}" = "test_box2" ]
+
+	ROOT=$ALT_ROOT runc list -q --filter annotation=tier=backend
+	[ "$status" -eq 0 ]
+	[ "$output" = "test_box2" ]
+
+	ROOT=$ALT_ROOT runc list -q --filter label=bundle="$(pwd)" --filter annotation=tier=none
+	[ "$status" -eq 0 ]
+	[ "$output" = "" ]
+
+	ROOT=$ALT_ROOT runc list --format '{{.ID}}:{{.Status}}:{{index .Annotations "tier"}}'
+	[ "$status" -eq 0 ]
+	[ "${lines[0]}" = "test_box1:running:frontend" ]
+	[ "${lines[1]}" = "test_box2:created:backend" ]
+
+	ROOT=$ALT_ROOT runc list --filter status=unknown
+	[ "$status" -ne 0 ]
+
+	ROOT=$ALT_ROOT runc list --format yaml
+	[ "$status" -ne 0 ]
+}
+
 @test "list with non-existent root fails" {
 	ROOT=/non-existent-dir runc list
 	[ "$status" -ne 0 ]
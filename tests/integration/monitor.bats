@@ -0,0 +1,96 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+	update_config '.process.args = ["sh", "-c", "sleep 1; exit 3"]'
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc run --monitor" {
+	runc run -d --console-socket "$CONSOLE_SOCKET" --monitor test_monitor
+	[ "$status" -eq 0 ]
+	testcontainer test_monitor running
+
+	wait_for_container 10 1 test_monitor stopped
+	# The exit status is recorded by the monitor.
+	runc state test_monitor
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitStatus.exitCode <<<"$output")" = "3" ]
+
+	runc delete test_monitor
+	[ "$status" -eq 0 ]
+}
+
+@test "runc create --monitor" {
+	runc create --console-socket "$CONSOLE_SOCKET" --monitor test_monitor
+	[ "$status" -eq 0 ]
+	testcontainer test_monitor created
+
+	runc start test_monitor
+	[ "$status" -eq 0 ]
+
+	# The exit status is available to runc wait on any kernel, since the
+	# monitor reaps init and records its exit status.
+	runc wait test_monitor
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitCode <<<"$output")" = "3" ]
+}
+
+@test "runc run --monitor --preserve-fds" {
+	update_config '.process.args = ["sleep", "100"]'
+
+	echo hello >preserve-fds.test
+	# fd 3 is used by bats, so we use 4
+	exec 4<preserve-fds.test
+	runc run -d --console-socket "$CONSOLE_SOCKET" --monitor --preserve-fds=2 test_monitor
+	[ "$status" -eq 0 ]
+
+	runc exec test_monitor cat /proc/1/fd/4
+	[ "$status" -eq 0 ]
+	[ "$output" = "hello" ]
+
+	runc delete --force test_monitor
+	[ "$status" -eq 0 ]
+}
+
+@test "runc run --auto-remove" {
+	update_config '.hooks |= . + {"poststop": [{"path": "/bin/sh", "args": ["/bin/sh", "-c", "touch '"$PWD"'/poststop.done"]}]}'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" --auto-remove test_monitor
+	[ "$status" -eq 0 ]
+	testcontainer test_monitor running
+
+	retry 10 1 eval "! __runc state test_monitor"
+	retry 10 1 test -f "$PWD/poststop.done"
+}
+
+@test "runc run --auto-remove [removed by runc delete]" {
+	update_config '.process.args = ["sleep", "100"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" --auto-remove test_monitor
+	[ "$status" -eq 0 ]
+
+	runc delete --force test_monitor
+	[ "$status" -eq 0 ]
+	runc state test_monitor
+	[ "$status" -ne 0 ]
+}
+
+@test "runc run --monitor [failure]" {
+	update_config '.process.args = ["/nonexistent"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" --monitor test_monitor
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"runc run failed"* ]]
+}
+
+@test "runc run --monitor [without --detach]" {
+	runc run --monitor test_monitor
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"require --detach"* ]]
+}
@@ -23,8 +23,8 @@ function teardown() {
 @test "ps" {
 	runc ps test_busybox
 	[ "$status" -eq 0 ]
-	[[ "$output" =~ UID\ +PID\ +PPID\ +C\ +STIME\ +TTY\ +TIME\ +CMD+ ]]
-	[[ "$output" == *"$(id -un 2>/dev/null)"*[0-9]* ]]
+	[[ "${lines[0]}" =~ PID\ +CPID\ +PPID\ +UID\ +GID\ +STAT\ +STARTED\ +RSS\ +TIME\ +CMD ]]
+	[[ "${lines[1]}" =~ [0-9]+\ +1\ +[0-9]+\ +0\ +0\ +S\ .*\ sh$ ]]
 }
 
 @test "ps -f json" {
@@ -33,6 +33,25 @@ function teardown() {
 	[[ "$output" =~ [0-9]+ ]]
 }
 
+@test "ps -f json-detailed" {
+	runc ps -f json-detailed test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq -r '.[0].containerPid' <<<"$output")" = "1" ]
+	[ "$(jq -r '.[0].uid' <<<"$output")" = "0" ]
+	[ "$(jq -r '.[0].cmdline[0]' <<<"$output")" = "sh" ]
+	jq -e '.[0].capabilities | index("CAP_KILL")' <<<"$output"
+}
+
+@test "ps -ef" {
+	runc ps test_busybox -ef
+	[ "$status" -eq 0 ]
+	[[ "$output" =~ UID\ +PID\ +PPID\ +C\ +STIME\ +TTY\ +TIME\ +CMD+ ]]
+	[[ "$output" == *"$(id -un 2>/dev/null)"*[0-9]* ]]
+
+	runc ps -f json-detailed test_busybox -ef
+	[ "$status" -ne 0 ]
+}
+
 @test "ps -e -x" {
 	runc ps test_busybox -e -x
 	[ "$status" -eq 0 ]
@@ -21,6 +21,40 @@ function teardown() {
 	[ "$status" -eq 0 ]
 }
 
+@test "spec generation with options" {
+	rm config.json
+	runc spec --args /bin/echo --args "Hello, World" --env FOO=bar --hostname myhost \
+		--mount tmpfs:/mnt:size=1m --cap-drop ALL --readonly=false --annotation foo=bar
+	[ "$status" -eq 0 ]
+
+	[ "$(jq -c .process.args config.json)" = '["/bin/echo","Hello, World"]' ]
+	jq -e '.process.env | index("FOO=bar")' config.json
+	[ "$(jq -r .hostname config.json)" = "myhost" ]
+	[ "$(jq -c '.mounts[-1]' config.json)" = '{"destination":"/mnt","type":"tmpfs","source":"tmpfs","options":["size=1m"]}' ]
+	[ "$(jq -c .process.capabilities config.json)" = '{}' ]
+	[ "$(jq .root.readonly config.json)" = "null" ]
+	[ "$(jq -r .annotations.foo config.json)" = "bar" ]
+}
+
+@test "spec generation --from" {
+	runc spec --env FOO=bar
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"exists"* ]]
+
+	# Patch in place.
+	runc spec --from config.json --env FOO=bar --memory 64m
+	[ "$status" -eq 0 ]
+	jq -e '.process.env | index("FOO=bar")' config.json
+	[ "$(jq .linux.resources.memory.limit config.json)" -eq 67108864 ]
+	[ "$(jq -c .process.args config.json)" = '["/bin/echo","Hello World"]' ]
+
+	mkdir new
+	runc spec --from config.json --bundle new --args /bin/true
+	[ "$status" -eq 0 ]
+	[ "$(jq -c .process.args new/config.json)" = '["/bin/true"]' ]
+	jq -e '.process.env | index("FOO=bar")' new/config.json
+}
+
 @test "spec validator" {
 	requires rootless_no_features
 
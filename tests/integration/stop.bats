@@ -0,0 +1,65 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc stop [graceful]" {
+	update_config '.process.args = ["sh", "-c", "trap \"exit 3\" TERM; while :; do sleep 0.1; done"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	testcontainer test_busybox running
+
+	runc stop --timeout 30s test_busybox
+	[ "$status" -eq 3 ]
+	testcontainer test_busybox stopped
+}
+
+@test "runc stop [timeout]" {
+	# As PID 1, sleep ignores SIGTERM.
+	update_config '.process.args = ["sleep", "1h"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc stop --timeout 1s test_busybox
+	[ "$status" -eq 137 ]
+	testcontainer test_busybox stopped
+}
+
+@test "runc stop [paused]" {
+	requires cgroups_freezer
+	if [ $EUID -ne 0 ]; then
+		requires rootless_cgroup
+		set_cgroups_path
+	fi
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	runc pause test_busybox
+	[ "$status" -eq 0 ]
+
+	runc stop --signal KILL test_busybox
+	[ "$status" -eq 137 ]
+	testcontainer test_busybox stopped
+}
+
+@test "runc stop [stopped]" {
+	update_config '.process.args = ["true"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+	wait_for_container 10 1 test_busybox stopped
+
+	# Nothing has recorded the exit status.
+	runc stop test_busybox
+	[ "$status" -eq 255 ]
+	[[ "$output" == *"exit status is unknown"* ]]
+}
@@ -0,0 +1,50 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc validate" {
+	runc validate
+	[ "$status" -eq 0 ]
+	[ "$output" = "" ]
+
+	runc validate --format json
+	[ "$status" -eq 0 ]
+	[ "$(jq .valid <<<"$output")" = "true" ]
+	[ "$(jq '.errors | length' <<<"$output")" -eq 0 ]
+}
+
+@test "runc validate [multiple errors]" {
+	update_config '	  .hostname = "myhost"
+			| .linux.namespaces -= [{"type": "uts"}]
+			| .linux.sysctl = {"fs/file-max": "1", "no.such.sysctl": "1"}'
+
+	runc validate
+	[ "$status" -ne 0 ]
+	[[ "$output" == *"error: /hostname: "* ]]
+	[[ "$output" == *"error: /linux/sysctl/no.such.sysctl: "* ]]
+	[[ "$output" == *"3 error(s) found"* ]]
+
+	runc validate --format json
+	[ "$status" -ne 0 ]
+	[ "$(head -1 <<<"$output" | jq .valid)" = "false" ]
+	[ "$(head -1 <<<"$output" | jq -r '.errors[1].path')" = "/linux/sysctl/fs~1file-max" ]
+}
+
+@test "runc validate [seccomp]" {
+	update_config '.linux.seccomp = {
+				"defaultAction": "SCMP_ACT_ALLOW",
+				"syscalls": [{"names": [""], "action": "SCMP_ACT_ERRNO"}]
+			}'
+
+	runc validate --format json
+	[ "$status" -ne 0 ]
+	[ "$(head -1 <<<"$output" | jq -r '.errors[0].path')" = "/linux/seccomp/syscalls/0/names/0" ]
+}
@@ -0,0 +1,69 @@
+#!/usr/bin/env bats
+
+load helpers
+
+function setup() {
+	setup_busybox
+}
+
+function teardown() {
+	teardown_bundle
+}
+
+@test "runc wait [not running]" {
+	runc wait test_busybox
+	[ "$status" -ne 0 ]
+}
+
+@test "runc wait [exit code]" {
+	# Need PIDFD_INFO_EXIT to get the exit status of a non-child.
+	requires_kernel 6.15
+	update_config '.process.args = ["sh", "-c", "sleep 1; exit 42"]'
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	runc wait test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitCode <<<"$output")" = "42" ]
+	[ "$(jq -r .exitSignal <<<"$output")" = "null" ]
+
+	testcontainer test_busybox stopped
+	# The exit status is recorded in the state.
+	runc state test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitStatus.exitCode <<<"$output")" = "42" ]
+}
+
+@test "runc wait [killed by signal]" {
+	requires_kernel 6.15
+
+	runc run -d --console-socket "$CONSOLE_SOCKET" test_busybox
+	[ "$status" -eq 0 ]
+
+	(
+		sleep 1
+		__runc kill test_busybox KILL
+	) &
+
+	runc wait test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitCode <<<"$output")" = "137" ]
+	[ "$(jq -r .exitSignal <<<"$output")" = "SIGKILL" ]
+}
+
+@test "runc run --keep records exit status" {
+	update_config '.process.args = ["sh", "-c", "exit 3"]'
+
+	runc run --keep test_busybox
+	[ "$status" -eq 3 ]
+
+	runc state test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitStatus.exitCode <<<"$output")" = "3" ]
+
+	# runc wait returns the recorded status of a stopped container.
+	runc wait test_busybox
+	[ "$status" -eq 0 ]
+	[ "$(jq .exitCode <<<"$output")" = "3" ]
+}
@@ -3,6 +3,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/opencontainers/cgroups"
 	"github.com/opencontainers/runc/libcontainer/intelrdt"
 )
@@ -14,6 +16,33 @@ type Event struct {
 	Data any    `json:"data,omitempty"`
 }
 
+// Lifecycle is the data of a container lifecycle event, which is one of
+// "created", "started", "paused", "resumed", "exec-started", "exec-exited",
+// "exited", or "deleted".
+type Lifecycle struct {
+	Timestamp time.Time `json:"timestamp"`
+	// Pid is the container's init, or an additional process for
+	// "exec-started" and "exec-exited" events.
+	Pid int `json:"pid,omitempty"`
+	// ExitCode, ExitSignal and CoreDumped describe how the process has
+	// ended, for "exited" and "exec-exited" events (if known).
+	ExitCode   *int   `json:"exitCode,omitempty"`
+	ExitSignal string `json:"exitSignal,omitempty"`
+	CoreDumped bool   `json:"coreDumped,omitempty"`
+}
+
+// Pressure is the data of a "pressure" event, describing the PSI trigger
+// which has fired.
+type Pressure struct {
+	// Resource is one of "cpu", "memory", or "io".
+	Resource string `json:"resource"`
+	// Kind is either "some" or "full".
+	Kind string `json:"kind"`
+	// Stall and Window are the trigger thresholds, in microseconds.
+	Stall  int64 `json:"stall"`
+	Window int64 `json:"window"`
+}
+
 // Stats is the runc specific stats structure for stability when encoding and decoding stats.
 type Stats struct {
 	CPU               Cpu                 `json:"cpu"`
@@ -8,7 +8,10 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync/atomic"
+	"time"
 
+	"github.com/opencontainers/cgroups"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	selinux "github.com/opencontainers/selinux/go-selinux"
 	"github.com/sirupsen/logrus"
@@ -182,11 +185,23 @@ func createPidFile(path string, process *libcontainer.Process) error {
 }
 
 func createContainer(cmd *cli.Command, id string, spec *specs.Spec) (*libcontainer.Container, error) {
+	config, err := createLibcontainerConfig(cmd, id, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	root := cmd.String("root")
+	return libcontainer.Create(root, id, config)
+}
+
+// createLibcontainerConfig converts spec to the configuration of the
+// container with the given id.
+func createLibcontainerConfig(cmd *cli.Command, id string, spec *specs.Spec) (*configs.Config, error) {
 	rootlessCg, err := shouldUseRootlessCgroupManager(cmd)
 	if err != nil {
 		return nil, err
 	}
-	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
+	return specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
 		CgroupName:       id,
 		UseSystemdCgroup: cmd.Bool("systemd-cgroup"),
 		NoPivotRoot:      cmd.Bool("no-pivot"),
@@ -195,12 +210,6 @@ func createContainer(cmd *cli.Command, id string, spec *specs.Spec) (*libcontain
 		RootlessEUID:     os.Geteuid() != 0,
 		RootlessCgroups:  rootlessCg,
 	})
-	if err != nil {
-		return nil, err
-	}
-
-	root := cmd.String("root")
-	return libcontainer.Create(root, id, config)
 }
 
 type runner struct {
@@ -218,6 +227,10 @@ type runner struct {
 	notifySocket    *notifySocket
 	criuOpts        *libcontainer.CriuOpts
 	subCgroupPaths  map[string]string
+	timeout         time.Duration
+	timeoutCgroup   string
+	// timedOut is set if the process has been killed because of timeout.
+	timedOut bool
 }
 
 func (r *runner) run(config *specs.Process) (_ int, retErr error) {
@@ -321,9 +334,58 @@ func (r *runner) run(config *specs.Process) (_ int, retErr error) {
 	if detach {
 		return 0, nil
 	}
+	var timedOut atomic.Bool
+	if r.timeout > 0 {
+		t := time.AfterFunc(r.timeout, func() {
+			timedOut.Store(true)
+			r.killProcessTree(process)
+		})
+		defer t.Stop()
+	}
 	// For non-detached container, we should forward signals to the container.
 	handler := <-handlerCh
-	return handler.forward(process, tty)
+	ws, err := handler.forward(process, tty)
+	if err != nil {
+		return -1, err
+	}
+	// Unless the container is about to be destroyed, record how its init
+	// has ended, since nobody else is able to obtain this information.
+	if r.init && !r.shouldDestroy {
+		if err := r.container.RecordExit(ws); err != nil {
+			logrus.Warn(err)
+		}
+	} else if !r.init {
+		if err := r.container.RecordExecExit(process, ws); err != nil {
+			logrus.Warn(err)
+		}
+	}
+	if timedOut.Load() {
+		r.timedOut = true
+		return timeoutExitCode, nil
+	}
+	return utils.ExitStatus(ws), nil
+}
+
+// killProcessTree kills the process and all its descendants, by killing
+// all processes in r.timeoutCgroup, or, if it is not available, the process
+// group of the process (as runc init makes each process a session leader).
+func (r *runner) killProcessTree(p *libcontainer.Process) {
+	logrus.Debugf("process has not exited in %s, killing it", r.timeout)
+	if r.timeoutCgroup != "" {
+		err := cgroups.WriteFile(r.timeoutCgroup, "cgroup.kill", "1")
+		if err == nil {
+			return
+		}
+		logrus.Warnf("unable to kill the process tree: %v", err)
+	}
+	pid, err := p.Pid()
+	if err != nil {
+		logrus.Warnf("unable to kill the process tree: %v", err)
+		return
+	}
+	if err := unix.Kill(-pid, unix.SIGKILL); err != nil {
+		logrus.Warnf("unable to kill the process tree: %v", os.NewSyscallError("kill", err))
+	}
 }
 
 func (r *runner) destroy() {
@@ -379,6 +441,10 @@ const (
 )
 
 func startContainer(cmd *cli.Command, action CtAct, criuOpts *libcontainer.CriuOpts) (int, error) {
+	monitor, err := newMonitor(cmd)
+	if err != nil {
+		return -1, err
+	}
 	if err := revisePidFile(cmd); err != nil {
 		return -1, err
 	}
@@ -414,7 +480,8 @@ func startContainer(cmd *cli.Command, action CtAct, criuOpts *libcontainer.CriuO
 	}
 
 	r := &runner{
-		enableSubreaper: !cmd.Bool("no-subreaper"),
+		// The monitor only reaps the container init.
+		enableSubreaper: !cmd.Bool("no-subreaper") && monitor == nil,
 		shouldDestroy:   !cmd.Bool("keep"),
 		container:       container,
 		listenFDs:       activation.Files(), // On-demand socket activation.
@@ -428,7 +495,11 @@ func startContainer(cmd *cli.Command, action CtAct, criuOpts *libcontainer.CriuO
 		criuOpts:        criuOpts,
 		init:            true,
 	}
-	return r.run(spec.Process)
+	status, err := r.run(spec.Process)
+	if err == nil && monitor != nil {
+		monitor.run(container)
+	}
+	return status, err
 }
 
 func setupPidfdSocket(process *libcontainer.Process, sockpath string) (_clean func(), _ error) {
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/opencontainers/runc/internal/specerr"
+	"github.com/opencontainers/runc/libcontainer/configs/validate"
+	"github.com/opencontainers/runc/libcontainer/specconv"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/urfave/cli/v3"
+)
+
+// validationProblem is an error or a warning found by runc validate.
+type validationProblem struct {
+	// Path is a JSON pointer to the offending value in the spec, if known.
+	Path string `json:"path,omitempty"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// validationResult is the result of runc validate.
+type validationResult struct {
+	// Valid tells whether the bundle is valid (it may still have warnings).
+	Valid    bool                `json:"valid"`
+	Errors   []validationProblem `json:"errors"`
+	Warnings []validationProblem `json:"warnings"`
+}
+
+var validateCommand = &cli.Command{
+	Name:  "validate",
+	Usage: "check the bundle configuration for errors",
+	ArgsUsage: `
+
+The bundle is specified via the "--bundle" option (default: the current
+directory).`,
+	Description: `The validate command checks the bundle configuration (` + specConfig + `) in the
+same way as runc create does, and reports all the errors found, without
+creating a container or otherwise changing the host.
+
+Each error is reported together with a JSON pointer (RFC 6901) to the
+offending value in ` + specConfig + `, if known, such as /linux/sysctl/kernel.shmmax.`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:    "bundle",
+			Aliases: []string{"b"},
+			Value:   "",
+			Usage:   `path to the root of the bundle directory, defaults to the current directory`,
+		},
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "text",
+			Usage:   `select one of: text or json`,
+		},
+	},
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		if err := checkArgs(cmd, 0, exactArgs); err != nil {
+			return err
+		}
+		format := cmd.String("format")
+		switch format {
+		case "text", "json":
+		default:
+			return errors.New("invalid format option")
+		}
+		if bundle := cmd.String("bundle"); bundle != "" {
+			if err := os.Chdir(bundle); err != nil {
+				return err
+			}
+		}
+		errs, warns := validateBundle(cmd)
+		res := validationResult{
+			Valid:    len(errs) == 0,
+			Errors:   toValidationProblems(errs),
+			Warnings: toValidationProblems(warns),
+		}
+
+		if format == "json" {
+			if err := json.NewEncoder(os.Stdout).Encode(res); err != nil {
+				return err
+			}
+		} else {
+			for _, p := range res.Errors {
+				fmt.Println("error:", p)
+			}
+			for _, p := range res.Warnings {
+				fmt.Println("warning:", p)
+			}
+		}
+		if !res.Valid {
+			return fmt.Errorf("%s is invalid: %d error(s) found", specConfig, len(errs))
+		}
+		return nil
+	},
+}
+
+func (p validationProblem) String() string {
+	if p.Path == "" {
+		return p.Message
+	}
+	return p.Path + ": " + p.Message
+}
+
+func toValidationProblems(errs []error) []validationProblem {
+	problems := make([]validationProblem, 0, len(errs))
+	for _, err := range errs {
+		problems = append(problems, validationProblem{
+			Path:    specerr.Path(err),
+			Message: err.Error(),
+		})
+	}
+	return problems
+}
+
+// validateBundle checks the spec in the current directory, and returns
+// all the errors and warnings found.
+func validateBundle(cmd *cli.Command) (errs, warns []error) {
+	f, err := os.Open(specConfig)
+	if err != nil {
+		return []error{err}, nil
+	}
+	defer f.Close()
+	var spec *specs.Spec
+	if err := json.NewDecoder(f).Decode(&spec); err != nil {
+		return []error{fmt.Errorf("unable to parse %s: %w", specConfig, err)}, nil
+	}
+	if spec == nil {
+		return []error{errors.New("config cannot be null")}, nil
+	}
+
+	if err := validateProcessSpec(spec.Process); err != nil {
+		errs = append(errs, specerr.WithPath(err, "process"))
+	}
+	if spec.Process != nil {
+		for i, rlimit := range spec.Process.Rlimits {
+			if _, err := createLibContainerRlimit(rlimit); err != nil {
+				errs = append(errs, specerr.WithPath(err, "process", "rlimits", i, "type"))
+			}
+		}
+	}
+
+	// Check seccomp separately, so its errors do not prevent the other
+	// checks from being run.
+	if spec.Linux != nil && spec.Linux.Seccomp != nil {
+		if _, err := specconv.SetupSeccomp(spec.Linux.Seccomp); err != nil {
+			errs = append(errs, validate.Unjoin(err)...)
+		}
+		linux := *spec.Linux
+		linux.Seccomp = nil
+		s := *spec
+		s.Linux = &linux
+		spec = &s
+	}
+
+	rootlessCg, err := shouldUseRootlessCgroupManager(cmd)
+	if err != nil {
+		return append(errs, err), nil
+	}
+	config, err := specconv.CreateLibcontainerConfig(&specconv.CreateOpts{
+		// As there is no container, use a placeholder for its cgroup name.
+		CgroupName:       "runc-validate",
+		UseSystemdCgroup: cmd.Bool("systemd-cgroup"),
+		Spec:             spec,
+		RootlessEUID:     os.Geteuid() != 0,
+		RootlessCgroups:  rootlessCg,
+	})
+	if err != nil {
+		// The configuration checks can't be run without a configuration.
+		return append(errs, validate.Unjoin(err)...), nil
+	}
+	configErrs, warns := validate.ValidateAll(config)
+	return append(errs, configErrs...), warns
+}
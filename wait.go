@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/opencontainers/runc/libcontainer"
+	"github.com/urfave/cli/v3"
+	"golang.org/x/sys/unix"
+)
+
+// exitStatus represents how the container's init process has ended.
+type exitStatus struct {
+	// ExitCode is the exit code of init, or 128+N if init was killed by signal N.
+	ExitCode int `json:"exitCode"`
+	// ExitSignal is the name of the signal which killed init, if any.
+	ExitSignal string `json:"exitSignal,omitempty"`
+	// CoreDumped tells whether init has dumped core.
+	CoreDumped bool `json:"coreDumped,omitempty"`
+	// ExitedAt is the time when init has exited.
+	ExitedAt time.Time `json:"exitedAt"`
+}
+
+func convertExitStatus(s *libcontainer.ExitStatus) *exitStatus {
+	if s == nil {
+		return nil
+	}
+	es := &exitStatus{
+		ExitCode:   s.Code,
+		CoreDumped: s.CoreDumped,
+		ExitedAt:   s.ExitedAt,
+	}
+	if s.Signal != 0 {
+		es.ExitSignal = unix.SignalName(s.Signal)
+	}
+	return es
+}
+
+var waitCommand = &cli.Command{
+	Name:  "wait",
+	Usage: "wait for the container's init process to exit and print its exit status",
+	ArgsUsage: `<container-id>
+
+Where "<container-id>" is the name for the instance of the container.`,
+	Description: `The wait command blocks until the init process of the container exits,
+and then outputs its exit status in a JSON format. The exit status is also
+recorded in the container state, so it is later shown by runc state.
+
+If init is reaped by its parent before runc wait notices it has exited, the
+exit status can only be obtained on Linux 6.15 or later, or if it was recorded
+by runc run.`,
+	// Disable comma as separator for slice flags.
+	DisableSliceFlagSeparator: true,
+	Action: func(_ context.Context, cmd *cli.Command) error {
+		if err := checkArgs(cmd, 1, exactArgs); err != nil {
+			return err
+		}
+		container, err := getContainer(cmd)
+		if err != nil {
+			return err
+		}
+		s, err := container.Wait()
+		if err != nil {
+			if errors.Is(err, libcontainer.ErrExitUnknown) {
+				return fmt.Errorf("container %s has exited, but its exit status is unknown", container.ID())
+			}
+			return err
+		}
+		data, err := json.MarshalIndent(convertExitStatus(s), "", "  ")
+		if err != nil {
+			return err
+		}
+		os.Stdout.Write(data)
+		return nil
+	},
+}